@@ -10,16 +10,34 @@ package aliyun
 
 import (
 	"bytes"
-	"encoding/base64"
+	"cmp"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"io/fs"
+	"log"
 	"mime/multipart"
-	"path/filepath"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/b64"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/httplog"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/keylock"
+	"github.com/zjguoxin/gosuploader/internal/magic"
+	"github.com/zjguoxin/gosuploader/internal/mime"
 )
 
 // AliUploader 阿里云OSS上传处理器
@@ -28,47 +46,147 @@ type AliUploader struct {
 	bucket   *oss.Bucket
 	config   config.AliyunConfig
 	endpoint string
+	keys     keylock.Locker // WithKey上传时的按key锁，仅在本实例内生效，不是分布式锁
+
+	credentials *credentialProvider // 配置了凭证提供函数时的凭证缓存
+}
+
+// limits OSS的上传限制：PutObject最大5GB，分片100KB~5GB，最多10000片
+var limits = config.ProviderLimits{
+	MaxSinglePutSize: 5 << 30,
+	MinPartSize:      100 << 10,
+	MaxPartSize:      5 << 30,
+	MaxParts:         10000,
+}
+
+// ProviderLimits 返回OSS的上传限制
+func (u *AliUploader) ProviderLimits() config.ProviderLimits {
+	return limits
 }
 
 // New 创建阿里云OSS上传处理器
 func New(cfg config.AliyunConfig) (*AliUploader, error) {
-	// 验证必要配置
-	if cfg.Endpoint == "" || cfg.AccessKeyID == "" || cfg.AccessKeySecret == "" || cfg.BucketName == "" {
-		return nil, errors.New("aliyun OSS configuration is incomplete")
+	// 验证必要配置，仅为警告时继续创建
+	if err := cfg.Validate(); err != nil && !errors.Is(err, config.ErrConfigWarning) {
+		return nil, err
+	}
+	if !keygen.ValidStrategy(cfg.KeyStrategy) {
+		return nil, fmt.Errorf("unsupported key strategy: %s", cfg.KeyStrategy)
 	}
+	if !keygen.ValidSeparator(cfg.KeySeparator) {
+		return nil, fmt.Errorf("invalid key separator: %q", cfg.KeySeparator)
+	}
+	if cfg.KeyStrategy == config.KeyStrategySequential && !cfg.Sequence.Persistent() {
+		return nil, errors.New("sequential key strategy requires a Sequence with a Store, otherwise keys restart at 1 and overwrite existing objects")
+	}
+	if err := cfg.RoutingRules.Validate(); err != nil {
+		return nil, err
+	}
+	// 只配置了地域时由地域推导访问域名，之后各处统一使用Endpoint
+	cfg.Endpoint = cfg.ResolvedEndpoint()
+
+	if cfg.TLSSkipVerify {
+		log.Println(config.TLSSkipVerifyWarning)
+	}
+	// 使用STS凭证提供函数时由SDK在每次请求前获取凭证
+	options := []oss.ClientOption{
+		oss.ForcePathStyle(cfg.ForcePathStyle),
+		oss.InsecureSkipVerify(cfg.TLSSkipVerify),
+		oss.UserAgent(cmp.Or(cfg.UserAgent, config.DefaultUserAgent)),
+	}
+	var provider *credentialProvider
+	switch {
+	case cfg.CredentialsProvider != nil:
+		provider = &credentialProvider{fetch: cfg.CredentialsProvider}
+	case cfg.CredentialProvider != nil:
+		// 兼容已弃用的 CredentialProvider：凭证没有过期时间，只在403后重新获取
+		fetch := cfg.CredentialProvider
+		provider = &credentialProvider{fetch: func() (config.Credentials, error) {
+			id, secret, token, err := fetch(context.Background())
+			return config.Credentials{AccessKeyID: id, AccessKeySecret: secret, SecurityToken: token}, err
+		}}
+	}
+	if provider != nil {
+		options = append(options, oss.SetCredentialsProvider(provider))
+	}
+	options = append(options, timeoutOptions(cfg)...)
 
 	// 创建OSS客户端
-	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret)
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OSS client: %w", err)
 	}
 
+	if cfg.AutoCreateBucket {
+		if err := ensureBucket(client, cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	// 获取存储空间
 	bucket, err := client.Bucket(cfg.BucketName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bucket: %w", err)
 	}
 
-	// 构建endpoint
-	endpoint := "https://" + cfg.BucketName + "." + cfg.Endpoint
-	if cfg.Domain != "" {
-		endpoint = "https://" + cfg.Domain
-	}
-
 	return &AliUploader{
 		client:   client,
 		bucket:   bucket,
 		config:   cfg,
-		endpoint: endpoint,
+		endpoint: baseURL(cfg),
+
+		credentials: provider,
 	}, nil
 }
 
+// timeoutOptions 将配置中的超时转换为客户端选项，为0的项保留SDK默认值
+// oss.Timeout 只接受整秒且会同时修改多项超时，因此直接设置对应字段
+func timeoutOptions(cfg config.AliyunConfig) []oss.ClientOption {
+	var options []oss.ClientOption
+	if cfg.ConnectTimeout > 0 {
+		options = append(options, func(c *oss.Client) {
+			c.Config.HTTPTimeout.ConnectTimeout = cfg.ConnectTimeout
+		})
+	}
+	if cfg.ReadWriteTimeout > 0 {
+		options = append(options, func(c *oss.Client) {
+			c.Config.HTTPTimeout.ReadWriteTimeout = cfg.ReadWriteTimeout
+			c.Config.HTTPTimeout.HeaderTimeout = cfg.ReadWriteTimeout
+		})
+	}
+	// 当前SDK没有请求总超时选项，设置时改用带Timeout的http.Client；记录请求(DebugHTTP)同样需要自定义Transport
+	if cfg.RequestTimeout > 0 || cfg.DebugHTTP {
+		connectTimeout := cfg.ConnectTimeout
+		if connectTimeout == 0 {
+			connectTimeout = 30 * time.Second
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.ResponseHeaderTimeout = cfg.ReadWriteTimeout
+		transport.MaxIdleConnsPerHost = 100
+		if cfg.TLSSkipVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		var rt http.RoundTripper = transport
+		if cfg.DebugHTTP {
+			rt = httplog.Wrap(transport, cfg.Logger)
+		}
+		options = append(options, oss.HTTPClient(&http.Client{Transport: rt, Timeout: cfg.RequestTimeout}))
+	}
+	return options
+}
+
 // UploadFile 上传multipart表单文件
-func (u *AliUploader) UploadFile(file *multipart.FileHeader) (string, error) {
+func (u *AliUploader) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
 	if file == nil {
 		return "", errors.New("file header cannot be nil")
 	}
 
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+
 	// 打开上传文件
 	src, err := file.Open()
 	if err != nil {
@@ -77,29 +195,64 @@ func (u *AliUploader) UploadFile(file *multipart.FileHeader) (string, error) {
 	defer src.Close()
 
 	// 生成存储对象键
-	objectKey := u.generateObjectKey(file.Filename)
+	objectKey, body, err := u.objectKey(file.Filename, src, o)
+	if err != nil {
+		return "", err
+	}
 
 	// 上传文件到OSS
-	err = u.bucket.PutObject(objectKey, src)
-	if err != nil {
+	if err = u.put(objectKey, body, file.Size, o); err != nil {
 		return "", fmt.Errorf("failed to upload file to OSS: %w", err)
 	}
 
 	return u.getFileURL(objectKey), nil
 }
 
+// UploadFSFile 上传 fs.File 的内容，适用于 os.DirFS、embed.FS 等非HTTP场景
+// key为空时按文件名生成存储key，ctx取消或超时时中止上传；调用方负责关闭f
+func (u *AliUploader) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	file, err := fsfile.Open(f, mime.Resolver{Overrides: u.config.ContentTypeOverrides}, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	o.ContentType = file.ContentType
+
+	objectKey, body, err := u.objectKey(file.Name, file.Reader, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	result := config.UploadResult{Size: file.Size, ContentType: file.ContentType, RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	if err = u.put(objectKey, body, file.Size, o); err != nil {
+		return config.UploadResult{}, fmt.Errorf("failed to upload file to OSS: %w", err)
+	}
+	result.Path = u.getFileURL(objectKey)
+	return result, nil
+}
+
 // UploadBinary 上传二进制数据
-func (u *AliUploader) UploadBinary(filename string, content []byte) (string, error) {
+func (u *AliUploader) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
 	if len(content) == 0 {
 		return "", errors.New("content cannot be empty")
 	}
 
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+
 	// 生成存储对象键
-	objectKey := u.generateObjectKey(filename)
+	objectKey, body, err := u.objectKey(filename, bytes.NewReader(content), o)
+	if err != nil {
+		return "", err
+	}
 
 	// 上传文件到OSS
-	err := u.bucket.PutObject(objectKey, bytes.NewReader(content))
-	if err != nil {
+	if err = u.put(objectKey, body, int64(len(content)), o); err != nil {
 		return "", fmt.Errorf("failed to upload binary to OSS: %w", err)
 	}
 
@@ -107,49 +260,350 @@ func (u *AliUploader) UploadBinary(filename string, content []byte) (string, err
 }
 
 // UploadBase64 上传Base64编码的文件
-func (u *AliUploader) UploadBase64(filename string, base64Str string) (string, error) {
+func (u *AliUploader) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
 	if base64Str == "" {
 		return "", errors.New("base64 content cannot be empty")
 	}
 
 	// 解码Base64数据
-	data, err := base64.StdEncoding.DecodeString(base64Str)
+	data, err := b64.Decode(base64Str, u.config.MaxBase64Length)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	return u.UploadBinary(filename, data)
+	return u.UploadBinary(filename, data, opts...)
 }
 
-// Delete 删除OSS文件
+// Delete 删除OSS文件，对软链接只删除链接本身，不影响目标对象
 func (u *AliUploader) Delete(objectKey string) error {
 	if objectKey == "" {
 		return errors.New("object key cannot be empty")
 	}
 
-	err := u.bucket.DeleteObject(objectKey)
+	err := u.bucket.DeleteObject(u.config.KeyCharPolicy.Apply(objectKey))
+	if isObjectLocked(err) {
+		return fmt.Errorf("failed to delete OSS object %s: %w: %w", objectKey, config.ErrObjectLocked, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete OSS object: %w", withRequestID(err))
+	}
+
+	return nil
+}
+
+// DeleteIfExists 删除OSS文件，OSS删除不存在的对象同样成功，无法区分对象是否存在，
+// 因此不做存在性检查，成功时总是返回true
+func (u *AliUploader) DeleteIfExists(ctx context.Context, objectKey string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if err := u.Delete(objectKey); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// objectKey 确定存储对象键，优先使用上传选项指定的key
+// 指定的key经过规范化校验，不合法时返回 config.ErrInvalidKey
+// content-addressed策略下读取r按内容摘要生成key，返回从头读取同一内容的Reader
+func (u *AliUploader) objectKey(originalName string, r io.Reader, o *config.UploadOptions) (string, io.Reader, error) {
+	if o.Key != "" {
+		key, err := keygen.PolicyKey(o.Key, u.config.KeyCharPolicy)
+		return key, r, err
+	}
+	if u.config.KeyStrategy == config.KeyStrategyContentAddressed {
+		return keygen.ContentKey(o.FilenameOr(originalName), r)
+	}
+	key, err := u.generateObjectKey(o.FilenameOr(originalName), o.ContentType)
+	return u.config.KeyCharPolicy.Apply(key), r, err
+}
+
+// generateObjectKey 生成存储对象键，contentType为空时按扩展名推断，用于匹配路由规则
+func (u *AliUploader) generateObjectKey(originalName, contentType string) (string, error) {
+	// 生成[路由前缀/]日期路径和唯一文件名
+	return keygen.GenerateKey(originalName, keygen.KeygenOptions{
+		DateFormat: "2006/01/02",
+		Prefix:     u.config.RoutingRules.Prefix(originalName, cmp.Or(contentType, mime.TypeByFilename(originalName))),
+		Strategy:   u.config.KeyStrategy,
+		Sequence:   u.config.Sequence,
+		Separator:  u.config.KeySeparator,
+	})
+}
+
+// BucketUsage 获取存储空间已用容量(字节)与对象数量
+func (u *AliUploader) BucketUsage() (int64, int64, error) {
+	stat, err := u.client.GetBucketStat(u.config.BucketName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get OSS bucket stat: %w", err)
+	}
+	return stat.Storage, stat.ObjectCount, nil
+}
+
+// Ping 检查存储空间是否可访问
+func (u *AliUploader) Ping(ctx context.Context) error {
+	if _, err := u.client.GetBucketInfo(u.config.BucketName, oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to ping OSS bucket: %w", err)
+	}
+	return nil
+}
+
+// put 上传数据到OSS，size为内容长度
+func (u *AliUploader) put(objectKey string, r io.Reader, size int64, o *config.UploadOptions) error {
+	if err := limits.CheckSinglePut(size); err != nil {
+		return err
+	}
+	// OSS的合规保留(WORM)只能在存储桶级别配置，无法按对象指定
+	if o.Retention != nil {
+		return fmt.Errorf("OSS does not support per-object retention, configure bucket WORM policy instead: %w", config.ErrNotSupported)
+	}
+	// OSS的服务端加密只支持OSS托管密钥与KMS，不接受请求中携带的客户密钥
+	if o.CustomerKey != nil {
+		return fmt.Errorf("OSS does not support customer-provided encryption keys, use KMS instead: %w", config.ErrNotSupported)
+	}
+	if o.Key != "" {
+		defer u.keys.Lock(objectKey)()
+	}
+	// 内容寻址的key由内容决定，对象已存在时内容相同，跳过写入
+	if o.Key == "" && u.config.KeyStrategy == config.KeyStrategyContentAddressed {
+		if exists, err := u.Exists(objectKey); err != nil || exists {
+			return err
+		}
+	}
+
+	ctx, cancel := o.Context(size)
+	defer cancel()
+
+	err := u.putObject(ctx, objectKey, r, size, o)
+	// STS凭证失效时刷新凭证，内容可重新读取时重试一次
+	if u.refreshCredentials(err) {
+		if s, ok := r.(io.Seeker); ok {
+			if _, seekErr := s.Seek(0, io.SeekStart); seekErr == nil {
+				err = u.putObject(ctx, objectKey, r, size, o)
+			}
+		}
+	}
+	return withRequestID(err)
+}
+
+// putObject 执行一次上传请求
+func (u *AliUploader) putObject(ctx context.Context, objectKey string, r io.Reader, size int64, o *config.UploadOptions) error {
+	if o.StrictTypeValidation {
+		var err error
+		if r, err = magic.Validate(r, o.FilenameOr(objectKey), config.MagicByteRules); err != nil {
+			return err
+		}
+	}
+	r, ct, err := u.resolveContentType(objectKey, r, o)
 	if err != nil {
-		return fmt.Errorf("failed to delete OSS object: %w", err)
+		return err
+	}
+	r, done := audit.Wrap(r, size, o)
+
+	options := append(u.putOptions(objectKey, ct, o), oss.ContentLength(size), oss.WithContext(ctx))
+	var (
+		crc        hash.Hash64
+		respHeader http.Header
+	)
+	if u.config.VerifyChecksum {
+		crc = crc64.New(crc64.MakeTable(crc64.ECMA))
+		r = io.TeeReader(r, crc)
+		options = append(options, oss.GetResponseHeader(&respHeader))
+	}
+	if err := u.bucket.PutObject(objectKey, r, options...); err != nil {
+		// SDK默认也会校验CRC，开启校验时统一返回 ErrChecksumMismatch
+		if u.config.VerifyChecksum && errors.As(err, &oss.CRCCheckError{}) {
+			return fmt.Errorf("%w: %v", config.ErrChecksumMismatch, err)
+		}
+		return err
+	}
+	if crc != nil {
+		if err := checkCRC64(crc.Sum64(), respHeader); err != nil {
+			return err
+		}
+	}
+	// 上传响应已经过CRC64校验时无需再查询
+	if o.VerifyAfterUpload && crc == nil && !u.bucket.GetConfig().IsEnableCRC {
+		if err := config.VerifySize(func() (int64, error) { return u.Size(objectKey) }, size); err != nil {
+			return err
+		}
 	}
 
+	done(objectKey)
 	return nil
 }
 
-// generateObjectKey 生成存储对象键
-func (u *AliUploader) generateObjectKey(originalName string) string {
-	// 获取文件扩展名
-	ext := filepath.Ext(originalName)
-	baseName := strings.TrimSuffix(filepath.Base(originalName), ext)
+// checkCRC64 比对本地计算的CRC64与OSS返回的校验值
+func checkCRC64(local uint64, header http.Header) error {
+	value := header.Get(oss.HTTPHeaderOssCRC64)
+	if value == "" {
+		return fmt.Errorf("%w: response has no %s header", config.ErrChecksumMismatch, oss.HTTPHeaderOssCRC64)
+	}
+	server, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid server checksum %q", config.ErrChecksumMismatch, value)
+	}
+	if server != local {
+		return fmt.Errorf("%w: local crc64 %d, server %d", config.ErrChecksumMismatch, local, server)
+	}
+	return nil
+}
+
+// putOptions 将上传选项转换为OSS请求选项，contentType为空时由SDK按key推断
+func (u *AliUploader) putOptions(objectKey, contentType string, o *config.UploadOptions) []oss.Option {
+	var options []oss.Option
+	if cacheControl := u.cacheControl(contentType, o); cacheControl != "" {
+		options = append(options, oss.CacheControl(cacheControl))
+	}
+	if contentType != "" {
+		options = append(options, oss.ContentType(contentType))
+	}
+	if o.StorageClass != "" {
+		options = append(options, oss.ObjectStorageClass(storageClasses[o.StorageClass]))
+	}
+	if tags := config.ObjectTags(u.config.ProvenanceTags, o); len(tags) > 0 {
+		tagging := oss.Tagging{}
+		for k, v := range tags {
+			tagging.Tags = append(tagging.Tags, oss.Tag{Key: k, Value: v})
+		}
+		sort.Slice(tagging.Tags, func(i, j int) bool { return tagging.Tags[i].Key < tagging.Tags[j].Key })
+		options = append(options, oss.SetTagging(tagging))
+	}
+	for name, values := range o.Headers {
+		for _, v := range values {
+			options = append(options, oss.SetHeader(name, v))
+		}
+	}
+	for name, value := range o.ExtraHeaders {
+		if key, ok := cutMetaPrefix(name); ok {
+			options = append(options, oss.Meta(key, value))
+		} else {
+			options = append(options, oss.SetHeader(name, value))
+		}
+	}
+	if o.RecordUploadedAt {
+		options = append(options, oss.Meta(config.UploadedAtMeta, config.FormatUploadedAt(time.Now())))
+	}
+	return options
+}
+
+// GetObjectInfo 获取对象信息，对象不存在时返回 config.ErrNotFound
+func (u *AliUploader) GetObjectInfo(objectKey string) (config.ObjectInfo, error) {
+	header, err := u.bucket.GetObjectDetailedMeta(objectKey)
+	if err != nil {
+		if isNotFound(err) {
+			return config.ObjectInfo{}, config.ErrNotFound
+		}
+		return config.ObjectInfo{}, fmt.Errorf("failed to get OSS object meta: %w", err)
+	}
+
+	info := config.ObjectInfo{
+		Key:           objectKey,
+		ContentType:   header.Get("Content-Type"),
+		ETag:          strings.Trim(header.Get("ETag"), `"`),
+		CustomHeaders: make(map[string]string),
+	}
+	info.Size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(header.Get("Last-Modified"))
+	info.StorageClass = storageClassOf(header.Get(oss.HTTPHeaderOssStorageClass))
+	for name := range header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, metaPrefix) {
+			info.CustomHeaders[lower] = header.Get(name)
+		}
+	}
+	info.UploadedAt = config.ParseUploadedAt(info.CustomHeaders[metaPrefix+config.UploadedAtMeta], info.LastModified)
+	return info, nil
+}
+
+// Size 返回对象大小，只请求基本元数据，对象不存在时返回 config.ErrNotFound
+func (u *AliUploader) Size(objectKey string) (int64, error) {
+	header, err := u.bucket.GetObjectMeta(objectKey)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, config.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to get OSS object meta: %w", err)
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OSS object size: %w", err)
+	}
+	return size, nil
+}
+
+// storageClasses 通用存储类型对应的OSS存储类型
+var storageClasses = map[string]oss.StorageClassType{
+	config.StorageClassStandard:         oss.StorageStandard,
+	config.StorageClassInfrequentAccess: oss.StorageIA,
+	config.StorageClassArchive:          oss.StorageArchive,
+}
+
+// storageClassOf 将OSS返回的存储类型转换为通用存储类型，未知类型原样返回
+func storageClassOf(class string) string {
+	for generic, c := range storageClasses {
+		if string(c) == class {
+			return generic
+		}
+	}
+	return class
+}
+
+// metaPrefix OSS自定义元数据头前缀
+const metaPrefix = "x-oss-meta-"
+
+// cutMetaPrefix 去除自定义元数据头前缀，不带前缀时返回false
+func cutMetaPrefix(name string) (string, bool) {
+	if len(name) > len(metaPrefix) && strings.EqualFold(name[:len(metaPrefix)], metaPrefix) {
+		return name[len(metaPrefix):], true
+	}
+	return "", false
+}
+
+// isNotFound 判断是否为对象不存在错误
+func isNotFound(err error) bool {
+	var se oss.ServiceError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
 
-	// 生成日期路径和唯一文件名
-	datePath := time.Now().Format("2006/01/02")
-	uniqueName := fmt.Sprintf("%s_%d%s", baseName, time.Now().UnixNano(), ext)
+// isObjectLocked 判断是否为合规保留策略(WORM)禁止删除或覆盖的错误
+func isObjectLocked(err error) bool {
+	var se oss.ServiceError
+	return errors.As(err, &se) && se.StatusCode == http.StatusConflict && se.Code == "FileImmutable"
+}
 
-	return filepath.Join(datePath, uniqueName)
+// cacheControl 确定上传时的Cache-Control，显式选项优先，其次按内容类型规则匹配
+func (u *AliUploader) cacheControl(contentType string, o *config.UploadOptions) string {
+	if o.CacheControl != "" {
+		return o.CacheControl
+	}
+	value, _ := mime.Lookup(u.config.ContentTypeCacheRules, contentType)
+	return value
+}
+
+// baseURL 构建文件访问URL的前缀
+// 配置了Domain时使用自定义域名，否则按ForcePathStyle选择路径风格或虚拟主机风格
+func baseURL(cfg config.AliyunConfig) string {
+	if cfg.Domain != "" {
+		return "https://" + cfg.Domain
+	}
+
+	scheme, host := "https", cfg.Endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	if cfg.ForcePathStyle {
+		return scheme + "://" + host + "/" + cfg.BucketName
+	}
+	return scheme + "://" + cfg.BucketName + "." + host
 }
 
 // getFileURL 获取文件访问URL
+// 使用自定义域名时去除 URLStripPrefix
 func (u *AliUploader) getFileURL(objectKey string) string {
+	if u.config.Domain != "" {
+		objectKey = keygen.StripPrefix(objectKey, u.config.URLStripPrefix)
+	}
 	return fmt.Sprintf("%s/%s", u.endpoint, objectKey)
 }
 
@@ -158,11 +612,89 @@ func (u *AliUploader) SetACL(objectKey string, acl oss.ACLType) error {
 	return u.bucket.SetObjectACL(objectKey, acl)
 }
 
-// GetSignedURL 获取带签名的临时URL
+// GetSignedURL 获取带签名的临时URL，配置了UseS3Compat时生成S3兼容的SigV4签名URL
 func (u *AliUploader) GetSignedURL(objectKey string, expiredInSec int64) (string, error) {
+	if u.config.UseS3Compat {
+		return u.presignS3(http.MethodGet, objectKey, expiredInSec)
+	}
 	signedURL, err := u.bucket.SignURL(objectKey, oss.HTTPGet, expiredInSec)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate signed URL: %w", err)
 	}
 	return signedURL, nil
 }
+
+// CopyWithMeta 服务端复制对象并替换元数据
+// 与普通复制不同，目标对象不继承源对象的元数据，而是使用meta中的值，
+// 适合在复制的同时修改Content-Type或Cache-Control，避免复制后再单独更新元数据
+func (u *AliUploader) CopyWithMeta(ctx context.Context, srcKey, dstKey string, meta config.ObjectMeta) error {
+	if srcKey == "" || dstKey == "" {
+		return errors.New("source and destination keys cannot be empty")
+	}
+
+	options := []oss.Option{oss.WithContext(ctx), oss.MetadataDirective(oss.MetaReplace)}
+	options = append(options, metaOptions(meta)...)
+
+	if _, err := u.bucket.CopyObject(srcKey, dstKey, options...); err != nil {
+		return fmt.Errorf("failed to copy OSS object: %w", err)
+	}
+
+	return nil
+}
+
+// metaOptions 将对象元数据转换为OSS请求选项
+func metaOptions(meta config.ObjectMeta) []oss.Option {
+	var options []oss.Option
+	if meta.ContentType != "" {
+		options = append(options, oss.ContentType(meta.ContentType))
+	}
+	if meta.CacheControl != "" {
+		options = append(options, oss.CacheControl(meta.CacheControl))
+	}
+	if meta.ContentDisposition != "" {
+		options = append(options, oss.ContentDisposition(meta.ContentDisposition))
+	}
+	if meta.ContentEncoding != "" {
+		options = append(options, oss.ContentEncoding(meta.ContentEncoding))
+	}
+	for k, v := range meta.Metadata {
+		options = append(options, oss.Meta(k, v))
+	}
+	return options
+}
+
+// resolveContentType 确定上传的内容类型，WithFilename 指定的文件名优先于key
+// 开启DetectMIME时读取内容开头用于嗅探，返回的reader仍包含完整内容
+func (u *AliUploader) resolveContentType(objectKey string, r io.Reader, o *config.UploadOptions) (io.Reader, string, error) {
+	resolver := mime.Resolver{Overrides: u.config.ContentTypeOverrides, DetectMIME: u.config.DetectMIME}
+	var head []byte
+	if resolver.DetectMIME && o.ContentType == "" {
+		var err error
+		if head, r, err = mime.Peek(r); err != nil {
+			return nil, "", err
+		}
+	}
+	return r, resolver.ResolveContentType(o.FilenameOr(objectKey), head, o.ContentType), nil
+}
+
+// ErrorDetails 从err中提取OSS返回的错误码、错误信息、请求ID与HTTP状态码，err不是OSS服务端错误时返回false
+func ErrorDetails(err error) (*config.ProviderError, bool) {
+	var pe *config.ProviderError
+	if errors.As(err, &pe) {
+		return pe, true
+	}
+	var se oss.ServiceError
+	if !errors.As(err, &se) {
+		return nil, false
+	}
+	return &config.ProviderError{Provider: "aliyun", Code: se.Code, Message: se.Message, RequestID: se.RequestID, StatusCode: se.StatusCode, Err: err}, true
+}
+
+// withRequestID 将OSS服务端错误转换为 *config.ProviderError，使错误信息带上 request_id，
+// 原始错误仍可通过 errors.As 取得；其他错误原样返回
+func withRequestID(err error) error {
+	if d, ok := ErrorDetails(err); ok {
+		return d
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 22:10:27
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 22:10:27
+ * Description: OSS追加上传，适用于日志、遥测等持续写入的场景
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// AppendObject 在对象末尾追加内容，返回下一次追加的位置
+// 首次追加(对象不存在)时offset为0，之后传入上一次返回的nextOffset；offset与对象当前长度不一致时OSS返回409错误
+// 只能追加到通过追加上传创建的对象(Appendable类型)
+func (u *AliUploader) AppendObject(ctx context.Context, key string, content []byte, offset int64) (int64, error) {
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return offset, err
+	}
+
+	next, err := u.bucket.AppendObject(key, bytes.NewReader(content), offset, oss.WithContext(ctx))
+	if err != nil {
+		return offset, fmt.Errorf("failed to append OSS object: %w", err)
+	}
+	return next, nil
+}
+
+// Appender 对同一对象的顺序追加，自动维护追加位置，可并发调用
+type Appender struct {
+	u      *AliUploader
+	key    string
+	mu     sync.Mutex
+	offset int64
+}
+
+// NewAppender 创建追加器，offset为起始追加位置，新对象为0，续写已有对象时传入其当前长度
+func (u *AliUploader) NewAppender(key string, offset int64) *Appender {
+	return &Appender{u: u, key: key, offset: offset}
+}
+
+// Append 追加内容，失败时追加位置不变，可直接重试
+func (a *Appender) Append(ctx context.Context, content []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	next, err := a.u.AppendObject(ctx, a.key, content, a.offset)
+	if err != nil {
+		return err
+	}
+	a.offset = next
+	return nil
+}
+
+// Offset 返回下一次追加的位置，即已写入的总长度
+func (a *Appender) Offset() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.offset
+}
@@ -0,0 +1,76 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 04:21:48
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 04:21:48
+ * Description: 阿里云OSS存储空间自动创建
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"fmt"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// bucketACLs 支持的存储空间读写权限
+var bucketACLs = map[string]oss.ACLType{
+	string(oss.ACLPrivate):         oss.ACLPrivate,
+	string(oss.ACLPublicRead):      oss.ACLPublicRead,
+	string(oss.ACLPublicReadWrite): oss.ACLPublicReadWrite,
+}
+
+// bucketRedundancies 支持的数据冗余类型
+var bucketRedundancies = map[string]oss.DataRedundancyType{
+	string(oss.RedundancyLRS): oss.RedundancyLRS,
+	string(oss.RedundancyZRS): oss.RedundancyZRS,
+}
+
+// createBucketOptions 将创建设置转换为请求选项，空字段不发送，由OSS使用默认值
+func createBucketOptions(b config.BucketCreateOptions) ([]oss.Option, error) {
+	var options []oss.Option
+	if b.StorageClass != "" {
+		class, ok := storageClasses[b.StorageClass]
+		if !ok {
+			return nil, fmt.Errorf("unsupported OSS bucket storage class: %s", b.StorageClass)
+		}
+		options = append(options, oss.StorageClass(class))
+	}
+	if b.ACL != "" {
+		acl, ok := bucketACLs[b.ACL]
+		if !ok {
+			return nil, fmt.Errorf("unsupported OSS bucket ACL: %s", b.ACL)
+		}
+		options = append(options, oss.ACL(acl))
+	}
+	if b.DataRedundancy != "" {
+		redundancy, ok := bucketRedundancies[b.DataRedundancy]
+		if !ok {
+			return nil, fmt.Errorf("unsupported OSS data redundancy type: %s", b.DataRedundancy)
+		}
+		options = append(options, oss.RedundancyType(redundancy))
+	}
+	return options, nil
+}
+
+// ensureBucket 存储空间不存在时按配置创建，已存在时不做修改
+// 使用 GetBucketInfo 判断是否存在，不需要列举全部存储空间的权限
+func ensureBucket(client *oss.Client, cfg config.AliyunConfig) error {
+	options, err := createBucketOptions(cfg.BucketCreateOptions)
+	if err != nil {
+		return err
+	}
+	_, err = client.GetBucketInfo(cfg.BucketName)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to get OSS bucket info: %w", err)
+	}
+	if err := client.CreateBucket(cfg.BucketName, options...); err != nil {
+		return fmt.Errorf("failed to create OSS bucket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 17:35:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 17:35:40
+ * Description: 阿里云STS临时凭证的获取与刷新
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// credentials 一组OSS访问凭证
+type credentials struct {
+	accessKeyID     string
+	accessKeySecret string
+	securityToken   string
+}
+
+func (c credentials) GetAccessKeyID() string     { return c.accessKeyID }
+func (c credentials) GetAccessKeySecret() string { return c.accessKeySecret }
+func (c credentials) GetSecurityToken() string   { return c.securityToken }
+
+// ossCredentials 转换为SDK使用的凭证
+func ossCredentials(c config.Credentials) credentials {
+	return credentials{accessKeyID: c.AccessKeyID, accessKeySecret: c.AccessKeySecret, securityToken: c.SecurityToken}
+}
+
+// credentialProvider 缓存由凭证提供函数获取的凭证
+// 首次请求时获取，临近过期或失效(403)后由 invalidate 清除时，下次请求重新获取；
+// SDK在每次请求前读取凭证，因此刷新凭证不需要重建客户端
+type credentialProvider struct {
+	fetch config.CredentialsProvider
+
+	mu     sync.Mutex
+	cached *config.Credentials
+}
+
+var _ oss.CredentialsProviderE = (*credentialProvider)(nil)
+
+// GetCredentialsE 返回缓存的凭证，未缓存时调用fetch获取
+func (p *credentialProvider) GetCredentialsE() (oss.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.cached != nil && !p.cached.NeedsRefresh(now) {
+		return ossCredentials(*p.cached), nil
+	}
+
+	cred, err := p.fetch()
+	if err != nil {
+		// 刷新失败但旧凭证尚未过期时继续使用旧凭证
+		if p.cached != nil && !p.cached.Expired(now) {
+			return ossCredentials(*p.cached), nil
+		}
+		return nil, fmt.Errorf("failed to fetch OSS credentials: %w", err)
+	}
+	p.cached = &cred
+	return ossCredentials(cred), nil
+}
+
+// GetCredentials 同 GetCredentialsE，获取失败时返回空凭证
+func (p *credentialProvider) GetCredentials() oss.Credentials {
+	c, err := p.GetCredentialsE()
+	if err != nil {
+		return credentials{}
+	}
+	return c
+}
+
+// invalidate 清除缓存的凭证
+func (p *credentialProvider) invalidate() {
+	p.mu.Lock()
+	p.cached = nil
+	p.mu.Unlock()
+}
+
+// isCredentialExpired 判断是否为凭证无效或过期导致的错误
+func isCredentialExpired(err error) bool {
+	var se oss.ServiceError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusForbidden {
+		return false
+	}
+	return se.Code == "InvalidAccessKeyId" || se.Code == "SecurityTokenExpired"
+}
+
+// refreshCredentials 错误为凭证失效且配置了凭证提供函数时清除缓存的凭证并返回true
+func (u *AliUploader) refreshCredentials(err error) bool {
+	if u.credentials == nil || !isCredentialExpired(err) {
+		return false
+	}
+	u.credentials.invalidate()
+	return true
+}
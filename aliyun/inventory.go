@@ -0,0 +1,144 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:52:14
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:52:14
+ * Description: OSS存储空间清单，定期导出存储空间内全部对象的列表，用于合规审计与账单核对
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// 清单任务状态
+const (
+	InventoryPending   = "Pending"   // 清单规则已生效，清单文件尚未生成
+	InventoryCompleted = "Completed" // 至少已生成一份清单文件
+)
+
+// InventoryOpts 清单导出选项
+type InventoryOpts struct {
+	TargetBucket    string // 存放清单文件的存储空间，为空时使用当前存储空间
+	TargetPrefix    string // 清单文件的key前缀
+	Format          string // 清单文件格式，OSS只支持CSV，为空时为CSV
+	IncludeVersions bool   // 是否包含对象的所有历史版本，false时只包含当前版本
+	AccountID       string // 存储空间所属账号的ID，OSS要求必填
+	RoleArn         string // OSS写入清单文件时扮演的RAM角色，如 acs:ram::<uid>:role/AliyunOSSRole，OSS要求必填
+	Frequency       string // 生成频率 Daily 或 Weekly，为空时为Daily
+}
+
+// InventoryStatus 清单任务状态
+type InventoryStatus struct {
+	State        string // InventoryPending 或 InventoryCompleted
+	TargetBucket string // 存放清单文件的存储空间
+	ManifestKey  string // 最近一份清单的manifest.json的key，State为InventoryPending时为空
+}
+
+// ExportInventory 为存储空间配置清单规则并返回任务ID(即清单规则ID)
+// OSS的清单是按Frequency周期生成的规则而非一次性任务：配置后首份清单异步生成，之后按周期覆盖导出，
+// 不再需要时调用 StopInventory 删除规则。清单文件写入目标存储空间的
+// <TargetPrefix>/<存储空间>/<任务ID>/<时间>/ 目录，manifest.json 中列出各CSV数据文件
+func (u *AliUploader) ExportInventory(ctx context.Context, opts InventoryOpts) (string, error) {
+	if opts.Format == "" {
+		opts.Format = "CSV"
+	}
+	if !strings.EqualFold(opts.Format, "CSV") {
+		return "", fmt.Errorf("%w: OSS inventory only supports CSV format, got %q", config.ErrNotSupported, opts.Format)
+	}
+	if opts.AccountID == "" || opts.RoleArn == "" {
+		return "", fmt.Errorf("inventory AccountID and RoleArn are required")
+	}
+	if opts.TargetBucket == "" {
+		opts.TargetBucket = u.config.BucketName
+	}
+	if opts.Frequency == "" {
+		opts.Frequency = "Daily"
+	}
+	versions := "Current"
+	if opts.IncludeVersions {
+		versions = "All"
+	}
+
+	jobID := "gosuploader-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	enabled := true
+	err := u.client.SetBucketInventory(u.config.BucketName, oss.InventoryConfiguration{
+		Id:        jobID,
+		IsEnabled: &enabled,
+		OSSBucketDestination: oss.OSSBucketDestination{
+			Format:    "CSV",
+			AccountId: opts.AccountID,
+			RoleArn:   opts.RoleArn,
+			Bucket:    "acs:oss:::" + opts.TargetBucket,
+			Prefix:    opts.TargetPrefix,
+		},
+		Frequency:              opts.Frequency,
+		IncludedObjectVersions: versions,
+		OptionalFields: oss.OptionalFields{
+			Field: []string{"Size", "LastModifiedDate", "ETag", "StorageClass", "IsMultipartUploaded", "EncryptionStatus"},
+		},
+	}, oss.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to set OSS bucket inventory: %w", withRequestID(err))
+	}
+	return jobID, nil
+}
+
+// GetInventoryStatus 查询清单任务状态，任务不存在(未创建或已停止)时返回 ErrNotFound
+// 生成完成后可通过目标存储空间下载 ManifestKey 及其中列出的数据文件
+func (u *AliUploader) GetInventoryStatus(ctx context.Context, jobID string) (InventoryStatus, error) {
+	inv, err := u.client.GetBucketInventory(u.config.BucketName, jobID, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			return InventoryStatus{}, config.ErrNotFound
+		}
+		return InventoryStatus{}, fmt.Errorf("failed to get OSS bucket inventory: %w", withRequestID(err))
+	}
+
+	dst := inv.OSSBucketDestination
+	status := InventoryStatus{
+		State:        InventoryPending,
+		TargetBucket: dst.Bucket[strings.LastIndex(dst.Bucket, ":")+1:],
+	}
+	target, err := u.client.Bucket(status.TargetBucket)
+	if err != nil {
+		return InventoryStatus{}, fmt.Errorf("failed to open OSS bucket %s: %w", status.TargetBucket, err)
+	}
+
+	// 目录名为生成时间(如 2026-10-18T00-00Z)，按字典序最后一个manifest即最近一份
+	prefix := path.Join(dst.Prefix, u.config.BucketName, jobID) + "/"
+	token := ""
+	for {
+		result, err := target.ListObjectsV2(oss.Prefix(prefix), oss.ContinuationToken(token), oss.MaxKeys(1000), oss.WithContext(ctx))
+		if err != nil {
+			return InventoryStatus{}, fmt.Errorf("failed to list OSS inventory files: %w", err)
+		}
+		for _, object := range result.Objects {
+			if strings.HasSuffix(object.Key, "/manifest.json") && object.Key > status.ManifestKey {
+				status.ManifestKey = object.Key
+				status.State = InventoryCompleted
+			}
+		}
+		if !result.IsTruncated {
+			return status, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// StopInventory 删除清单规则，之后不再生成新的清单，已生成的清单文件保留
+func (u *AliUploader) StopInventory(ctx context.Context, jobID string) error {
+	if err := u.client.DeleteBucketInventory(u.config.BucketName, jobID, oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete OSS bucket inventory: %w", withRequestID(err))
+	}
+	return nil
+}
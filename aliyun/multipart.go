@@ -0,0 +1,174 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 11:31:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 11:31:40
+ * Description: 阿里云OSS分片上传，实现 multipart.Backend
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/multipart"
+)
+
+var _ multipart.Backend = (*AliUploader)(nil)
+
+// InitiateMultipart 初始化分片上传
+func (u *AliUploader) InitiateMultipart(ctx context.Context, key string) (string, error) {
+	imur, err := u.bucket.InitiateMultipartUpload(key, oss.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return imur.UploadID, nil
+}
+
+// UploadPart 上传单个分片
+func (u *AliUploader) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (multipart.Part, error) {
+	part, err := u.bucket.UploadPart(u.imur(key, uploadID), r, size, number, oss.WithContext(ctx))
+	if err != nil {
+		return multipart.Part{}, err
+	}
+	return multipart.Part{Number: part.PartNumber, ETag: part.ETag, Size: size}, nil
+}
+
+// CompleteMultipart 合并分片
+func (u *AliUploader) CompleteMultipart(ctx context.Context, key, uploadID string, parts []multipart.Part) error {
+	ossParts := make([]oss.UploadPart, 0, len(parts))
+	for _, p := range parts {
+		ossParts = append(ossParts, oss.UploadPart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, err := u.bucket.CompleteMultipartUpload(u.imur(key, uploadID), ossParts, oss.WithContext(ctx))
+	return err
+}
+
+// AbortMultipart 中止分片上传并清理已上传的分片
+func (u *AliUploader) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	return u.bucket.AbortMultipartUpload(u.imur(key, uploadID), oss.WithContext(ctx))
+}
+
+// MultipartOpts 流式分片上传参数
+type MultipartOpts struct {
+	PartSize     int64                  // 分片大小，为0时按内存上限自动选择，不能小于OSS的最小分片100KB(最后一片除外)
+	Workers      int                    // 并发上传的分片数，默认4，为1时顺序上传
+	RetryPerPart int                    // 单个分片失败后的重试次数
+	Progress     multipart.ProgressFunc // 进度回调，调用是串行的
+}
+
+// MultipartUploadStream 从r流式读取内容并分片上传到key，返回文件访问URL，size未知时传-1
+// 适用于HTTP请求体、网络管道等无法定位的输入：手动初始化分片上传、逐片读取并上传、记录各分片ETag后合并，
+// 同时占用的内存约为 (Workers+1)*PartSize；失败时中止分片上传，不会残留已上传的分片
+func (u *AliUploader) MultipartUploadStream(ctx context.Context, key string, r io.Reader, size int64, opts MultipartOpts) (string, error) {
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	orchestrator := multipart.NewOrchestrator(u, multipart.Options{
+		PartSize:     opts.PartSize,
+		Workers:      opts.Workers,
+		RetryPerPart: opts.RetryPerPart,
+		Progress:     opts.Progress,
+	})
+	if err := orchestrator.Upload(ctx, key, r, size); err != nil {
+		return "", fmt.Errorf("failed to upload OSS object %s: %w", key, withRequestID(err))
+	}
+	return u.getFileURL(key), nil
+}
+
+// ListIncompleteMultipartUploads 列举前缀下未完成的分片上传
+func (u *AliUploader) ListIncompleteMultipartUploads(ctx context.Context, prefix string) ([]multipart.IncompleteUpload, error) {
+	var uploads []multipart.IncompleteUpload
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := u.bucket.ListMultipartUploads(oss.Prefix(prefix), oss.KeyMarker(keyMarker),
+			oss.UploadIDMarker(uploadIDMarker), oss.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list OSS multipart uploads: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			item := multipart.IncompleteUpload{Key: upload.Key, UploadID: upload.UploadID, InitiatedAt: upload.Initiated}
+			if err := u.countParts(ctx, &item); err != nil {
+				return nil, err
+			}
+			uploads = append(uploads, item)
+		}
+		if !result.IsTruncated {
+			return uploads, nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+// countParts 统计分片上传已上传的分片数与字节数
+func (u *AliUploader) countParts(ctx context.Context, item *multipart.IncompleteUpload) error {
+	marker := 0
+	for {
+		result, err := u.bucket.ListUploadedParts(u.imur(item.Key, item.UploadID),
+			oss.PartNumberMarker(marker), oss.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to list OSS uploaded parts: %w", err)
+		}
+		for _, part := range result.UploadedParts {
+			item.PartCount++
+			item.BytesUploaded += int64(part.Size)
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		if marker, err = strconv.Atoi(result.NextPartNumberMarker); err != nil {
+			return fmt.Errorf("invalid OSS part number marker %q: %w", result.NextPartNumberMarker, err)
+		}
+	}
+}
+
+// imur 构造分片上传标识
+func (u *AliUploader) imur(key, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{
+		Bucket:   u.config.BucketName,
+		Key:      key,
+		UploadID: uploadID,
+	}
+}
+
+// UploadStream 从r流式上传大小未知的内容到key，以分片上传写入，返回的 UploadResult.Path 为文件访问URL
+// key为空时按 WithFilename 指定的文件名生成，两者都为空时返回 ErrInvalidKey
+// ctx取消、超时或读取失败(如 UploadWriter.Abort)时中止分片上传，不会生成对象，也不会残留已上传的分片；
+// 大小限制、扫描、审计与 WithResult 照常生效，请求头、元数据与存储类型等选项不会应用到分片上传
+func (u *AliUploader) UploadStream(ctx context.Context, key string, r io.Reader, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	if o.Key != "" || o.Filename == "" {
+		key, err = keygen.PolicyKey(o.Key, u.config.KeyCharPolicy)
+	} else {
+		// 未指定key时按文件名生成，content-addressed策略需要预先读取全部内容，返回错误
+		key, err = u.generateObjectKey(o.Filename, o.ContentType)
+		key = u.config.KeyCharPolicy.Apply(key)
+	}
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+
+	result := config.UploadResult{RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	r, done := audit.Wrap(r, -1, o)
+	ctx, cancel := o.Context(-1)
+	defer cancel()
+	if err := multipart.NewOrchestrator(u, multipart.Options{}).Upload(ctx, key, r, -1); err != nil {
+		return config.UploadResult{}, fmt.Errorf("failed to upload OSS object %s: %w", key, withRequestID(err))
+	}
+	done(key)
+	result.Path = u.getFileURL(key)
+	return result, nil
+}
@@ -0,0 +1,196 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 14:10:26
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 14:10:26
+ * Description: 阿里云OSS对象管理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// Copy 服务端复制对象
+func (u *AliUploader) Copy(ctx context.Context, srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.bucket.CopyObject(srcKey, dstKey, oss.WithContext(ctx)); err != nil {
+		if isNotFound(err) {
+			return config.ErrNotFound
+		}
+		return fmt.Errorf("failed to copy OSS object: %w", err)
+	}
+	return nil
+}
+
+// Move 移动对象，通过服务端复制后删除源对象实现
+func (u *AliUploader) Move(srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Copy(context.Background(), srcKey, dstKey); err != nil {
+		return err
+	}
+	if err := u.bucket.DeleteObject(srcKey); err != nil {
+		return fmt.Errorf("failed to delete OSS object: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断对象是否存在，软链接按目标对象判断；只判断软链接本身时使用 ExistsWithOptions
+func (u *AliUploader) Exists(objectKey string) (bool, error) {
+	return u.ExistsWithOptions(objectKey, ExistsOptions{FollowSymlinks: true})
+}
+
+// List 列出前缀下的所有对象key，前缀按原样匹配
+// 配置了 KeySeparator 时生成的key需以分隔后的形式查询，如 2026_10_，WithKey 指定的含/的key仍按/查询
+func (u *AliUploader) List(prefix string) ([]string, error) {
+	var keys []string
+	err := u.walk(context.Background(), prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Count 统计前缀下的对象数量
+// 前缀为空时使用存储空间统计接口(数据有延迟，通常在一小时内)，否则逐页列举计数，耗时与对象数成正比
+func (u *AliUploader) Count(ctx context.Context, prefix string) (int64, error) {
+	if prefix == "" {
+		_, count, err := u.BucketUsage()
+		return count, err
+	}
+	var count int64
+	err := u.walk(ctx, prefix, func(string) { count++ })
+	return count, err
+}
+
+// walk 逐页列举前缀下的对象，每页请求前检查ctx
+func (u *AliUploader) walk(ctx context.Context, prefix string, fn func(key string)) error {
+	token := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := u.bucket.ListObjectsV2(oss.Prefix(prefix), oss.ContinuationToken(token), oss.MaxKeys(1000), oss.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to list OSS objects: %w", err)
+		}
+		for _, object := range result.Objects {
+			fn(object.Key)
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// GetTags 读取对象标签
+func (u *AliUploader) GetTags(objectKey string) (map[string]string, error) {
+	result, err := u.bucket.GetObjectTagging(objectKey)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get OSS object tagging: %w", err)
+	}
+
+	tags := make(map[string]string, len(result.Tags))
+	for _, tag := range result.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
+
+// Head 读取对象的前n个字节，对象小于n时返回全部内容
+func (u *AliUploader) Head(objectKey string, n int64) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("head length must be positive")
+	}
+
+	body, err := u.bucket.GetObject(objectKey, oss.Range(0, n-1))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get OSS object: %w", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSS object: %w", err)
+	}
+	return data, nil
+}
+
+// DownloadRange 从offset开始读取length字节，length小于0时读取到对象末尾，length为0或offset超出对象末尾时返回空内容
+// 调用方负责关闭返回的ReadCloser
+func (u *AliUploader) DownloadRange(ctx context.Context, objectKey string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("range offset must not be negative")
+	}
+	// bytes=N-(N-1)是无效范围，服务端会忽略它返回完整对象，因此不发起请求
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	rng := oss.NormalizedRange(fmt.Sprintf("%d-", offset))
+	if length >= 0 {
+		rng = oss.Range(offset, offset+length-1)
+	}
+	body, err := u.bucket.GetObject(objectKey, rng, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, config.ErrNotFound
+		}
+		var se oss.ServiceError
+		if errors.As(err, &se) && se.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		return nil, fmt.Errorf("failed to get OSS object: %w", err)
+	}
+	return body, nil
+}
+
+// DownloadIfNoneMatch 下载完整对象并返回其ETag，etag与对象当前ETag相同时返回 ErrNotModified
+// 条件判断由OSS完成，未修改时不传输内容
+func (u *AliUploader) DownloadIfNoneMatch(ctx context.Context, objectKey, etag string) (io.ReadCloser, string, error) {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	var header http.Header
+	options := []oss.Option{oss.WithContext(ctx), oss.GetResponseHeader(&header)}
+	if etag != "" {
+		options = append(options, oss.IfNoneMatch(`"`+etag+`"`))
+	}
+	body, err := u.bucket.GetObject(objectKey, options...)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", config.ErrNotFound
+		}
+		// 304响应不是ServiceError，以响应中的ETag确认未修改
+		if etag != "" && strings.Trim(header.Get("ETag"), `"`) == etag {
+			return nil, etag, config.ErrNotModified
+		}
+		return nil, "", fmt.Errorf("failed to get OSS object: %w", err)
+	}
+	return body, strings.Trim(header.Get("ETag"), `"`), nil
+}
@@ -0,0 +1,155 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 01:05:22
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 01:05:22
+ * Description: 通过OSS的S3兼容接口生成AWS SigV4预签名URL
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxS3PresignExpires SigV4预签名URL的最长有效期(7天)
+const maxS3PresignExpires = 7 * 24 * 3600
+
+// s3Presign 一次SigV4查询串签名所需的参数
+type s3Presign struct {
+	method          string
+	scheme, host    string
+	path            string // 未编码的请求路径，以/开头
+	region          string
+	accessKeyID     string
+	accessKeySecret string
+	securityToken   string
+	expires         int64
+	now             time.Time
+}
+
+// presignS3 生成访问S3兼容接口的预签名URL
+func (u *AliUploader) presignS3(method, objectKey string, expiredInSec int64) (string, error) {
+	if objectKey == "" {
+		return "", errors.New("object key cannot be empty")
+	}
+	if expiredInSec <= 0 || expiredInSec > maxS3PresignExpires {
+		return "", fmt.Errorf("S3 presign expiry must be between 1 and %d seconds", maxS3PresignExpires)
+	}
+
+	endpoint := u.config.S3CompatEndpoint
+	if endpoint == "" {
+		endpoint = u.config.Endpoint
+	}
+	scheme, host := "https", endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	p := s3Presign{
+		method:          method,
+		scheme:          scheme,
+		region:          strings.TrimSuffix(strings.SplitN(host, ".", 2)[0], "-internal"),
+		accessKeyID:     u.config.AccessKeyID,
+		accessKeySecret: u.config.AccessKeySecret,
+		expires:         expiredInSec,
+		now:             time.Now(),
+	}
+	if u.credentials != nil {
+		cred, err := u.credentials.GetCredentialsE()
+		if err != nil {
+			return "", err
+		}
+		p.accessKeyID = cred.GetAccessKeyID()
+		p.accessKeySecret = cred.GetAccessKeySecret()
+		p.securityToken = cred.GetSecurityToken()
+	}
+
+	objectKey = strings.TrimPrefix(objectKey, "/")
+	if u.config.ForcePathStyle {
+		p.host, p.path = host, "/"+u.config.BucketName+"/"+objectKey
+	} else {
+		p.host, p.path = u.config.BucketName+"."+host, "/"+objectKey
+	}
+	return p.sign(), nil
+}
+
+// sign 按SigV4查询串签名规则生成URL，负载固定为UNSIGNED-PAYLOAD
+func (p s3Presign) sign() string {
+	date := p.now.UTC().Format("20060102T150405Z")
+	scope := date[:8] + "/" + p.region + "/s3/aws4_request"
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    p.accessKeyID + "/" + scope,
+		"X-Amz-Date":          date,
+		"X-Amz-Expires":       strconv.FormatInt(p.expires, 10),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if p.securityToken != "" {
+		query["X-Amz-Security-Token"] = p.securityToken
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = s3Escape(name, true) + "=" + s3Escape(query[name], true)
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+	canonicalPath := s3Escape(p.path, false)
+
+	canonicalRequest := strings.Join([]string{
+		p.method,
+		canonicalPath,
+		canonicalQuery,
+		"host:" + p.host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+p.accessKeySecret), date[:8])
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return p.scheme + "://" + p.host + canonicalPath + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// s3Escape 按SigV4规则编码，只保留非保留字符；encodeSlash为false时保留路径中的/
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,85 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 06:07:42
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 06:07:42
+ * Description: OSS软链接，用于指向版本化内容的 latest 等固定别名
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// CreateSymlink 创建指向同一存储空间内targetKey的软链接symlinkKey，已存在时覆盖
+// OSS不检查目标是否存在，读取目标不存在的软链接时返回404
+func (u *AliUploader) CreateSymlink(ctx context.Context, symlinkKey, targetKey string) error {
+	symlinkKey, targetKey, err := keygen.NormalizeKeyPair(symlinkKey, targetKey)
+	if err != nil {
+		return err
+	}
+	if symlinkKey == targetKey {
+		return fmt.Errorf("%w: symlink cannot point to itself", config.ErrInvalidKey)
+	}
+
+	if err := u.bucket.PutSymlink(symlinkKey, targetKey, oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to create OSS symlink: %w", err)
+	}
+	return nil
+}
+
+// GetSymlinkTarget 返回软链接指向的目标key，软链接不存在时返回 ErrNotFound
+func (u *AliUploader) GetSymlinkTarget(ctx context.Context, symlinkKey string) (string, error) {
+	symlinkKey, err := keygen.NormalizeKey(symlinkKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := u.bucket.GetSymlink(symlinkKey, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			return "", config.ErrNotFound
+		}
+		var se oss.ServiceError
+		if errors.As(err, &se) && se.Code == "NotSymlink" {
+			return "", fmt.Errorf("OSS object %s is not a symlink: %w", symlinkKey, err)
+		}
+		return "", fmt.Errorf("failed to get OSS symlink: %w", err)
+	}
+	return header.Get(oss.HTTPHeaderOssSymlinkTarget), nil
+}
+
+// ExistsOptions Exists 的查询选项
+type ExistsOptions struct {
+	// FollowSymlinks 为true时软链接按目标对象判断，目标不存在时返回false；
+	// 为false时只判断key本身是否存在
+	FollowSymlinks bool
+}
+
+// ExistsWithOptions 按选项判断对象是否存在
+func (u *AliUploader) ExistsWithOptions(objectKey string, opts ExistsOptions) (bool, error) {
+	objectKey = u.config.KeyCharPolicy.Apply(objectKey)
+	if !opts.FollowSymlinks {
+		exists, err := u.bucket.IsObjectExist(objectKey)
+		if err != nil {
+			return false, fmt.Errorf("failed to check OSS object: %w", err)
+		}
+		return exists, nil
+	}
+
+	// HeadObject 访问软链接时返回目标对象的元信息
+	if _, err := u.bucket.GetObjectDetailedMeta(objectKey); err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check OSS object: %w", err)
+	}
+	return true, nil
+}
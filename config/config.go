@@ -8,9 +8,59 @@
  */
 package config
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// TLSSkipVerifyWarning 开启 TLSSkipVerify 时输出的警告
+const TLSSkipVerifyWarning = "WARNING: TLS verification is disabled; do not use in production"
+
 // LocalConfig 本地存储配置
 type LocalConfig struct {
 	BasePath string // 存储基础路径
+
+	// MaxFilesPerDir 单个日期目录的文件数上限，0表示不限制
+	// 超过后滚动到编号子目录，如 2006/01/02/00/、2006/01/02/01/
+	MaxFilesPerDir int
+
+	// ProvenanceTags 来源标签，如 {"service": "order-api", "version": "1.2.0"}
+	// 每次上传自动写入对象标签，键会加上 ProvenanceTagPrefix 前缀，可通过 GetTags 读取
+	ProvenanceTags map[string]string
+
+	// CollisionRetries 自动生成的文件名已被占用(如并发上传生成了相同的时间戳文件名)时，
+	// 重新生成文件名的最大次数，默认3，全部冲突时返回 ErrTooManyCollisions
+	CollisionRetries int
+
+	// KeyStrategy 唯一文件名生成策略: timestamp(默认)、uuid、sequential
+	KeyStrategy string
+	// Sequence sequential策略使用的计数器，未配置 Store 时按目录中已有文件的最大编号继续，重启后不会与已有文件冲突
+	Sequence *Sequence
+	// KeyCharPolicy 存储key的字符策略，如 KeyCharPolicyURLSafe，为nil时不处理
+	KeyCharPolicy *KeyCharPolicy
+	// KeySeparator 生成key时替换前缀、日期与文件名之间的/，如 "_" 生成 2025_07_01_image_123456.jpg 这样的扁平key，
+	// 不能包含/或\；为空时使用/。List、Count的前缀按原样匹配，查询生成的key需使用分隔后的前缀；WithKey 指定的key与content-addressed策略的key不受影响；
+	// 文件都位于BasePath下，MaxFilesPerDir 不再生效
+	KeySeparator string
+	// RoutingRules 按扩展名或内容类型为生成的key加上前缀目录，如图片放在 images/ 下，为nil时不加前缀
+	RoutingRules *RoutingRules
+	// DeduplicationStore 按内容SHA-256去重的索引，设置后 uploader.NewUploader 返回以其包装的 uploader.Deduplicator，
+	// 相同内容只上传一次；返回值只提供 Uploader 接口的方法，为nil时不去重
+	DeduplicationStore DeduplicationStore
+
+	// MaxBase64Length Base64上传解码后的最大字节数，0表示不限制
+	// 解码前按字符串长度估算并提前拒绝，超限返回 ErrFileTooLarge
+	MaxBase64Length int64
+
+	// SigningSecret 签名URL的HMAC密钥，为空时不能生成签名URL
+	// 用于由反向代理对外提供文件时模拟私有访问，见 GenerateLocalSignedURL
+	SigningSecret string
+	// BaseURL 对外访问文件的地址前缀，如 https://files.example.com/uploads，为空时签名URL只包含路径
+	BaseURL string
 }
 
 // QiniuConfig 七牛云配置
@@ -20,15 +70,206 @@ type QiniuConfig struct {
 	Bucket    string
 	Domain    string
 	Region    string // 存储区域
+
+	// ZoneID 上传区域: z0、z1、z2、na0、as0
+	// 指定后直接使用预置区域，不再调用 storage.GetZone 查询，可减少冷启动耗时；为空时自动查询
+	ZoneID string
+
+	// URLStripPrefix 生成访问URL时从key开头去除的目录前缀，存储操作仍使用完整key
+	// 适用于CDN回源路径已包含该前缀的场景，如前缀 media 时 media/a.png 的URL为 https://<Domain>/a.png
+	URLStripPrefix string
+
+	// KeyAsURL 允许不配置Domain，此时上传返回存储key而非访问URL，适用于只在服务端读取或另行生成访问地址的场景；
+	// 为false时Domain必填，避免返回 https:///<key> 这样无效的URL。下载、签名URL等需要访问域名的操作仍需配置Domain
+	KeyAsURL bool
+
+	// KeyStrategy 唯一文件名生成策略: timestamp(默认)、uuid、sequential
+	KeyStrategy string
+	// Sequence sequential策略使用的计数器，必须配置 Store，否则重启后编号从1开始并覆盖已有对象，New 返回错误
+	Sequence *Sequence
+	// KeyCharPolicy 存储key的字符策略，如 KeyCharPolicyURLSafe，为nil时不处理
+	KeyCharPolicy *KeyCharPolicy
+	// KeySeparator 生成key时替换前缀、日期与文件名之间的/，如 "_" 生成 2025_07_01_image_123456.jpg 这样的扁平key，
+	// 不能包含/或\；为空时使用/。List、Count的前缀按原样匹配，查询生成的key需使用分隔后的前缀；WithKey 指定的key与content-addressed策略的key不受影响
+	KeySeparator string
+	// RoutingRules 按扩展名或内容类型为生成的key加上前缀目录，如图片放在 images/ 下，为nil时不加前缀
+	RoutingRules *RoutingRules
+	// DeduplicationStore 按内容SHA-256去重的索引，设置后 uploader.NewUploader 返回以其包装的 uploader.Deduplicator，
+	// 相同内容只上传一次；返回值只提供 Uploader 接口的方法，为nil时不去重
+	DeduplicationStore DeduplicationStore
+
+	// MaxBase64Length Base64上传解码后的最大字节数，0表示不限制
+	// 解码前按字符串长度估算并提前拒绝，超限返回 ErrFileTooLarge
+	MaxBase64Length int64
+
+	// ContentTypeOverrides 按扩展名覆盖内容类型，如 {".webp": "image/webp"}
+	ContentTypeOverrides map[string]string
+	// DetectMIME 按内容开头嗅探内容类型(http.DetectContentType)，无法识别时按扩展名推断
+	DetectMIME bool
+
+	// MaxRetries 上传遇到限流(573)、回调失败(579)等可重试错误时的最大重试次数，0表示不重试
+	// 重试间隔按指数增长并加入随机抖动，限流错误的初始间隔更长
+	MaxRetries int
+
+	// TLSSkipVerify 跳过服务端证书校验，仅用于开发环境的自签名证书或自建CA，默认false，生产环境不要设置
+	// 开启时创建上传器会输出警告日志
+	TLSSkipVerify bool
+
+	// DebugHTTP 以Debug级别记录发往存储服务的每个HTTP请求的方法、URL、响应状态码与耗时，用于排查问题；
+	// 不记录请求与响应内容，URL中的签名、令牌等查询参数会被隐去
+	DebugHTTP bool
+	// Logger DebugHTTP 使用的日志记录器，为nil时使用 slog.Default()
+	Logger *slog.Logger
+
+	// UserAgent 发往存储服务的请求的User-Agent，便于在服务商的访问日志中识别流量，为空时使用 DefaultUserAgent
+	UserAgent string
 }
 
 // AliyunConfig 阿里云OSS配置
 type AliyunConfig struct {
-	Endpoint        string
+	// Endpoint OSS访问域名，如 oss-cn-hangzhou.aliyuncs.com，为空时由 Region 推导
+	Endpoint string
+	// Region 地域，如 oss-cn-hangzhou 或 cn-hangzhou，Endpoint为空时推导为 <region>.aliyuncs.com
+	Region string
+	// InternalEndpoint 由 Region 推导时使用内网域名 <region>-internal.aliyuncs.com，
+	// 供同地域ECS等内网访问，不产生外网流出流量费用；设置了 Endpoint 时忽略
+	InternalEndpoint bool
+
 	AccessKeyID     string
 	AccessKeySecret string
 	BucketName      string
 	Domain          string
+
+	// URLStripPrefix 生成自定义域名(Domain)访问URL时从key开头去除的目录前缀，存储操作仍使用完整key
+	// 适用于CDN回源路径已包含该前缀的场景，如前缀 media 时 media/a.png 的URL为 https://<Domain>/a.png
+	URLStripPrefix string
+
+	// KeyStrategy 唯一文件名生成策略: timestamp(默认)、uuid、sequential
+	KeyStrategy string
+	// Sequence sequential策略使用的计数器，必须配置 Store，否则重启后编号从1开始并覆盖已有对象，New 返回错误
+	Sequence *Sequence
+	// KeyCharPolicy 存储key的字符策略，如 KeyCharPolicyURLSafe，为nil时不处理
+	KeyCharPolicy *KeyCharPolicy
+	// KeySeparator 生成key时替换前缀、日期与文件名之间的/，如 "_" 生成 2025_07_01_image_123456.jpg 这样的扁平key，
+	// 不能包含/或\；为空时使用/。List、Count的前缀按原样匹配，查询生成的key需使用分隔后的前缀；WithKey 指定的key与content-addressed策略的key不受影响
+	KeySeparator string
+	// RoutingRules 按扩展名或内容类型为生成的key加上前缀目录，如图片放在 images/ 下，为nil时不加前缀
+	RoutingRules *RoutingRules
+	// DeduplicationStore 按内容SHA-256去重的索引，设置后 uploader.NewUploader 返回以其包装的 uploader.Deduplicator，
+	// 相同内容只上传一次；返回值只提供 Uploader 接口的方法，为nil时不去重
+	DeduplicationStore DeduplicationStore
+
+	// MaxBase64Length Base64上传解码后的最大字节数，0表示不限制
+	// 解码前按字符串长度估算并提前拒绝，超限返回 ErrFileTooLarge
+	MaxBase64Length int64
+
+	// AutoCreateBucket 创建上传器时存储空间不存在则按 BucketCreateOptions 自动创建
+	// 需要凭证具备 oss:GetBucketInfo 与 oss:PutBucket 权限
+	AutoCreateBucket bool
+	// BucketCreateOptions 自动创建存储空间时使用的设置，存储空间已存在时不会修改
+	BucketCreateOptions BucketCreateOptions
+
+	// ForcePathStyle 使用路径风格访问(endpoint/bucket/key)，而非虚拟主机风格(bucket.endpoint/key)
+	// 适用于自建网关等不支持 bucket 子域名的S3兼容服务
+	ForcePathStyle bool
+
+	// CredentialsProvider 获取STS等临时凭证，设置后忽略 AccessKeyID/AccessKeySecret
+	// 凭证在首次请求时获取并缓存，临近过期时在下次请求前自动刷新，
+	// 收到403 InvalidAccessKeyId/SecurityTokenExpired 时清除缓存并重新获取
+	CredentialsProvider CredentialsProvider
+	// CredentialProvider 获取不带过期时间的STS临时凭证，只在收到403时重新获取
+	//
+	// Deprecated: 使用 CredentialsProvider，两者都设置时以 CredentialsProvider 为准
+	CredentialProvider func(ctx context.Context) (accessKeyID, accessKeySecret, securityToken string, err error)
+
+	// ContentTypeCacheRules 按内容类型自动设置Cache-Control
+	// 键为内容类型模式(如 image/*、text/html、*)，值为Cache-Control
+	// 上传时可通过 WithCacheControl 单独覆盖
+	ContentTypeCacheRules map[string]string
+
+	// ContentTypeOverrides 按扩展名覆盖内容类型，如 {".webp": "image/webp"}
+	ContentTypeOverrides map[string]string
+	// DetectMIME 按内容开头嗅探内容类型(http.DetectContentType)，无法识别时按扩展名推断
+	DetectMIME bool
+
+	// ProvenanceTags 来源标签，如 {"service": "order-api", "version": "1.2.0"}
+	// 每次上传自动写入对象标签，键会加上 ProvenanceTagPrefix 前缀，可通过 GetTags 读取
+	ProvenanceTags map[string]string
+
+	// ConnectTimeout 建立连接的超时时间，0表示使用SDK默认值(30s)
+	ConnectTimeout time.Duration
+	// ReadWriteTimeout 连接上单次读写的超时时间，0表示使用SDK默认值(60s)
+	ReadWriteTimeout time.Duration
+	// RequestTimeout 单个请求(含读取响应体)的总超时时间，0表示不限制
+	// 设置后使用自建的http.Client，连接与读写超时仍按上面两项生效
+	RequestTimeout time.Duration
+
+	// VerifyChecksum 上传后在本地计算内容的CRC64-ECMA，与OSS返回的x-oss-hash-crc64ecma比对，
+	// 不一致时返回 ErrChecksumMismatch，用于发现传输中的数据损坏
+	VerifyChecksum bool
+
+	// UseS3Compat 生成签名URL时使用AWS SigV4签名，访问OSS的S3兼容接口，
+	// 供rclone等只支持S3协议的工具直接使用
+	UseS3Compat bool
+	// S3CompatEndpoint S3兼容接口的地址，如 https://oss-cn-hangzhou.aliyuncs.com，为空时使用Endpoint
+	// 签名区域取自主机名的第一段(如 oss-cn-hangzhou)
+	S3CompatEndpoint string
+
+	// TLSSkipVerify 跳过服务端证书校验，仅用于开发环境的自签名证书或自建CA，默认false，生产环境不要设置
+	// 开启时创建上传器会输出警告日志
+	TLSSkipVerify bool
+
+	// DebugHTTP 以Debug级别记录发往存储服务的每个HTTP请求的方法、URL、响应状态码与耗时，用于排查问题；
+	// 不记录请求与响应内容，URL中的签名、令牌等查询参数会被隐去
+	DebugHTTP bool
+	// Logger DebugHTTP 使用的日志记录器，为nil时使用 slog.Default()
+	Logger *slog.Logger
+
+	// UserAgent 发往存储服务的请求的User-Agent，便于在服务商的访问日志中识别流量，为空时使用 DefaultUserAgent
+	UserAgent string
+}
+
+// BucketCreateOptions 创建OSS存储空间的设置，空字段使用OSS默认值
+type BucketCreateOptions struct {
+	// StorageClass 存储空间的默认存储类型，取值为 StorageClassStandard(默认)、StorageClassInfrequentAccess 或 StorageClassArchive
+	StorageClass string
+	// ACL 读写权限: private(默认)、public-read、public-read-write
+	ACL string
+	// DataRedundancy 数据冗余类型: LRS(本地冗余，默认)、ZRS(同城冗余，仅部分地域支持)
+	DataRedundancy string
+}
+
+// Validate 校验阿里云OSS配置
+// 配置不完整或超时为负数时返回错误；仅存在隐患的配置返回包装了 ErrConfigWarning 的错误，
+// 调用方可用 errors.Is 区分后按需记录日志
+func (c AliyunConfig) Validate() error {
+	hasKey := c.AccessKeyID != "" && c.AccessKeySecret != ""
+	if c.ResolvedEndpoint() == "" || c.BucketName == "" || (!hasKey && c.CredentialProvider == nil && c.CredentialsProvider == nil) {
+		return errors.New("aliyun OSS configuration is incomplete")
+	}
+	if c.ConnectTimeout < 0 || c.ReadWriteTimeout < 0 || c.RequestTimeout < 0 {
+		return errors.New("aliyun OSS timeouts must not be negative")
+	}
+	// 请求总超时与读写超时相互影响，只设置前者时大文件上传可能先被SDK默认的读写超时中断
+	if c.RequestTimeout > 0 && c.ReadWriteTimeout == 0 {
+		return fmt.Errorf("%w: RequestTimeout is set without ReadWriteTimeout", ErrConfigWarning)
+	}
+	return nil
+}
+
+// ResolvedEndpoint 返回实际使用的访问域名：优先使用 Endpoint，否则由 Region 推导，两者都为空时返回空字符串
+func (c AliyunConfig) ResolvedEndpoint() string {
+	if c.Endpoint != "" || c.Region == "" {
+		return c.Endpoint
+	}
+	region, internal := strings.CutSuffix(c.Region, "-internal")
+	if !strings.HasPrefix(region, "oss-") {
+		region = "oss-" + region
+	}
+	if internal || c.InternalEndpoint {
+		region += "-internal"
+	}
+	return region + ".aliyuncs.com"
 }
 
 // TencentConfig 腾讯云COS配置
@@ -38,8 +279,82 @@ type TencentConfig struct {
 	BucketName string
 	Region     string
 	Domain     string
+
+	// URLStripPrefix 生成自定义域名(Domain)访问URL时从key开头去除的目录前缀，存储操作仍使用完整key
+	// 适用于CDN回源路径已包含该前缀的场景，如前缀 media 时 media/a.png 的URL为 https://<Domain>/a.png
+	URLStripPrefix string
+
+	// KeyStrategy 唯一文件名生成策略: timestamp(默认)、uuid、sequential
+	KeyStrategy string
+	// Sequence sequential策略使用的计数器，必须配置 Store，否则重启后编号从1开始并覆盖已有对象，New 返回错误
+	Sequence *Sequence
+	// KeyCharPolicy 存储key的字符策略，如 KeyCharPolicyURLSafe，为nil时不处理
+	KeyCharPolicy *KeyCharPolicy
+	// KeySeparator 生成key时替换前缀、日期与文件名之间的/，如 "_" 生成 2025_07_01_image_123456.jpg 这样的扁平key，
+	// 不能包含/或\；为空时使用/。List、Count的前缀按原样匹配，查询生成的key需使用分隔后的前缀；WithKey 指定的key与content-addressed策略的key不受影响
+	KeySeparator string
+	// RoutingRules 按扩展名或内容类型为生成的key加上前缀目录，如图片放在 images/ 下，为nil时不加前缀
+	RoutingRules *RoutingRules
+	// DeduplicationStore 按内容SHA-256去重的索引，设置后 uploader.NewUploader 返回以其包装的 uploader.Deduplicator，
+	// 相同内容只上传一次；返回值只提供 Uploader 接口的方法，为nil时不去重
+	DeduplicationStore DeduplicationStore
+
+	// MaxBase64Length Base64上传解码后的最大字节数，0表示不限制
+	// 解码前按字符串长度估算并提前拒绝，超限返回 ErrFileTooLarge
+	MaxBase64Length int64
+
+	// ContentTypeCacheRules 按内容类型自动设置Cache-Control，规则同 AliyunConfig
+	ContentTypeCacheRules map[string]string
+
+	// ContentTypeOverrides 按扩展名覆盖内容类型，如 {".webp": "image/webp"}
+	ContentTypeOverrides map[string]string
+	// DetectMIME 按内容开头嗅探内容类型(http.DetectContentType)，无法识别时按扩展名推断
+	DetectMIME bool
+
+	// ProvenanceTags 来源标签，如 {"service": "order-api", "version": "1.2.0"}
+	// 每次上传自动写入对象标签，键会加上 ProvenanceTagPrefix 前缀，可通过 GetTags 读取
+	ProvenanceTags map[string]string
+
+	// RequestTimeout 单个请求的总超时时间，0表示使用默认值30s
+	// 上传大文件时应按文件大小与带宽调大
+	RequestTimeout time.Duration
+	// DialTimeout 建立连接的超时时间，0表示使用默认值10s
+	DialTimeout time.Duration
+
+	// CredentialsProvider 获取带过期时间的临时凭证，设置后忽略 SecretID/SecretKey
+	// 凭证在首次请求时获取，临近过期时在下次请求前自动刷新
+	CredentialsProvider CredentialsProvider
+
+	// BatchRoleArn 批量处理任务使用的CAM角色，如 qcs::cam::uin/<主账号UIN>:roleName/COS_Batch_QcsRole，
+	// 调用 BatchCosOperation 时必须设置
+	BatchRoleArn string
+	// BatchPollInterval 轮询批量处理任务状态的间隔，0表示使用默认值10s
+	BatchPollInterval time.Duration
+
+	// TLSSkipVerify 跳过服务端证书校验，仅用于开发环境的自签名证书或自建CA，默认false，生产环境不要设置
+	// 开启时创建上传器会输出警告日志
+	TLSSkipVerify bool
+
+	// DebugHTTP 以Debug级别记录发往存储服务的每个HTTP请求的方法、URL、响应状态码与耗时，用于排查问题；
+	// 不记录请求与响应内容，URL中的签名、令牌等查询参数会被隐去
+	DebugHTTP bool
+	// Logger DebugHTTP 使用的日志记录器，为nil时使用 slog.Default()
+	Logger *slog.Logger
+
+	// UserAgent 发往存储服务的请求的User-Agent，便于在服务商的访问日志中识别流量，为空时使用 DefaultUserAgent
+	UserAgent string
 }
 
+// 唯一文件名生成策略
+const (
+	KeyStrategyTimestamp  = "timestamp"  // baseName_<UnixNano>.ext
+	KeyStrategyUUID       = "uuid"       // baseName_<uuid>.ext
+	KeyStrategySequential = "sequential" // <补零的顺序编号>.ext，如 00001.jpg，见 Sequence
+	// KeyStrategyContentAddressed 按内容的SHA-256生成 sha256/ab/cd/<摘要>.ext，不含日期目录，
+	// 相同内容的key与URL不变，对象已存在时跳过写入；上传前需要额外读取一遍内容计算摘要
+	KeyStrategyContentAddressed = "content-addressed"
+)
+
 type ErrInvalidConfig struct {
 	error
 }
@@ -0,0 +1,36 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 02:20:15
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 02:20:15
+ * Description: 带过期时间的临时访问凭证
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import "time"
+
+// Credentials 一组访问凭证，通常为STS或工作负载身份换取的临时凭证
+type Credentials struct {
+	AccessKeyID     string    // 阿里云AccessKeyId、腾讯云SecretId
+	AccessKeySecret string    // 阿里云AccessKeySecret、腾讯云SecretKey
+	SecurityToken   string    // 临时凭证的安全令牌
+	Expiration      time.Time // 过期时间，零值表示不过期
+}
+
+// CredentialsProvider 获取访问凭证的函数
+// 上传器在首次请求前以及凭证临近过期(见 CredentialsRefreshWindow)时调用，调用是串行的
+type CredentialsProvider func() (Credentials, error)
+
+// CredentialsRefreshWindow 凭证在过期前多久刷新，避免请求在传输途中因凭证过期失败
+var CredentialsRefreshWindow = 5 * time.Minute
+
+// NeedsRefresh 判断凭证在now时是否已临近过期
+func (c Credentials) NeedsRefresh(now time.Time) bool {
+	return !c.Expiration.IsZero() && !now.Add(CredentialsRefreshWindow).Before(c.Expiration)
+}
+
+// Expired 判断凭证在now时是否已过期
+func (c Credentials) Expired(now time.Time) bool {
+	return !c.Expiration.IsZero() && !now.Before(c.Expiration)
+}
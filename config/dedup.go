@@ -0,0 +1,18 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 09:12:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 09:12:40
+ * Description: 按内容去重上传使用的去重索引
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+// DeduplicationStore 内容摘要到上传路径的去重索引
+// 多实例部署时可基于Redis(见 dedup/redisstore)、数据库等实现，使各实例共享索引，避免重复上传相同内容
+type DeduplicationStore interface {
+	// Get 查询摘要对应的上传路径
+	Get(hash string) (url string, ok bool)
+	// Set 记录摘要对应的上传路径
+	Set(hash string, url string)
+}
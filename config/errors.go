@@ -0,0 +1,66 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 09:41:02
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 09:41:02
+ * Description:
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 各存储后端共用的错误
+var (
+	ErrInvalidHeader        = errors.New("invalid upload header")
+	ErrNotSupported         = errors.New("operation not supported by this uploader")
+	ErrNotFound             = errors.New("object not found")
+	ErrInvalidPrefix        = errors.New("invalid key prefix")
+	ErrInvalidFilename      = errors.New("invalid filename")
+	ErrFileTypeMismatch     = errors.New("file type mismatch")
+	ErrInvalidKey           = errors.New("invalid object key")
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrInvalidRetention     = errors.New("invalid retention")
+	ErrObjectLocked         = errors.New("object is protected by retention")
+	ErrConfigWarning        = errors.New("config warning")
+	ErrInvalidTag           = errors.New("invalid object tag")
+	ErrTooManyCollisions    = errors.New("too many filename collisions")
+	ErrFileTypeForbidden    = errors.New("file type not allowed")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrSignatureExpired     = errors.New("signature expired")
+	ErrChecksumMismatch     = errors.New("checksum mismatch")
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+	ErrIsDirectory          = errors.New("path is a directory")
+	ErrVerificationFailed   = errors.New("upload verification failed")
+	ErrContentRejected      = errors.New("content rejected by scanner")
+	ErrUploadAborted        = errors.New("upload aborted")
+	ErrNotModified          = errors.New("object not modified")
+	ErrObjectExists         = errors.New("object already exists")
+)
+
+// ProviderError 云存储服务返回的错误详情，用于记录日志或向服务商提交工单
+// 上传、删除失败时各后端返回该类型(经 fmt.Errorf 包装)，错误信息包含 request_id=<id>，
+// 原始的SDK错误可通过 errors.As 取得
+type ProviderError struct {
+	Provider   string // 服务商: qiniu、aliyun、tencent
+	Code       string // 服务商的错误码，如 NoSuchKey；七牛云未返回错误码时为HTTP状态码
+	Message    string // 服务商返回的错误信息
+	RequestID  string // 服务商的请求ID，提交工单时需要提供
+	StatusCode int    // HTTP状态码
+	Err        error  // 原始的SDK错误
+}
+
+func (e *ProviderError) Error() string {
+	msg := fmt.Sprintf("%s error: %s (code=%s, status=%d", e.Provider, e.Message, e.Code, e.StatusCode)
+	if e.RequestID != "" {
+		msg += ", request_id=" + e.RequestID
+	}
+	return msg + ")"
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
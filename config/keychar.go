@@ -0,0 +1,78 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 06:44:27
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 06:44:27
+ * Description: 存储key的字符策略，替换服务商、CDN无法正确处理的字符
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// KeyCharPolicy 存储key的字符策略，在生成key之后应用，Delete、Exists对传入的key应用同一策略，保证往返一致
+// 不在 Allowed 中的字符先查 Replace 替换，其余替换为 Fallback；Fallback为空时按UTF-8百分号编码(%XX)，
+// 此时 % 视为已编码的字符原样保留，重复应用结果不变。/ 作为目录分隔符总是保留
+// 百分号编码后的key含有%，拼接访问URL时需要再次转义，直接返回给浏览器的场景建议使用替换
+type KeyCharPolicy struct {
+	Allowed  func(r rune) bool // 原样保留的字符，为nil时保留所有字符
+	Replace  map[rune]string   // 指定字符的替换，如 {' ': "-"}，替换结果应只含允许的字符
+	Fallback string            // 其余不允许的字符的替换，为空时百分号编码
+}
+
+var (
+	// KeyCharPolicyURLSafe url-safe预设: 保留各语言的字母、数字与 -._~，空格替换为 -，其余字符(+、%、#、? 等)替换为 _
+	KeyCharPolicyURLSafe = &KeyCharPolicy{
+		Allowed: func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-._~", r)
+		},
+		Replace:  map[rune]string{' ': "-"},
+		Fallback: "_",
+	}
+	// KeyCharPolicyStrictASCII strict-ascii预设: 只保留ASCII字母、数字与 -._，空格替换为 -，
+	// 其余字符(包括中文)按UTF-8百分号编码
+	KeyCharPolicyStrictASCII = &KeyCharPolicy{
+		Allowed: func(r rune) bool {
+			return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) || strings.ContainsRune("-._", r)
+		},
+		Replace: map[rune]string{' ': "-"},
+	}
+)
+
+// Apply 对key应用字符策略，p为nil时原样返回
+func (p *KeyCharPolicy) Apply(key string) string {
+	if p == nil || p.Allowed == nil && len(p.Replace) == 0 {
+		return key
+	}
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r == '/' || p.allowed(r):
+			b.WriteRune(r)
+		case p.Replace[r] != "":
+			b.WriteString(p.Replace[r])
+		case p.Fallback != "":
+			b.WriteString(p.Fallback)
+		case r == '%':
+			b.WriteRune(r)
+		default:
+			for _, c := range []byte(string(r)) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// allowed 判断字符是否原样保留
+func (p *KeyCharPolicy) allowed(r rune) bool {
+	if p.Allowed == nil {
+		_, replaced := p.Replace[r]
+		return !replaced
+	}
+	return p.Allowed(r)
+}
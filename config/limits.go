@@ -0,0 +1,28 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 21:52:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 21:52:40
+ * Description: 存储服务商的对象大小限制
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import "fmt"
+
+// ProviderLimits 存储服务商的上传限制，字段为0表示不限制
+type ProviderLimits struct {
+	MaxSinglePutSize int64 // 单次上传(非分片)的最大字节数
+	MinPartSize      int64 // 分片上传的最小分片(最后一片除外)
+	MaxPartSize      int64 // 分片上传的最大分片
+	MaxParts         int   // 分片上传的最大分片数
+}
+
+// CheckSinglePut 校验单次上传的大小，超出时返回包装了 ErrFileTooLarge 并建议改用分片上传的错误
+func (l ProviderLimits) CheckSinglePut(size int64) error {
+	if l.MaxSinglePutSize > 0 && size > l.MaxSinglePutSize {
+		return fmt.Errorf("%w: %d bytes exceeds single upload limit %d bytes, use multipart upload (multipart.NewOrchestrator) instead",
+			ErrFileTooLarge, size, l.MaxSinglePutSize)
+	}
+	return nil
+}
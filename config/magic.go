@@ -0,0 +1,47 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 16:58:20
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:49:26
+ * Description: 文件头魔数规则
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import "encoding/binary"
+
+// MagicRule 文件头魔数规则
+type MagicRule struct {
+	Signature   []byte   // 文件开头的魔数
+	ContentType string   // 魔数对应的内容类型
+	Extensions  []string // 允许的扩展名(小写，含点)，如 .jpg、.jpeg
+	// Match 可选，Signature匹配后对文件头的进一步校验，返回false时视为不匹配
+	Match func(head []byte) bool
+	// HeadSize Match需要的文件头长度，不足Signature长度时按Signature长度读取
+	HeadSize int
+}
+
+// MagicByteRules 严格类型校验使用的魔数规则表，可追加自定义规则
+// 应在程序初始化时修改，上传过程中并发修改不安全
+var MagicByteRules = []MagicRule{
+	{Signature: []byte("\x89PNG"), ContentType: "image/png", Extensions: []string{".png"}},
+	{Signature: []byte("\xFF\xD8\xFF"), ContentType: "image/jpeg", Extensions: []string{".jpg", ".jpeg"}},
+	{Signature: []byte("%PDF"), ContentType: "application/pdf", Extensions: []string{".pdf"}},
+	// Office Open XML 等格式同样是ZIP容器
+	{Signature: []byte("PK\x03\x04"), ContentType: "application/zip", Extensions: []string{".zip", ".docx", ".xlsx", ".pptx", ".jar", ".apk"}},
+	// MZ只有两个字节，文本等内容也可能以它开头，还需0x3C处的偏移指向PE签名
+	{Signature: []byte("MZ"), ContentType: "application/vnd.microsoft.portable-executable", Extensions: []string{".exe", ".dll"},
+		Match: isPortableExecutable, HeadSize: peHeadSize},
+}
+
+// peHeadSize 查找PE签名读取的文件头长度，常见的PE头偏移都在此范围内
+const peHeadSize = 1024
+
+// isPortableExecutable 判断DOS头0x3C处记录的偏移(小端uint32)是否指向 PE\0\0 签名
+func isPortableExecutable(head []byte) bool {
+	if len(head) < 0x40 {
+		return false
+	}
+	offset := uint64(binary.LittleEndian.Uint32(head[0x3C:]))
+	return offset+4 <= uint64(len(head)) && string(head[offset:offset+4]) == "PE\x00\x00"
+}
@@ -0,0 +1,53 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 09:12:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 09:12:40
+ * Description:
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import "time"
+
+// ObjectMeta 对象元数据
+// 用于在复制或更新对象时指定新的HTTP头及自定义元数据
+type ObjectMeta struct {
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	Metadata           map[string]string // 自定义元数据，不含厂商前缀(如x-oss-meta-)
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	// UploadedAt 以 WithUploadedAt 上传时记录的上传时间；未记录或所在上传路径不支持自定义元数据(如分片上传)时
+	// 等于 LastModified，而 LastModified 在复制、覆盖后会改变
+	UploadedAt time.Time
+	// StorageClass 存储类型，已知类型为 StorageClass* 常量，其余为服务商的原始取值；本地存储为空
+	StorageClass string
+	// CustomHeaders 自定义元数据头，键为小写的完整头名称，如 x-oss-meta-author
+	CustomHeaders map[string]string
+}
+
+// UploadedAtMeta 记录上传时间的自定义元数据名，不含服务商前缀(如 x-oss-meta-)
+const UploadedAtMeta = "uploaded-at"
+
+// FormatUploadedAt 返回写入元数据的上传时间(UTC，RFC3339，精确到纳秒)
+func FormatUploadedAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// ParseUploadedAt 解析元数据中的上传时间，value为空或无法解析时返回fallback(通常为 LastModified)
+func ParseUploadedAt(value string, fallback time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t
+	}
+	return fallback
+}
@@ -0,0 +1,634 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 09:40:15
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 09:40:15
+ * Description:
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// UploadOptions 单次上传的可选参数
+type UploadOptions struct {
+	// Headers 透传给云存储的原始请求头
+	// 仅阿里云OSS与腾讯云COS生效，本地存储与七牛云会忽略
+	// 具体行为取决于服务商，例如 x-oss-forbid-overwrite
+	Headers http.Header
+
+	// ExtraHeaders 自定义元数据头，如 x-oss-meta-author、x-cos-meta-author
+	// 带本服务商元数据前缀的头作为对象元数据保存，可通过 GetObjectInfo 的 CustomHeaders 读取
+	// 其余头按原始请求头透传，规则同 Headers
+	ExtraHeaders map[string]string
+
+	// Key 指定存储key，为空时由上传器自动生成
+	// 已存在的同名对象会被覆盖
+	Key string
+
+	// Filename 覆盖用于生成key与推断内容类型的原始文件名，如来自单独表单字段的文件名
+	// 合并选项时会做清理：去除目录部分与控制字符
+	Filename string
+
+	// StrictTypeValidation 按文件头魔数识别类型并与扩展名交叉校验
+	// 不一致时返回 ErrFileTypeMismatch，魔数规则见 MagicByteRules
+	StrictTypeValidation bool
+
+	// ContentType 本次上传的内容类型，优先于扩展名覆盖、内容嗅探与扩展名推断
+	ContentType string
+
+	// CacheControl 本次上传的Cache-Control，优先于配置中的 ContentTypeCacheRules
+	CacheControl string
+
+	// Tags 对象标签，不能使用 ProvenanceTagPrefix 前缀
+	// 七牛云不支持对象标签，设置后返回 ErrNotSupported
+	Tags map[string]string
+
+	// Retention 对象锁定(WORM)保留设置，为nil表示不设置
+	// 不支持对象级保留的存储后端会返回 ErrNotSupported，而不是忽略该选项
+	Retention *Retention
+
+	// StorageClass 写入时的存储类型，为空时使用存储桶的默认类型
+	// 取值为 StorageClassStandard、StorageClassInfrequentAccess 或 StorageClassArchive，由各后端转换为服务商的类型；
+	// 本地存储没有存储类型，会忽略该选项
+	StorageClass string
+
+	// CustomerKey 客户提供的AES-256加密密钥(SSE-C)，必须为32字节
+	// 服务端用该密钥加密对象且不保存密钥，读取时必须提供同一密钥；密钥丢失意味着数据无法恢复。
+	// 目前仅腾讯云COS支持，其他后端返回 ErrNotSupported
+	CustomerKey []byte
+
+	// Timeout 本次上传的总超时时间，0表示不限制
+	Timeout time.Duration
+	// TimeoutPerMB 按内容大小计算超时时间：每MB(不足1MB按1MB计)允许的时间，结果不低于 MinUploadTimeout；
+	// 大小未知时使用 MaxUploadTimeout。与 Timeout 同时设置时取较小者
+	TimeoutPerMB time.Duration
+
+	// VerifyAfterUpload 上传后立即查询对象元数据并核对大小，对象不存在或大小不符时返回 ErrVerificationFailed；
+	// 若服务商的上传响应已经过CRC校验则跳过查询
+	VerifyAfterUpload bool
+
+	// RecordUploadedAt 将上传时间记录为对象元数据(本地存储记录在标签旁路文件中)，
+	// 通过 GetObjectInfo 的 UploadedAt 读取，不随复制改变
+	RecordUploadedAt bool
+
+	// MaxSize 上传内容的字节数上限，0表示不限制；在读取上传流的过程中计数，超出时中止上传并返回 ErrFileTooLarge，
+	// 适用于大小未知或不可信(如客户端声明的Content-Length)的流
+	MaxSize int64
+	// Scanner 流式内容扫描器(如病毒扫描)，与摘要计算共用同一次读取
+	Scanner ContentScanner
+
+	// AuditSink 上传成功后回调的审计函数
+	AuditSink func(AuditRecord)
+	// Actor 审计记录中的操作者
+	Actor string
+	// RequestID 调用方的请求/关联ID，写入审计记录并在 UploadResult 中原样返回，用于跨服务追踪
+	RequestID string
+	// Result 上传成功后写入写入字节数、耗时与内容摘要，供只返回路径的上传方法取得 UploadResult 中的信息
+	Result *UploadResult
+
+	// ctx 由 WithContext 指定的父context，上传超时在其基础上计算
+	ctx context.Context
+}
+
+// 对象锁定保留模式
+const (
+	RetentionGovernance = "GOVERNANCE" // 治理模式，有特殊权限的用户可提前删除
+	RetentionCompliance = "COMPLIANCE" // 合规模式，保留期内任何用户都不能删除
+)
+
+// Retention 对象锁定保留设置
+type Retention struct {
+	Mode  string    // 保留模式，RetentionGovernance 或 RetentionCompliance
+	Until time.Time // 保留截止时间
+}
+
+// 存储类型，取值对应各服务商的同类存储
+const (
+	StorageClassStandard         = "STANDARD" // 标准存储
+	StorageClassInfrequentAccess = "IA"       // 低频访问存储，单价更低，有最短存储时间与取回费用
+	StorageClassArchive          = "ARCHIVE"  // 归档存储，读取前需要解冻
+)
+
+// AuditRecord 上传审计记录
+type AuditRecord struct {
+	Time      time.Time     // 完成时间
+	Key       string        // 存储key(本地存储为相对路径)
+	Size      int64         // 写入字节数
+	Duration  time.Duration // 写入耗时，从开始读取上传内容到写入完成，各后端计时范围一致
+	MD5       string        // 内容的MD5(十六进制)，在上传过程中计算
+	SHA256    string        // 内容的SHA-256(十六进制)，在上传过程中计算
+	Actor     string        // 由 WithActor 指定的操作者
+	RequestID string        // 由 WithRequestID 指定的关联ID
+}
+
+// UploadResult 上传结果
+type UploadResult struct {
+	Path        string // 上传方法返回的路径或URL
+	Size        int64
+	ContentType string        // 校验时识别出的内容类型
+	RequestID   string        // 由 WithRequestID 指定的关联ID
+	Duration    time.Duration // 写入耗时，同 AuditRecord.Duration
+	// MD5、SHA256 为内容摘要(十六进制)，随上传读取一并计算，无需再次读取内容
+	// 去重命中已有对象等未实际写入时为空
+	MD5    string
+	SHA256 string
+}
+
+// UploadOption 上传选项
+// 既可以使用 WithXxx 函数，也可以直接传入 UploadOptions 结构体
+type UploadOption interface {
+	apply(*UploadOptions)
+}
+
+// apply 将非零字段合并到dst，使 UploadOptions 本身也可作为上传选项传入
+func (o UploadOptions) apply(dst *UploadOptions) {
+	for name, values := range o.Headers {
+		for _, v := range values {
+			WithHeader(name, v).apply(dst)
+		}
+	}
+	if len(o.ExtraHeaders) > 0 {
+		if dst.ExtraHeaders == nil {
+			dst.ExtraHeaders = make(map[string]string, len(o.ExtraHeaders))
+		}
+		for k, v := range o.ExtraHeaders {
+			dst.ExtraHeaders[k] = v
+		}
+	}
+	if o.Key != "" {
+		dst.Key = o.Key
+	}
+	if o.Filename != "" {
+		dst.Filename = o.Filename
+	}
+	if o.StrictTypeValidation {
+		dst.StrictTypeValidation = true
+	}
+	if o.ContentType != "" {
+		dst.ContentType = o.ContentType
+	}
+	if o.CacheControl != "" {
+		dst.CacheControl = o.CacheControl
+	}
+	if len(o.Tags) > 0 {
+		if dst.Tags == nil {
+			dst.Tags = make(map[string]string, len(o.Tags))
+		}
+		for k, v := range o.Tags {
+			dst.Tags[k] = v
+		}
+	}
+	if o.Retention != nil {
+		dst.Retention = o.Retention
+	}
+	if o.StorageClass != "" {
+		dst.StorageClass = o.StorageClass
+	}
+	if o.CustomerKey != nil {
+		dst.CustomerKey = o.CustomerKey
+	}
+	if o.Timeout != 0 {
+		dst.Timeout = o.Timeout
+	}
+	if o.TimeoutPerMB != 0 {
+		dst.TimeoutPerMB = o.TimeoutPerMB
+	}
+	if o.VerifyAfterUpload {
+		dst.VerifyAfterUpload = true
+	}
+	if o.RecordUploadedAt {
+		dst.RecordUploadedAt = true
+	}
+	if o.MaxSize != 0 {
+		dst.MaxSize = o.MaxSize
+	}
+	if o.Scanner != nil {
+		dst.Scanner = o.Scanner
+	}
+	if o.AuditSink != nil {
+		dst.AuditSink = o.AuditSink
+	}
+	if o.Actor != "" {
+		dst.Actor = o.Actor
+	}
+	if o.RequestID != "" {
+		dst.RequestID = o.RequestID
+	}
+	if o.Result != nil {
+		dst.Result = o.Result
+	}
+	if o.ctx != nil {
+		dst.ctx = o.ctx
+	}
+}
+
+// optionFunc 以函数形式实现的上传选项
+type optionFunc func(*UploadOptions)
+
+func (f optionFunc) apply(o *UploadOptions) { f(o) }
+
+// NewUploadOptions 合并上传选项并校验
+func NewUploadOptions(opts ...UploadOption) (*UploadOptions, error) {
+	o := &UploadOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(o)
+		}
+	}
+
+	if o.Filename != "" {
+		name := SanitizeFilename(o.Filename)
+		if name == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFilename, o.Filename)
+		}
+		o.Filename = name
+	}
+
+	if strings.ContainsAny(o.ContentType, "\r\n") {
+		return nil, fmt.Errorf("%w: content type contains line break", ErrInvalidHeader)
+	}
+	if strings.ContainsAny(o.RequestID, "\r\n") {
+		return nil, fmt.Errorf("%w: request id contains line break", ErrInvalidHeader)
+	}
+
+	for k := range o.Tags {
+		if k == "" {
+			return nil, fmt.Errorf("%w: empty key", ErrInvalidTag)
+		}
+		if strings.HasPrefix(k, ProvenanceTagPrefix) {
+			return nil, fmt.Errorf("%w: key %q uses reserved prefix %q", ErrInvalidTag, k, ProvenanceTagPrefix)
+		}
+	}
+
+	if r := o.Retention; r != nil {
+		mode := strings.ToUpper(r.Mode)
+		if mode != RetentionGovernance && mode != RetentionCompliance {
+			return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidRetention, r.Mode)
+		}
+		if !r.Until.After(time.Now()) {
+			return nil, fmt.Errorf("%w: retain-until date %s is not in the future", ErrInvalidRetention, r.Until.Format(time.RFC3339))
+		}
+		o.Retention = &Retention{Mode: mode, Until: r.Until}
+	}
+
+	if o.StorageClass != "" {
+		class := strings.ToUpper(o.StorageClass)
+		if class != StorageClassStandard && class != StorageClassInfrequentAccess && class != StorageClassArchive {
+			return nil, fmt.Errorf("unknown storage class %q: %w", o.StorageClass, ErrNotSupported)
+		}
+		o.StorageClass = class
+	}
+
+	if o.CustomerKey != nil && len(o.CustomerKey) != CustomerKeySize {
+		return nil, fmt.Errorf("%w: customer key must be %d bytes, got %d", ErrInvalidEncryptionKey, CustomerKeySize, len(o.CustomerKey))
+	}
+
+	if o.Timeout < 0 || o.TimeoutPerMB < 0 {
+		return nil, errors.New("upload timeout must not be negative")
+	}
+
+	for name, value := range o.ExtraHeaders {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%w: value of %q contains line break", ErrInvalidHeader, name)
+		}
+	}
+	for name, values := range o.Headers {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, name)
+		}
+		for _, v := range values {
+			if strings.ContainsAny(v, "\r\n") {
+				return nil, fmt.Errorf("%w: value of %q contains line break", ErrInvalidHeader, name)
+			}
+		}
+	}
+
+	if o.Result != nil {
+		o.CaptureDigest(o.Result)
+	}
+	return o, nil
+}
+
+// WithHeader 为本次上传追加一个原始请求头，可多次调用
+// 这是类型化选项尚未覆盖的服务商特性的兜底手段，行为由服务商决定
+func WithHeader(name, value string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		if o.Headers == nil {
+			o.Headers = make(http.Header)
+		}
+		o.Headers.Add(name, value)
+	})
+}
+
+// WithKey 指定本次上传的存储key，不再自动生成日期路径与唯一文件名
+// 同一上传器实例内对同一key的并发上传会串行执行，该锁不跨实例与进程
+func WithKey(key string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Key = key
+	})
+}
+
+// WithFilename 覆盖本次上传用于生成key与推断内容类型的文件名
+// 适用于真实文件名来自单独表单字段或请求头、而非 FileHeader.Filename 的场景
+func WithFilename(name string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Filename = name
+	})
+}
+
+// FilenameOr 返回 WithFilename 指定的文件名，未指定时返回name
+func (o *UploadOptions) FilenameOr(name string) string {
+	if o.Filename != "" {
+		return o.Filename
+	}
+	return name
+}
+
+// SanitizeFilename 清理外部传入的文件名
+// 只保留最后一段路径(同时识别/与\分隔符)，去除控制字符与首尾空白，结果无效时返回空字符串
+func SanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.ReplaceAll(name, "\\", "/")
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// WithStrictTypeValidation 开启文件头魔数校验，防止通过修改扩展名绕过类型检查
+func WithStrictTypeValidation() UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.StrictTypeValidation = true
+	})
+}
+
+// WithContentType 指定本次上传的内容类型
+func WithContentType(contentType string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.ContentType = contentType
+	})
+}
+
+// WithCacheControl 设置本次上传的Cache-Control
+func WithCacheControl(value string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.CacheControl = value
+	})
+}
+
+// WithTags 为本次上传追加对象标签，可多次调用
+func WithTags(tags map[string]string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		if o.Tags == nil {
+			o.Tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			o.Tags[k] = v
+		}
+	})
+}
+
+// ProvenanceTagPrefix 来源标签的键前缀，配置中的 ProvenanceTags 会自动加上该前缀，
+// 用户通过 WithTags 设置的标签不能使用该前缀，因此两者不会冲突
+const ProvenanceTagPrefix = "provenance-"
+
+// ObjectTags 合并实例级来源标签与本次上传的标签，没有标签时返回nil
+func ObjectTags(provenance map[string]string, o *UploadOptions) map[string]string {
+	if len(provenance) == 0 && len(o.Tags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(provenance)+len(o.Tags))
+	for k, v := range o.Tags {
+		tags[k] = v
+	}
+	for k, v := range provenance {
+		tags[ProvenanceTagPrefix+k] = v
+	}
+	return tags
+}
+
+// WithRetention 为本次上传设置对象锁定保留，mode为 RetentionGovernance 或 RetentionCompliance(不区分大小写)
+// until必须晚于当前时间，否则返回 ErrInvalidRetention
+func WithRetention(mode string, until time.Time) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Retention = &Retention{Mode: mode, Until: until}
+	})
+}
+
+// WithStorageClass 指定本次上传的存储类型(不区分大小写)，不支持的类型返回 ErrNotSupported
+func WithStorageClass(class string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.StorageClass = class
+	})
+}
+
+// WithReducedRedundancy 以更低成本的存储类型写入临时文件
+// OSS与COS已不提供低冗余存储(REDUCED_REDUNDANCY)，这里使用最接近的低频访问存储，
+// 注意低频存储有最短存储时间，提前删除仍按最短时间计费
+func WithReducedRedundancy() UploadOption {
+	return WithStorageClass(StorageClassInfrequentAccess)
+}
+
+// CustomerKeySize SSE-C密钥长度，AES-256为32字节
+const CustomerKeySize = 32
+
+// WithCustomerKey 使用客户提供的密钥(SSE-C)加密本次上传的对象，key必须为32字节，否则返回 ErrInvalidEncryptionKey
+// 服务端不保存密钥，读取时必须提供同一密钥，密钥丢失后数据无法恢复
+func WithCustomerKey(key []byte) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.CustomerKey = key
+	})
+}
+
+// ContentScanner 流式内容扫描器，上传过程中通过 Write 观察内容，内容读完后调用一次 Verdict，
+// 返回非nil时上传失败并返回包装了 ErrContentRejected 的错误，对象不会写入(本地存储删除已写入的文件)
+type ContentScanner interface {
+	io.Writer
+	Verdict() error
+}
+
+// WithMaxSize 限制上传内容的字节数，读取过程中超出时中止上传并返回 ErrFileTooLarge
+func WithMaxSize(n int64) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.MaxSize = n
+	})
+}
+
+// WithScanner 在上传过程中用s扫描内容，与摘要计算、大小限制共用同一次读取，不会额外读取或缓存内容
+func WithScanner(s ContentScanner) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Scanner = s
+	})
+}
+
+// WithAuditSink 设置审计回调，上传成功后以审计记录调用
+// 便于集中记录"谁在何时上传了什么"，而不是在各处理函数中自行拼装
+func WithAuditSink(sink func(AuditRecord)) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.AuditSink = sink
+	})
+}
+
+// WithResult 上传成功后将写入字节数、耗时、内容摘要与关联ID写入res，Path与ContentType不会设置
+// 用于 UploadBinary 等只返回路径的方法，使各后端以同样的方式报告写入结果；
+// 不经过单次上传读取的路径(如服务端复制、分片上传)不会写入
+func WithResult(res *UploadResult) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Result = res
+	})
+}
+
+// CaptureDigest 上传成功后将写入字节数、耗时、内容摘要与关联ID写入res，保留已设置的审计回调
+func (o *UploadOptions) CaptureDigest(res *UploadResult) {
+	sink := o.AuditSink
+	o.AuditSink = func(r AuditRecord) {
+		res.Size, res.Duration = r.Size, r.Duration
+		res.MD5, res.SHA256 = r.MD5, r.SHA256
+		res.RequestID = r.RequestID
+		if sink != nil {
+			sink(r)
+		}
+	}
+}
+
+// WithActor 设置审计记录中的操作者
+func WithActor(actor string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Actor = actor
+	})
+}
+
+// WithRequestID 指定本次上传的请求/关联ID，写入审计记录并在 UploadResult 中返回
+// 内部重试(如凭证刷新后重传)使用同一组选项，关联ID保持不变
+func WithRequestID(id string) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.RequestID = id
+	})
+}
+
+// 按大小计算上传超时时间的上下限，可在程序启动时修改
+var (
+	// MinUploadTimeout WithTimeoutPerMB 计算结果的下限，避免小文件因网络抖动超时
+	MinUploadTimeout = 10 * time.Second
+	// MaxUploadTimeout 内容大小未知(流式上传)时 WithTimeoutPerMB 使用的超时时间
+	MaxUploadTimeout = time.Hour
+)
+
+// WithTimeout 设置本次上传的总超时时间，超时后中止上传并返回 context.DeadlineExceeded
+func WithTimeout(d time.Duration) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.Timeout = d
+	})
+}
+
+// WithTimeoutPerMB 按内容大小设置超时时间，每MB允许d，结果不低于 MinUploadTimeout，
+// 使小文件不会长期占用宽松的固定超时，大文件也有足够的时间；与 WithTimeout 同时使用时取较小者
+func WithTimeoutPerMB(d time.Duration) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.TimeoutPerMB = d
+	})
+}
+
+// WithContext 使本次上传在ctx取消或超时时中止，用于不带context参数的上传方法
+func WithContext(ctx context.Context) UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.ctx = ctx
+	})
+}
+
+// WithVerifyAfterUpload 上传完成后以HEAD/stat核对对象大小，用于最终一致或经过代理的存储；
+// 会多一次请求，上传响应已经过CRC校验时自动跳过
+func WithVerifyAfterUpload() UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.VerifyAfterUpload = true
+	})
+}
+
+// WithUploadedAt 将上传时间记录为对象元数据，作为不受复制影响的创建时间，用于排序与展示
+func WithUploadedAt() UploadOption {
+	return optionFunc(func(o *UploadOptions) {
+		o.RecordUploadedAt = true
+	})
+}
+
+// VerifySize 以size查询对象的实际大小并与want比对，供各后端实现 WithVerifyAfterUpload；
+// 对象不存在或大小不符时返回 ErrVerificationFailed
+func VerifySize(size func() (int64, error), want int64) error {
+	got, err := size()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	if got != want {
+		return fmt.Errorf("%w: size %d, want %d", ErrVerificationFailed, got, want)
+	}
+	return nil
+}
+
+// UploadTimeout 返回大小为size的内容的上传超时时间，size小于0表示大小未知，返回0表示不限制
+func (o *UploadOptions) UploadTimeout(size int64) time.Duration {
+	timeout := o.Timeout
+	if o.TimeoutPerMB > 0 {
+		perMB := MaxUploadTimeout
+		if size >= 0 {
+			perMB = time.Duration((size+(1<<20)-1)>>20) * o.TimeoutPerMB
+			if perMB < MinUploadTimeout {
+				perMB = MinUploadTimeout
+			}
+		}
+		if timeout == 0 || perMB < timeout {
+			timeout = perMB
+		}
+	}
+	return timeout
+}
+
+// Context 返回带有上传超时的上下文，没有设置超时时返回不会超时的上下文
+// 以 WithContext 指定的context为父context，未指定时使用 context.Background()
+func (o *UploadOptions) Context(size int64) (context.Context, context.CancelFunc) {
+	parent := o.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout := o.UploadTimeout(size); timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// validHeaderName 校验请求头名称是否为合法的HTTP token
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
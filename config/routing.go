@@ -0,0 +1,87 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 08:03:51
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:03:51
+ * Description: 按文件类型将生成的key路由到不同前缀目录
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RoutingRule 一条路由规则，扩展名或内容类型任一匹配即使用 Prefix
+type RoutingRule struct {
+	Extensions   []string // 扩展名，如 .jpg 或 jpg，不区分大小写
+	ContentTypes []string // 内容类型，如 application/pdf，以 /* 结尾时匹配该大类，如 image/*
+	Prefix       string   // 匹配时的key前缀目录，如 images
+}
+
+// RoutingRules 生成key时按文件类型选择前缀目录，规则按顺序匹配，第一个匹配的规则生效
+// 只作用于生成的key，WithKey 指定的key与内容寻址key不受影响
+type RoutingRules struct {
+	Rules   []RoutingRule
+	Default string // 没有规则匹配时的前缀，为空时不加前缀
+}
+
+// Prefix 返回文件应使用的key前缀，r为nil时返回空
+// contentType为上传时已知的内容类型，未指定时由调用方按扩展名推断
+func (r *RoutingRules) Prefix(filename, contentType string) string {
+	if r == nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(filename))
+	contentType, _, _ = strings.Cut(strings.ToLower(contentType), ";")
+	contentType = strings.TrimSpace(contentType)
+
+	for _, rule := range r.Rules {
+		for _, e := range rule.Extensions {
+			if e = strings.ToLower(e); ext != "" && (e == ext || "."+e == ext) {
+				return rule.Prefix
+			}
+		}
+		for _, pattern := range rule.ContentTypes {
+			if matchContentType(strings.ToLower(pattern), contentType) {
+				return rule.Prefix
+			}
+		}
+	}
+	return r.Default
+}
+
+// Validate 校验各前缀为相对目录：不含 .、.. 与空的路径段，不含 \，首尾的 / 会被忽略
+func (r *RoutingRules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	prefixes := []string{r.Default}
+	for _, rule := range r.Rules {
+		prefixes = append(prefixes, rule.Prefix)
+	}
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		for _, seg := range strings.Split(strings.Trim(prefix, "/"), "/") {
+			if seg == "" || seg == "." || seg == ".." || strings.Contains(seg, `\`) {
+				return fmt.Errorf("%w: routing prefix %q", ErrInvalidPrefix, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// matchContentType 判断内容类型是否匹配，pattern以 /* 结尾时匹配同一大类
+func matchContentType(pattern, contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	if major, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(contentType, major+"/")
+	}
+	return pattern == contentType
+}
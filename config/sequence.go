@@ -0,0 +1,88 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 04:36:12
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 04:36:12
+ * Description: 顺序编号key使用的计数器
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultSequenceWidth 顺序编号的默认补零位数
+const DefaultSequenceWidth = 5
+
+// SequenceStore 顺序编号的持久化钩子，使编号在进程重启后延续
+// scope为key的目录部分(如 2026/10/18)，没有目录时为空字符串
+type SequenceStore interface {
+	// Load 返回scope已分配的最大编号，没有记录时返回0
+	Load(scope string) (uint64, error)
+	// Save 记录scope已分配的最大编号
+	Save(scope string, n uint64) error
+}
+
+// Sequence 并发安全的顺序编号计数器，按scope分别从1开始递增
+// 计数保存在内存中，每个scope首次使用时从Store加载，每次分配后写回Store。
+// 计数器只在单个进程内保证唯一：多个进程(或多个Sequence)向同一目录写入时编号会重复，
+// 对象存储上重复的key会直接覆盖已有对象。Store只在scope首次使用时加载，不能协调多个进程，此时必须让各进程写入不同的目录
+type Sequence struct {
+	// Width 编号补零后的最小位数，0表示 DefaultSequenceWidth，编号超出位数时不截断
+	Width int
+	// Store 持久化钩子，为nil时只保存在内存中，进程重启后从1重新开始
+	Store SequenceStore
+
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// NewSequence 创建顺序编号计数器，store可以为nil
+func NewSequence(width int, store SequenceStore) *Sequence {
+	return &Sequence{Width: width, Store: store}
+}
+
+// Persistent 判断计数器是否配置了持久化钩子，s为nil时返回false
+// 云存储后端的sequential策略要求持久化，否则重启后编号从1开始，会覆盖已有对象
+func (s *Sequence) Persistent() bool {
+	return s != nil && s.Store != nil
+}
+
+// DefaultSequence 配置中未指定 Sequence 时使用的进程内计数器
+var DefaultSequence = NewSequence(DefaultSequenceWidth, nil)
+
+// Next 分配scope的下一个编号，持久化失败时不消耗编号
+func (s *Sequence) Next(scope string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, loaded := s.counters[scope]
+	if !loaded && s.Store != nil {
+		var err error
+		if n, err = s.Store.Load(scope); err != nil {
+			return 0, fmt.Errorf("failed to load key sequence: %w", err)
+		}
+	}
+	n++
+	if s.Store != nil {
+		if err := s.Store.Save(scope, n); err != nil {
+			return 0, fmt.Errorf("failed to save key sequence: %w", err)
+		}
+	}
+	if s.counters == nil {
+		s.counters = make(map[string]uint64)
+	}
+	s.counters[scope] = n
+	return n, nil
+}
+
+// Format 按补零位数格式化编号
+func (s *Sequence) Format(n uint64) string {
+	width := s.Width
+	if width <= 0 {
+		width = DefaultSequenceWidth
+	}
+	return fmt.Sprintf("%0*d", width, n)
+}
@@ -0,0 +1,38 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:12:05
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:12:05
+ * Description: 发往存储服务的请求的默认User-Agent
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package config
+
+import "runtime/debug"
+
+// modulePath 本模块的导入路径，用于从构建信息中取得版本
+const modulePath = "github.com/zjguoxin/gosuploader"
+
+// DefaultUserAgent 未配置 UserAgent 时使用的User-Agent，格式为 gosuploader/<版本>，
+// 版本取自构建信息中本模块的版本，无法取得(如在本仓库内构建)时为 dev
+var DefaultUserAgent = "gosuploader/" + moduleVersion()
+
+// moduleVersion 返回构建信息中本模块的版本
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "dev"
+}
@@ -0,0 +1,71 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 03:12:44
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 03:12:44
+ * Description: 将小对象读取为data URI，用于内联小图片
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DownloadDataURI 读取对象并返回 data:<内容类型>;base64,<内容> 形式的data URI，便于内联小图标、头像
+// 内容类型优先使用对象保存的类型，没有时按内容嗅探；对象超过maxSize字节时返回 ErrFileTooLarge，不会读取内容。
+// 上传器需要实现 ObjectInspector 与 RangeReader，否则返回 ErrNotSupported
+func DownloadDataURI(u Uploader, key string, maxSize int64) (string, error) {
+	inspector, ok := u.(ObjectInspector)
+	if !ok {
+		return "", ErrNotSupported
+	}
+	reader, ok := u.(RangeReader)
+	if !ok {
+		return "", ErrNotSupported
+	}
+
+	info, err := inspector.GetObjectInfo(key)
+	if err != nil {
+		return "", err
+	}
+	if info.Size > maxSize {
+		return "", fmt.Errorf("%w: object is %d bytes, limit is %d", ErrFileTooLarge, info.Size, maxSize)
+	}
+
+	// 空对象不发起读取，部分服务商对空对象的Range请求返回416
+	var data []byte
+	if info.Size > 0 {
+		if data, err = readAtMost(reader, key, maxSize); err != nil {
+			return "", err
+		}
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// readAtMost 读取对象，超过maxSize字节时返回 ErrFileTooLarge
+// 多读1字节，用于发现查询信息后对象被替换为更大的内容
+func readAtMost(reader RangeReader, key string, maxSize int64) ([]byte, error) {
+	body, err := reader.DownloadRange(context.Background(), key, 0, maxSize+1)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: object exceeds %d bytes", ErrFileTooLarge, maxSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,160 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 00:05:12
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 09:12:40
+ * Description: 按内容摘要去重上传，去重索引可替换为多实例共享的存储
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/b64"
+)
+
+// DeduplicationStore 内容摘要到上传路径的去重索引，内置 MemoryDeduplicationStore 与 dedup/redisstore 中基于Redis的实现
+type DeduplicationStore = config.DeduplicationStore
+
+// defaultDedupCapacity MemoryDeduplicationStore 的默认容量
+const defaultDedupCapacity = 10000
+
+// MemoryDeduplicationStore 进程内的LRU去重索引，超过容量时淘汰最久未使用的记录
+type MemoryDeduplicationStore struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// dedupEntry LRU链表中的记录
+type dedupEntry struct {
+	hash string
+	url  string
+}
+
+// NewMemoryDeduplicationStore 创建进程内去重索引，capacity不大于0时使用默认容量10000
+func NewMemoryDeduplicationStore(capacity int) *MemoryDeduplicationStore {
+	if capacity <= 0 {
+		capacity = defaultDedupCapacity
+	}
+	return &MemoryDeduplicationStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get 查询摘要对应的上传路径，命中时将记录标记为最近使用
+func (s *MemoryDeduplicationStore) Get(hash string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[hash]
+	if !ok {
+		return "", false
+	}
+	s.ll.MoveToFront(e)
+	return e.Value.(*dedupEntry).url, true
+}
+
+// Set 记录摘要对应的上传路径
+func (s *MemoryDeduplicationStore) Set(hash string, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[hash]; ok {
+		e.Value.(*dedupEntry).url = url
+		s.ll.MoveToFront(e)
+		return
+	}
+	s.items[hash] = s.ll.PushFront(&dedupEntry{hash: hash, url: url})
+	if s.ll.Len() > s.capacity {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(*dedupEntry).hash)
+	}
+}
+
+// Deduplicator 按内容的SHA-256去重的上传器，相同内容只上传一次，之后直接返回首次上传的路径
+//
+// 通过 WithKey 指定了存储key的上传与 UploadFSFile 不参与去重。
+// 去重索引不感知删除，删除已记录的对象后，相同内容的上传仍会返回已删除的路径，
+// 需要删除对象的场景应由 DeduplicationStore 的实现自行清理索引
+type Deduplicator struct {
+	Uploader
+	store DeduplicationStore
+}
+
+// NewDeduplicator 以去重索引包装上传器，store为nil时使用默认容量的 MemoryDeduplicationStore
+func NewDeduplicator(u Uploader, store DeduplicationStore) *Deduplicator {
+	if store == nil {
+		store = NewMemoryDeduplicationStore(0)
+	}
+	return &Deduplicator{Uploader: u, store: store}
+}
+
+// UploadBinary 上传二进制数据，相同内容已上传过时直接返回已有路径
+func (d *Deduplicator) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
+	sum := sha256.Sum256(content)
+	return d.upload(hex.EncodeToString(sum[:]), opts, func() (string, error) {
+		return d.Uploader.UploadBinary(filename, content, opts...)
+	})
+}
+
+// UploadBase64 解码后按内容去重上传
+func (d *Deduplicator) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
+	content, err := b64.Decode(base64Str, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %w", err)
+	}
+	return d.UploadBinary(filename, content, opts...)
+}
+
+// UploadFile 计算表单文件的摘要后去重上传
+func (d *Deduplicator) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
+	if file == nil {
+		return d.Uploader.UploadFile(file, opts...)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to hash uploaded file: %w", err)
+	}
+
+	return d.upload(hex.EncodeToString(h.Sum(nil)), opts, func() (string, error) {
+		return d.Uploader.UploadFile(file, opts...)
+	})
+}
+
+// upload 查询去重索引，未命中时执行上传并记录
+func (d *Deduplicator) upload(hash string, opts []config.UploadOption, do func() (string, error)) (string, error) {
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+	if o.Key != "" {
+		return do()
+	}
+
+	if url, ok := d.store.Get(hash); ok {
+		return url, nil
+	}
+	url, err := do()
+	if err != nil {
+		return "", err
+	}
+	d.store.Set(hash, url)
+	return url, nil
+}
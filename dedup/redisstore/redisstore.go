@@ -0,0 +1,58 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 09:16:27
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 09:16:27
+ * Description: 基于Redis的去重索引，多个实例共享，只有导入本包时才会编译go-redis
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultKeyPrefix 未指定前缀时使用的Redis key前缀
+const DefaultKeyPrefix = "gosuploader:dedup:"
+
+// opTimeout 单次Redis读写的超时时间
+const opTimeout = 3 * time.Second
+
+// RedisDeduplicationStore 基于Redis的去重索引，实现 config.DeduplicationStore，
+// 可作为 uploader.NewDeduplicator 的参数或配置中的 DeduplicationStore 字段。
+// 索引接口不返回错误：Redis不可用时Get视为未命中(重新上传)，Set失败时丢弃该记录，上传本身不受影响
+type RedisDeduplicationStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// New 以调用方创建的Redis客户端创建去重索引，客户端的连接与关闭由调用方管理
+// prefix为空时使用 DefaultKeyPrefix；ttl为记录的过期时间，0表示不过期
+func New(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisDeduplicationStore {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &RedisDeduplicationStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Get 查询摘要对应的上传路径，记录不存在或查询失败时返回false
+func (s *RedisDeduplicationStore) Get(hash string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	url, err := s.client.Get(ctx, s.prefix+hash).Result()
+	if err != nil {
+		return "", false
+	}
+	return url, true
+}
+
+// Set 记录摘要对应的上传路径，写入失败时忽略
+func (s *RedisDeduplicationStore) Set(hash string, url string) {
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	s.client.Set(ctx, s.prefix+hash, url, s.ttl)
+}
@@ -0,0 +1,59 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 09:21:03
+ * Description: Redis去重索引测试
+ */
+package redisstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	uploader "github.com/zjguoxin/gosuploader"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/dedup/redisstore"
+)
+
+// 测试记录的读写、前缀与过期时间
+func TestRedisDeduplicationStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	var store config.DeduplicationStore = redisstore.New(client, "", time.Hour)
+	_, ok := store.Get("abc")
+	assert.False(t, ok)
+
+	store.Set("abc", "2026/10/18/a.png")
+	url, ok := store.Get("abc")
+	assert.True(t, ok)
+	assert.Equal(t, "2026/10/18/a.png", url)
+	assert.Equal(t, time.Hour, mr.TTL(redisstore.DefaultKeyPrefix+"abc"))
+
+	// 前缀隔离不同的索引
+	other := redisstore.New(client, "other:", 0)
+	_, ok = other.Get("abc")
+	assert.False(t, ok)
+}
+
+// 测试Redis不可用时视为未命中，上传不受影响
+func TestRedisUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+	mr.Close()
+
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{
+		BasePath:           t.TempDir(),
+		DeduplicationStore: redisstore.New(client, "", 0),
+	})
+	assert.NoError(t, err)
+	first, err := up.UploadBinary("a.txt", []byte("same"))
+	assert.NoError(t, err)
+	second, err := up.UploadBinary("a.txt", []byte("same"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
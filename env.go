@@ -0,0 +1,167 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 01:32:10
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 01:32:10
+ * Description: 从环境变量创建上传器，以及包级默认上传器
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// EnvDefaultType 默认上传器类型的环境变量，取值为 local、qiniu、aliyun、tencent
+const EnvDefaultType = "GOSUPLOADER_DEFAULT_TYPE"
+
+// NewUploaderFromEnv 按环境变量创建指定类型的上传器
+// 变量名为 GOSUPLOADER_<类型>_<字段>，如 GOSUPLOADER_ALIYUN_ENDPOINT：
+//   - local: BASE_PATH、KEY_STRATEGY、SIGNING_SECRET、BASE_URL
+//   - qiniu: ACCESS_KEY、SECRET_KEY、BUCKET、DOMAIN、ZONE_ID、KEY_STRATEGY
+//   - aliyun: ENDPOINT(或REGION)、ACCESS_KEY_ID、ACCESS_KEY_SECRET、BUCKET、DOMAIN、KEY_STRATEGY
+//   - tencent: SECRET_ID、SECRET_KEY、BUCKET、REGION、DOMAIN、KEY_STRATEGY
+//
+// 只覆盖常用字段，需要其他配置时请使用 NewUploader
+func NewUploaderFromEnv(t UploadType) (Uploader, error) {
+	env := func(name string) string {
+		return os.Getenv("GOSUPLOADER_" + strings.ToUpper(string(t)) + "_" + name)
+	}
+
+	switch t {
+	case Local:
+		return NewUploader(t, config.LocalConfig{
+			BasePath:      env("BASE_PATH"),
+			KeyStrategy:   env("KEY_STRATEGY"),
+			SigningSecret: env("SIGNING_SECRET"),
+			BaseURL:       env("BASE_URL"),
+		})
+	case Qiniu:
+		return NewUploader(t, config.QiniuConfig{
+			AccessKey:   env("ACCESS_KEY"),
+			SecretKey:   env("SECRET_KEY"),
+			Bucket:      env("BUCKET"),
+			Domain:      env("DOMAIN"),
+			ZoneID:      env("ZONE_ID"),
+			KeyStrategy: env("KEY_STRATEGY"),
+		})
+	case Aliyun:
+		return NewUploader(t, config.AliyunConfig{
+			Endpoint:        env("ENDPOINT"),
+			Region:          env("REGION"),
+			AccessKeyID:     env("ACCESS_KEY_ID"),
+			AccessKeySecret: env("ACCESS_KEY_SECRET"),
+			BucketName:      env("BUCKET"),
+			Domain:          env("DOMAIN"),
+			KeyStrategy:     env("KEY_STRATEGY"),
+		})
+	case Tencent:
+		return NewUploader(t, config.TencentConfig{
+			SecretID:    env("SECRET_ID"),
+			SecretKey:   env("SECRET_KEY"),
+			BucketName:  env("BUCKET"),
+			Region:      env("REGION"),
+			Domain:      env("DOMAIN"),
+			KeyStrategy: env("KEY_STRATEGY"),
+		})
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultUploader Uploader
+)
+
+// Init 替换包级默认上传器，传入nil时在下次使用时重新从环境变量创建
+func Init(u Uploader) {
+	defaultMu.Lock()
+	defaultUploader = u
+	defaultMu.Unlock()
+}
+
+// Default 返回包级默认上传器
+// 未通过 Init 设置时，在首次使用时按 GOSUPLOADER_DEFAULT_TYPE 调用 NewUploaderFromEnv 创建；
+// 创建失败时返回错误，下次调用会重新尝试
+func Default() (Uploader, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultUploader != nil {
+		return defaultUploader, nil
+	}
+	t := os.Getenv(EnvDefaultType)
+	if t == "" {
+		return nil, fmt.Errorf("%w: %s is not set", ErrInvalidConfig, EnvDefaultType)
+	}
+	u, err := NewUploaderFromEnv(UploadType(strings.ToLower(t)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create default uploader: %w", err)
+	}
+	defaultUploader = u
+	return u, nil
+}
+
+// defaultFor 返回默认上传器，并将ctx的截止时间转换为上传超时选项
+func defaultFor(ctx context.Context, opts []config.UploadOption) (Uploader, []config.UploadOption, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	u, err := Default()
+	if err != nil {
+		return nil, nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil, context.DeadlineExceeded
+		}
+		opts = append(opts, config.WithTimeout(remaining))
+	}
+	return u, opts, nil
+}
+
+// UploadFile 使用默认上传器上传multipart文件，ctx的截止时间作为上传超时(见 config.WithTimeout)
+func UploadFile(ctx context.Context, file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
+	u, opts, err := defaultFor(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return u.UploadFile(file, opts...)
+}
+
+// UploadBinary 使用默认上传器上传二进制数据，ctx的截止时间作为上传超时
+func UploadBinary(ctx context.Context, filename string, content []byte, opts ...config.UploadOption) (string, error) {
+	u, opts, err := defaultFor(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return u.UploadBinary(filename, content, opts...)
+}
+
+// UploadBase64 使用默认上传器上传Base64编码的数据，ctx的截止时间作为上传超时
+func UploadBase64(ctx context.Context, filename string, base64Str string, opts ...config.UploadOption) (string, error) {
+	u, opts, err := defaultFor(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	return u.UploadBase64(filename, base64Str, opts...)
+}
+
+// Delete 使用默认上传器删除文件，ctx已结束时不发起删除
+func Delete(ctx context.Context, key string) error {
+	u, _, err := defaultFor(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return u.Delete(key)
+}
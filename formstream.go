@@ -0,0 +1,57 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 08:21:05
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:21:05
+ * Description: 直接从multipart请求体流式上传表单文件，不经过临时文件
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// UploadFromMultipartReader 从表单中读取名为field的文件并直接交给 StreamUploader.UploadStream，
+// 适用于只接收单个大文件的接口：r.ParseMultipartForm 会先将大文件写入临时文件，UploadFile 再读取一遍，
+// 这里边读请求体边上传，不产生临时文件。mr 通常来自 http.Request.MultipartReader
+//
+// field之前的表单字段被跳过，找到文件后立即上传，之后的字段不再读取。
+// key按文件名生成，文件名经 SanitizeFilename 清理，opts中的 WithFilename、WithKey 优先；
+// 表单中没有该文件字段时返回 http.ErrMissingFile，u未实现 StreamUploader 时返回 ErrNotSupported
+func UploadFromMultipartReader(ctx context.Context, u Uploader, mr *multipart.Reader, field string, opts ...config.UploadOption) (UploadResult, error) {
+	s, ok := u.(StreamUploader)
+	if !ok {
+		return UploadResult{}, fmt.Errorf("uploader cannot stream uploads: %w", ErrNotSupported)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return UploadResult{}, fmt.Errorf("form field %q: %w", field, http.ErrMissingFile)
+		}
+		if err != nil {
+			return UploadResult{}, fmt.Errorf("failed to read multipart form: %w", err)
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		filename := config.SanitizeFilename(part.FileName())
+		if filename == "" {
+			part.Close()
+			return UploadResult{}, fmt.Errorf("%w: %q", ErrInvalidFilename, part.FileName())
+		}
+		result, err := s.UploadStream(ctx, "", part, append([]config.UploadOption{config.WithFilename(filename)}, opts...)...)
+		part.Close()
+		return result, err
+	}
+}
@@ -0,0 +1,86 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 16:20:05
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 16:20:05
+ * Description: 存储后端健康检查的net/http处理器
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	uploader "github.com/zjguoxin/gosuploader"
+)
+
+// pingTimeout 单个后端Ping的超时时间
+const pingTimeout = 5 * time.Second
+
+// 健康状态
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Response 健康检查响应体
+type Response struct {
+	Status   string            `json:"status"`   // 全部后端正常为ok，否则为degraded
+	Backends map[string]string `json:"backends"` // 后端名称 -> "ok" 或 "error: ..."
+}
+
+// HealthHandler 返回检查各上传器健康状态的处理器
+// 并发调用每个上传器的 Ping，单个调用超时5秒；全部正常返回200，否则返回503
+// 未实现 uploader.Pinger 的上传器视为异常
+// 用法: mux.Handle("/health/storage", health.HealthHandler(uploaders))
+func HealthHandler(uploaders map[string]uploader.Uploader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := Check(r.Context(), uploaders)
+
+		code := http.StatusOK
+		if resp.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// Check 并发检查各上传器的健康状态
+func Check(ctx context.Context, uploaders map[string]uploader.Uploader) Response {
+	resp := Response{Status: StatusOK, Backends: make(map[string]string, len(uploaders))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, u := range uploaders {
+		wg.Add(1)
+		go func(name string, u uploader.Uploader) {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+			defer cancel()
+			err := uploader.Ping(pingCtx, u)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Status = StatusDegraded
+				resp.Backends[name] = "error: " + err.Error()
+				return
+			}
+			resp.Backends[name] = StatusOK
+		}(name, u)
+	}
+	wg.Wait()
+
+	return resp
+}
@@ -0,0 +1,54 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 16:31:44
+ * Description: 健康检查处理器测试
+ */
+package health_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	uploader "github.com/zjguoxin/gosuploader"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/health"
+)
+
+func TestHealthHandler(t *testing.T) {
+	healthy, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+
+	// 基础路径是一个普通文件，Ping失败
+	blocked := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(blocked, nil, 0644))
+	broken, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: blocked})
+	assert.NoError(t, err)
+
+	serve := func(uploaders map[string]uploader.Uploader) (*httptest.ResponseRecorder, health.Response) {
+		rec := httptest.NewRecorder()
+		health.HealthHandler(uploaders).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/storage", nil))
+
+		var resp health.Response
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return rec, resp
+	}
+
+	// 全部正常
+	rec, resp := serve(map[string]uploader.Uploader{"local": healthy})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, health.Response{Status: "ok", Backends: map[string]string{"local": "ok"}}, resp)
+
+	// 部分异常
+	rec, resp = serve(map[string]uploader.Uploader{"local": healthy, "broken": broken})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "ok", resp.Backends["local"])
+	assert.True(t, strings.HasPrefix(resp.Backends["broken"], "error: "), resp.Backends["broken"])
+}
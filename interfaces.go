@@ -0,0 +1,280 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 10:05:31
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 10:05:31
+ * Description: 可选能力接口，各存储后端按需实现，调用方通过类型断言使用
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/multipart"
+)
+
+// UsageReporter 可查询存储用量的上传器
+type UsageReporter interface {
+	// BucketUsage 返回已用容量(字节)与对象数量
+	BucketUsage() (used int64, objectCount int64, err error)
+}
+
+// BucketUsage 查询上传器的存储用量，可用于大文件上传前提示"存储已满"
+// 上传器未实现 UsageReporter 时返回 ErrNotSupported
+func BucketUsage(u Uploader) (used int64, objectCount int64, err error) {
+	r, ok := u.(UsageReporter)
+	if !ok {
+		return 0, 0, ErrNotSupported
+	}
+	return r.BucketUsage()
+}
+
+// Mover 支持移动对象的上传器
+type Mover interface {
+	// Move 将对象从srcKey移动到dstKey
+	Move(srcKey, dstKey string) error
+}
+
+// MovePrefix 将oldPrefix下的所有对象移动到newPrefix下，保留前缀之后的部分，前缀不能为空且不能相同
+// 单个对象失败不会中断，返回成功移动的数量及汇总的错误；上传器未实现 Lister 与 Mover 时返回 ErrNotSupported
+func MovePrefix(u Uploader, oldPrefix, newPrefix string) (moved int, err error) {
+	if oldPrefix == "" || newPrefix == "" || oldPrefix == newPrefix {
+		return 0, ErrInvalidPrefix
+	}
+	lister, ok := u.(Lister)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	mover, ok := u.(Mover)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+
+	keys, err := lister.List(oldPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := mover.Move(key, newPrefix+strings.TrimPrefix(key, oldPrefix)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		moved++
+	}
+	return moved, errors.Join(errs...)
+}
+
+// ObjectInspector 可查询对象信息的上传器
+type ObjectInspector interface {
+	// GetObjectInfo 获取对象信息，对象不存在时返回 ErrNotFound
+	GetObjectInfo(key string) (config.ObjectInfo, error)
+	// Exists 判断对象是否存在
+	Exists(key string) (bool, error)
+}
+
+// Sizer 可单独查询对象大小的上传器，比 GetObjectInfo 更轻量，适合配额与进度计算
+type Sizer interface {
+	// Size 返回对象的字节数，对象不存在时返回 ErrNotFound
+	Size(key string) (int64, error)
+}
+
+// Size 查询对象大小，上传器未实现 Sizer 时使用 ObjectInspector，两者都未实现时返回 ErrNotSupported
+func Size(u Uploader, key string) (int64, error) {
+	if s, ok := u.(Sizer); ok {
+		return s.Size(key)
+	}
+	if i, ok := u.(ObjectInspector); ok {
+		info, err := i.GetObjectInfo(key)
+		return info.Size, err
+	}
+	return 0, ErrNotSupported
+}
+
+// BatchExister 可一次判断多个对象是否存在的上传器，本地存储实现了该接口
+type BatchExister interface {
+	// ExistsMany 返回每个key是否存在，检查失败的key不出现在结果中，错误汇总返回
+	ExistsMany(keys []string) (map[string]bool, error)
+}
+
+// existsManyWorkers 并发检查对象是否存在的协程数
+const existsManyWorkers = 8
+
+// ExistsMany 批量判断对象是否存在，上传器实现了 BatchExister 时直接使用，
+// 否则以有限并发调用 ObjectInspector.Exists，避免逐个串行请求；两者都未实现时返回 ErrNotSupported
+// 部分key检查失败时仍返回其余key的结果，失败的key不出现在结果中，错误汇总返回
+func ExistsMany(u Uploader, keys []string) (map[string]bool, error) {
+	if b, ok := u.(BatchExister); ok {
+		return b.ExistsMany(keys)
+	}
+	inspector, ok := u.(ObjectInspector)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	var (
+		mu     sync.Mutex
+		result = make(map[string]bool, len(keys))
+		errs   []error
+	)
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < existsManyWorkers && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				exists, err := inspector.Exists(key)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				} else {
+					result[key] = exists
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, key := range keys {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result, errors.Join(errs...)
+}
+
+// Lister 可按前缀列举对象的上传器
+type Lister interface {
+	// List 列出前缀下的所有对象key
+	List(prefix string) ([]string, error)
+}
+
+// Pinger 支持健康检查的上传器
+type Pinger interface {
+	// Ping 检查存储后端是否可用
+	Ping(ctx context.Context) error
+}
+
+// Ping 检查上传器的存储后端是否可用
+// 上传器未实现 Pinger 时返回 ErrNotSupported
+func Ping(ctx context.Context, u Uploader) error {
+	p, ok := u.(Pinger)
+	if !ok {
+		return ErrNotSupported
+	}
+	return p.Ping(ctx)
+}
+
+// ConditionalDeleter 删除不存在的对象同样成功的上传器(阿里云OSS、腾讯云COS)，无需先判断是否存在
+type ConditionalDeleter interface {
+	// DeleteIfExists 删除对象，服务端无法区分对象是否存在，成功时总是返回true
+	DeleteIfExists(ctx context.Context, key string) (bool, error)
+}
+
+// DeleteIfExists 删除存在的对象，用于重复提交的删除请求区分"已经删除"与真正的错误：
+// 对象不存在时返回(false, nil)，删除成功返回(true, nil)，其他失败返回(false, err)
+// 上传器实现了 ConditionalDeleter 时直接调用，跳过存在性检查；否则先通过 ObjectInspector 判断是否存在，
+// 检查之后被并发删除(Delete返回 ErrNotFound)同样视为不存在
+func DeleteIfExists(ctx context.Context, u Uploader, key string) (bool, error) {
+	if d, ok := u.(ConditionalDeleter); ok {
+		return d.DeleteIfExists(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if i, ok := u.(ObjectInspector); ok {
+		exists, err := i.Exists(key)
+		if err != nil || !exists {
+			return false, err
+		}
+	}
+	if err := u.Delete(key); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// HeadReader 可读取对象开头部分内容的上传器
+type HeadReader interface {
+	// Head 读取对象的前n个字节，对象小于n时返回全部内容，对象不存在时返回 ErrNotFound
+	// 云存储使用Range请求，适合生成预览或检查文件头
+	Head(key string, n int64) ([]byte, error)
+}
+
+// RangeReader 可按范围流式读取对象的上传器
+type RangeReader interface {
+	// DownloadRange 从offset开始读取length字节，length小于0时读取到对象末尾，为0时返回空内容，对象不存在时返回 ErrNotFound
+	// offset超出对象末尾时返回空内容；调用方负责关闭返回的ReadCloser
+	DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ConditionalDownloader 可按ETag条件下载对象的上传器，用于轮询读取时跳过未变化的内容
+type ConditionalDownloader interface {
+	// DownloadIfNoneMatch 下载完整对象并返回其当前ETag；etag与对象当前ETag相同时返回 ErrNotModified 且不返回内容，
+	// etag为空时无条件下载，对象不存在时返回 ErrNotFound；调用方负责关闭返回的ReadCloser
+	DownloadIfNoneMatch(ctx context.Context, key, etag string) (io.ReadCloser, string, error)
+}
+
+// RangeIntoReader 可将对象的一段直接读入调用方缓冲区的上传器，本地存储实现了该接口
+type RangeIntoReader interface {
+	// ReadRangeInto 从off开始读取至多len(buf)字节到buf，语义同 io.ReaderAt：
+	// 读满buf时返回nil，到达对象末尾而未读满时同时返回已读字节数与 io.EOF，对象不存在时返回 ErrNotFound
+	ReadRangeInto(key string, off int64, buf []byte) (int, error)
+}
+
+// ReadRangeInto 将对象从off开始的至多len(buf)字节读入buf，供频繁读取小范围的调用方复用缓冲区
+// 上传器未实现 RangeIntoReader 时以 DownloadRange 发起Range请求后复制到buf，两者都未实现时返回 ErrNotSupported
+func ReadRangeInto(u Uploader, key string, off int64, buf []byte) (int, error) {
+	if r, ok := u.(RangeIntoReader); ok {
+		return r.ReadRangeInto(key, off, buf)
+	}
+	reader, ok := u.(RangeReader)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	body, err := reader.DownloadRange(context.Background(), key, off, int64(len(buf)))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, buf)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// MultipartInspector 可列举未完成分片上传的上传器，用于排查残留分片，阿里云OSS与腾讯云COS实现了该接口
+type MultipartInspector interface {
+	// ListIncompleteMultipartUploads 列举前缀下未完成的分片上传及其已上传分片的统计
+	ListIncompleteMultipartUploads(ctx context.Context, prefix string) ([]multipart.IncompleteUpload, error)
+}
+
+// LimitsReporter 可查询存储服务商上传限制的上传器
+type LimitsReporter interface {
+	ProviderLimits() config.ProviderLimits
+}
+
+// TagReader 可读取对象标签的上传器
+type TagReader interface {
+	// GetTags 返回对象的全部标签，包括带 config.ProvenanceTagPrefix 前缀的来源标签
+	// 对象不存在时返回 ErrNotFound，没有标签时返回空map
+	GetTags(key string) (map[string]string, error)
+}
@@ -0,0 +1,94 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 10:36:20
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 06:52:08
+ * Description: 上传审计，在上传流读取过程中同步计算摘要、扫描内容并限制大小
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package audit
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// pipeline 上传流的单次读取管道
+// 通过一个 io.TeeReader 将读到的内容同时写给摘要、扫描器与计数，所有消费者观察同一次读取，内容不会被重复读取或缓存
+type pipeline struct {
+	r       io.Reader
+	md5     hash.Hash
+	h       hash.Hash
+	n       counter
+	size    int64
+	max     int64
+	scanner config.ContentScanner
+	checked bool
+}
+
+// counter 统计写入的字节数
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
+
+func (p *pipeline) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	// 出错时丢弃本次读到的内容，保证服务端收不到完整的内容，不会生成对象
+	if p.max > 0 && int64(p.n) > p.max {
+		return 0, fmt.Errorf("%w: exceeds limit of %d bytes", config.ErrFileTooLarge, p.max)
+	}
+	// 内容读完(或达到声明的大小，此时传输层可能不再读取EOF)后取得扫描结论，拒绝时以错误结束读取
+	done := err == io.EOF || p.size >= 0 && int64(p.n) >= p.size
+	if done && p.scanner != nil && !p.checked {
+		p.checked = true
+		if verdict := p.scanner.Verdict(); verdict != nil {
+			return 0, fmt.Errorf("%w: %w", config.ErrContentRejected, verdict)
+		}
+	}
+	return n, err
+}
+
+// Wrap 按上传选项包装r，使审计摘要、内容扫描(Scanner)与大小限制(MaxSize)在上传的同一次读取中完成
+// size为内容的声明大小，未知时传-1；返回的done应在存储成功后以最终的key调用，未配置审计回调时为空操作；三者都未配置时原样返回r
+func Wrap(r io.Reader, size int64, o *config.UploadOptions) (io.Reader, func(key string)) {
+	if o == nil || o.AuditSink == nil && o.Scanner == nil && o.MaxSize <= 0 {
+		return r, func(string) {}
+	}
+
+	start := time.Now()
+	p := &pipeline{size: size, max: o.MaxSize, scanner: o.Scanner}
+	writers := []io.Writer{&p.n}
+	if o.AuditSink != nil {
+		p.md5, p.h = md5.New(), sha256.New()
+		writers = append(writers, p.md5, p.h)
+	}
+	if o.Scanner != nil {
+		writers = append(writers, o.Scanner)
+	}
+	p.r = io.TeeReader(r, io.MultiWriter(writers...))
+	if o.AuditSink == nil {
+		return p, func(string) {}
+	}
+	return p, func(key string) {
+		o.AuditSink(config.AuditRecord{
+			Time:      time.Now(),
+			Key:       key,
+			Size:      int64(p.n),
+			Duration:  time.Since(start),
+			MD5:       hex.EncodeToString(p.md5.Sum(nil)),
+			SHA256:    hex.EncodeToString(p.h.Sum(nil)),
+			Actor:     o.Actor,
+			RequestID: o.RequestID,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 19:48:02
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 19:48:02
+ * Description: 限制大小并兼容缺少填充与URL安全字符集的Base64解码
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package b64
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// Decode 解码Base64字符串，解码后超过max字节时返回 config.ErrFileTooLarge，max<=0表示不限制
+// 兼容缺少=填充的输入：先补齐填充，再依次尝试 StdEncoding、URLEncoding、RawURLEncoding
+// 解码前先按长度估算解码大小并提前拒绝，解码时再以实际长度校验
+func Decode(s string, max int64) ([]byte, error) {
+	if max > 0 {
+		if size := EstimateDecodedLen(s); size > max {
+			return nil, fmt.Errorf("%w: decoded size about %d bytes exceeds limit %d", config.ErrFileTooLarge, size, max)
+		}
+	}
+
+	raw := strings.TrimRight(strings.NewReplacer("\r", "", "\n", "").Replace(s), "=")
+	padded := raw + strings.Repeat("=", (4-len(raw)%4)%4)
+
+	data, err := decode(base64.StdEncoding, padded, max)
+	if err == nil || errors.Is(err, config.ErrFileTooLarge) {
+		return data, err
+	}
+	// URL安全字符集的输入，按标准字符集的错误返回
+	if data, urlErr := decode(base64.URLEncoding, padded, max); urlErr == nil || errors.Is(urlErr, config.ErrFileTooLarge) {
+		return data, urlErr
+	}
+	if data, rawErr := decode(base64.RawURLEncoding, raw, max); rawErr == nil || errors.Is(rawErr, config.ErrFileTooLarge) {
+		return data, rawErr
+	}
+	return nil, err
+}
+
+// decode 以指定编码解码，max>0时流式解码并限制解码后的大小
+func decode(enc *base64.Encoding, s string, max int64) ([]byte, error) {
+	if max <= 0 {
+		return enc.DecodeString(s)
+	}
+
+	// 最多多读1字节用于判断是否超限
+	data, err := io.ReadAll(io.LimitReader(base64.NewDecoder(enc, strings.NewReader(s)), max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: decoded size exceeds limit %d", config.ErrFileTooLarge, max)
+	}
+	return data, nil
+}
+
+// EstimateDecodedLen 估算Base64字符串解码后的字节数，忽略换行与填充字符
+func EstimateDecodedLen(s string) int64 {
+	var n int64
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\r', '\n', '=':
+		default:
+			n++
+		}
+	}
+	return n * 3 / 4
+}
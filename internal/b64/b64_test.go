@@ -0,0 +1,81 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 19:55:37
+ * Description: 限制大小的Base64解码测试
+ */
+package b64
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+func TestDecode(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 10, 1000} {
+		data := []byte(strings.Repeat("x", size))
+		encoded := base64.StdEncoding.EncodeToString(data)
+
+		// 估算值与实际长度一致
+		assert.Equal(t, int64(size), EstimateDecodedLen(encoded), "size %d", size)
+
+		got, err := Decode(encoded, int64(size))
+		assert.NoError(t, err)
+		assert.Equal(t, data, got)
+
+		if size > 1 { // max为0表示不限制
+			_, err = Decode(encoded, int64(size-1))
+			assert.ErrorIs(t, err, config.ErrFileTooLarge)
+		}
+	}
+}
+
+// 测试带换行的Base64不会被误判超限
+func TestDecodeWithLineBreaks(t *testing.T) {
+	data := []byte(strings.Repeat("y", 200))
+	encoded := base64.StdEncoding.EncodeToString(data)
+	wrapped := encoded[:76] + "\r\n" + encoded[76:152] + "\r\n" + encoded[152:]
+
+	got, err := Decode(wrapped, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+// 测试不限制大小与非法输入
+func TestDecodeUnlimitedAndInvalid(t *testing.T) {
+	got, err := Decode("dGVzdA==", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test"), got)
+
+	_, err = Decode("!!!", 10)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, config.ErrFileTooLarge)
+}
+
+// 测试有无填充与URL安全字符集的组合
+func TestDecodeEncodings(t *testing.T) {
+	// 0xfb 0xff 0xfe 编码后包含 + / 或 - _ 字符
+	for _, data := range [][]byte{[]byte("a"), []byte("ab"), []byte("abc"), {0xfb, 0xff, 0xfe, 0x01}} {
+		encodings := map[string]*base64.Encoding{
+			"std":    base64.StdEncoding,
+			"rawstd": base64.RawStdEncoding,
+			"url":    base64.URLEncoding,
+			"rawurl": base64.RawURLEncoding,
+		}
+		for name, enc := range encodings {
+			encoded := enc.EncodeToString(data)
+			for _, max := range []int64{0, 100} {
+				got, err := Decode(encoded, max)
+				assert.NoError(t, err, "%s %q max=%d", name, encoded, max)
+				assert.Equal(t, data, got, "%s %q max=%d", name, encoded, max)
+			}
+		}
+	}
+
+	// 补齐填充后仍超限
+	_, err := Decode("dGVzdCBkYXRh", 4)
+	assert.ErrorIs(t, err, config.ErrFileTooLarge)
+}
@@ -0,0 +1,81 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 05:14:33
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 05:14:33
+ * Description: 读取 fs.File 的上传信息，各存储后端共用
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package fsfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/mime"
+)
+
+// File 准备上传的 fs.File
+type File struct {
+	Reader      io.Reader // 从头读取的内容
+	Name        string    // 文件名(不含目录)，用于生成key，Stat不可用时为空
+	Size        int64     // 内容大小
+	ContentType string    // 按内容嗅探与文件名确定的内容类型
+}
+
+// Open 读取f的大小、文件名与内容类型
+// f不支持Stat或不是普通文件时将内容读入内存以确定大小；内容类型由resolver确定并始终嗅探内容，
+// o.ContentType 非空时直接使用
+func Open(f fs.File, resolver mime.Resolver, o *config.UploadOptions) (File, error) {
+	if f == nil {
+		return File{}, fmt.Errorf("%w: file is nil", config.ErrInvalidFilename)
+	}
+
+	file := File{Reader: f, Size: -1}
+	if info, err := f.Stat(); err == nil {
+		if info.IsDir() {
+			return File{}, fmt.Errorf("%w: %s", config.ErrIsDirectory, info.Name())
+		}
+		file.Name = info.Name()
+		if info.Mode().IsRegular() {
+			file.Size = info.Size()
+		}
+	}
+	if file.Size < 0 {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return File{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		file.Reader, file.Size = bytes.NewReader(data), int64(len(data))
+	}
+
+	head, r, err := mime.Peek(file.Reader)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read file header: %w", err)
+	}
+	// 可定位的文件回到开头，保留Seek能力供上传失败后重试
+	if s, ok := file.Reader.(io.ReadSeeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err == nil {
+			r = s
+		}
+	}
+	file.Reader = r
+
+	resolver.DetectMIME = true
+	file.ContentType = resolver.ResolveContentType(o.FilenameOr(file.Name), head, o.ContentType)
+	return file, nil
+}
+
+// Options 合并上传选项，key非空时作为存储key，上传随ctx取消或超时中止
+func Options(ctx context.Context, key string, opts []config.UploadOption) (*config.UploadOptions, error) {
+	all := append([]config.UploadOption{}, opts...)
+	all = append(all, config.WithContext(ctx))
+	if key != "" {
+		all = append(all, config.WithKey(key))
+	}
+	return config.NewUploadOptions(all...)
+}
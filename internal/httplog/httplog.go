@@ -0,0 +1,74 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 06:58:42
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 06:58:42
+ * Description: 记录发往存储服务的HTTP请求，用于 DebugHTTP
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package httplog
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sensitiveParams 查询参数名中包含这些片段(不区分大小写)时隐去参数值，如 q-signature、security-token、OSSAccessKeyId
+var sensitiveParams = []string{"sign", "token", "key", "credential"}
+
+// transport 记录每个请求的方法、URL、响应状态码与耗时，不读取请求与响应内容
+type transport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+}
+
+// Wrap 包装base，以Debug级别将每个请求记录到logger，logger为nil时使用 slog.Default()
+func Wrap(base http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &transport{base: base, logger: logger}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", Redact(req.URL)),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	t.logger.LogAttrs(req.Context(), slog.LevelDebug, "storage http request", attrs...)
+	return resp, err
+}
+
+// Redact 返回隐去签名、令牌等查询参数值以及用户密码的URL
+func Redact(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	redacted := *u
+	query := u.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		for _, s := range sensitiveParams {
+			if strings.Contains(lower, s) {
+				query.Set(name, "REDACTED")
+				break
+			}
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.Redacted()
+}
@@ -0,0 +1,207 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 18:05:26
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 23:24:10
+ * Description: 存储key生成，各存储后端共用
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package keygen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// StrategyTimestampRandom 七牛云沿用的文件名格式: <UnixNano>_<8位随机串>.ext，不保留原文件名
+const StrategyTimestampRandom = "timestamp-random"
+
+// KeygenOptions key生成选项
+type KeygenOptions struct {
+	DateFormat string // 日期目录的Go时间格式，如 2006/01/02，为空时不生成日期目录
+	Prefix     string // key前缀目录
+	Strategy   string // 唯一文件名策略，见 config.KeyStrategyTimestamp 等，为空时使用时间戳策略
+	Timezone   string // 日期目录使用的IANA时区，如 Asia/Shanghai，为空或无效时使用本地时区
+	Separator  string // 替换前缀、日期与文件名之间的/，生成扁平key，为空时使用/
+
+	Sequence *config.Sequence // sequential策略的计数器，为nil时使用 config.DefaultSequence
+}
+
+// Generate 生成 /分隔的存储key: [Prefix/][日期目录/]唯一文件名
+// 不支持需要分配编号的sequential策略，该策略请使用 GenerateKey
+func Generate(originalName string, opts KeygenOptions) string {
+	dir := dirOf(opts)
+	return Flatten(path.Join(dir, uniqueName(originalName, opts.Strategy)), opts.Separator)
+}
+
+// GenerateKey 与 Generate 相同，另外支持sequential策略：文件名为目录内的补零顺序编号加扩展名，
+// 编号按目录([Prefix/][日期目录])分别计数，计数器持久化失败时返回错误
+// content-addressed策略需要读取内容，返回错误，请使用 ContentKey
+func GenerateKey(originalName string, opts KeygenOptions) (string, error) {
+	if opts.Strategy == config.KeyStrategyContentAddressed {
+		return "", errors.New("content-addressed key strategy requires the content, use ContentKey")
+	}
+	if opts.Strategy != config.KeyStrategySequential {
+		return Generate(originalName, opts), nil
+	}
+	seq := opts.Sequence
+	if seq == nil {
+		seq = config.DefaultSequence
+	}
+	dir := dirOf(opts)
+	n, err := seq.Next(dir)
+	if err != nil {
+		return "", err
+	}
+	_, ext := splitName(originalName)
+	return Flatten(path.Join(dir, seq.Format(n)+ext), opts.Separator), nil
+}
+
+// ContentKey 读取r计算SHA-256，生成内容寻址key: sha256/<摘要第1~2位>/<第3~4位>/<完整摘要>[.ext]
+// 返回可从头读取同一内容的Reader：r实现 io.Seeker 时读取后回到开头，否则将内容读入内存
+func ContentKey(originalName string, r io.Reader) (string, io.Reader, error) {
+	h := sha256.New()
+	if s, ok := r.(io.ReadSeeker); ok {
+		if _, err := io.Copy(h, s); err != nil {
+			return "", nil, fmt.Errorf("failed to hash content: %w", err)
+		}
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return "", nil, fmt.Errorf("failed to rewind content: %w", err)
+		}
+	} else {
+		data, err := io.ReadAll(io.TeeReader(r, h))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to hash content: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	return ContentKeyOf(originalName, h.Sum(nil)), r, nil
+}
+
+// ContentKeyOf 由已计算的SHA-256摘要生成内容寻址key，适用于边写入边计算摘要的场景
+func ContentKeyOf(originalName string, sha256Sum []byte) string {
+	sum := hex.EncodeToString(sha256Sum)
+	// 扩展名统一为小写，避免相同内容因扩展名大小写不同得到不同的key
+	_, ext := splitName(originalName)
+	return path.Join("sha256", sum[:2], sum[2:4], sum+strings.ToLower(ext))
+}
+
+// dirOf 生成key的目录部分: [Prefix/][日期目录]
+func dirOf(opts KeygenOptions) string {
+	var parts []string
+	if prefix := strings.Trim(opts.Prefix, "/"); prefix != "" {
+		parts = append(parts, prefix)
+	}
+	if opts.DateFormat != "" {
+		parts = append(parts, now(opts.Timezone).Format(opts.DateFormat))
+	}
+	return path.Join(parts...)
+}
+
+// Flatten 将key中的/替换为sep，sep为空时原样返回
+func Flatten(key, sep string) string {
+	if sep == "" {
+		return key
+	}
+	return strings.ReplaceAll(key, "/", sep)
+}
+
+// ValidSeparator 判断key分隔符是否有效：为空，或不含/与\(规范化时会被视为目录分隔符)
+func ValidSeparator(sep string) bool {
+	return !strings.ContainsAny(sep, "/\\")
+}
+
+// ValidStrategy 判断key生成策略是否有效，空字符串表示默认的时间戳策略
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case "", config.KeyStrategyTimestamp, config.KeyStrategyUUID, config.KeyStrategySequential, config.KeyStrategyContentAddressed:
+		return true
+	}
+	return false
+}
+
+// uniqueName 按策略生成唯一文件名
+func uniqueName(originalName, strategy string) string {
+	baseName, ext := splitName(originalName)
+
+	switch strategy {
+	case config.KeyStrategyUUID:
+		return fmt.Sprintf("%s_%s%s", baseName, uuid.New().String(), ext)
+	case StrategyTimestampRandom:
+		return fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), uuid.New().String()[:8], ext)
+	default:
+		return fmt.Sprintf("%s_%d%s", baseName, time.Now().UnixNano(), ext)
+	}
+}
+
+// 文件名的长度限制，超出部分截断，避免生成的key超过服务商限制
+const (
+	maxBaseLen = 64
+	maxExtLen  = 16
+)
+
+// defaultBaseName 原文件名没有可用部分(如空字符串、只有点)时使用的文件名
+const defaultBaseName = "file"
+
+// splitName 将原文件名拆分为文件名与扩展名，保证文件名非空
+//   - 去除目录部分，/ 与 \ 都视为分隔符
+//   - 只有前导点的文件名(如 .gitignore)整体视为文件名，没有扩展名
+//   - 扩展名只能由字母、数字组成且不超过 maxExtLen，否则丢弃扩展名；末尾的点不构成扩展名
+//   - 文件名去除首尾的点与空白，为空时使用 defaultBaseName，超过 maxBaseLen 个字符时截断
+func splitName(originalName string) (baseName, ext string) {
+	name := path.Base(strings.ReplaceAll(originalName, `\`, "/"))
+	if name == "/" {
+		name = ""
+	}
+
+	ext = path.Ext(name)
+	baseName = strings.TrimSuffix(name, ext)
+	if strings.Trim(baseName, ".") == "" || !validExt(ext) {
+		baseName, ext = name, ""
+	}
+
+	baseName = strings.Trim(baseName, ". \t")
+	if baseName == "" {
+		baseName = defaultBaseName
+	}
+	if r := []rune(baseName); len(r) > maxBaseLen {
+		baseName = string(r[:maxBaseLen])
+	}
+	return baseName, ext
+}
+
+// validExt 判断扩展名(含点)是否可用
+func validExt(ext string) bool {
+	if len(ext) < 2 || len(ext) > maxExtLen+1 {
+		return false
+	}
+	for _, c := range ext[1:] {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// now 返回指定时区的当前时间
+func now(timezone string) time.Time {
+	t := time.Now()
+	if timezone == "" {
+		return t
+	}
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return t.In(loc)
+	}
+	return t
+}
@@ -0,0 +1,253 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 18:20:14
+ * Description: 存储key生成测试
+ */
+package keygen
+
+import (
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+func TestGenerate(t *testing.T) {
+	date := time.Now().Format("2006/01/02")
+
+	assert.Regexp(t, regexp.MustCompile(`^photo_\d+\.png$`), Generate("dir/photo.png", KeygenOptions{}))
+	assert.Regexp(t, regexp.MustCompile(`^`+date+`/photo_\d+\.png$`),
+		Generate("photo.png", KeygenOptions{DateFormat: "2006/01/02", Strategy: config.KeyStrategyTimestamp}))
+	assert.Regexp(t,
+		regexp.MustCompile(`^avatars/photo_[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.png$`),
+		Generate("photo.png", KeygenOptions{Prefix: "/avatars/", Strategy: config.KeyStrategyUUID}))
+	assert.Regexp(t, regexp.MustCompile(`^\d+_[0-9a-f]{8}\.png$`),
+		Generate("photo.png", KeygenOptions{Strategy: StrategyTimestampRandom}))
+
+	opts := KeygenOptions{Strategy: config.KeyStrategyUUID}
+	assert.NotEqual(t, Generate("a.txt", opts), Generate("a.txt", opts))
+}
+
+// 测试日期目录使用指定时区
+func TestGenerateTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("时区数据不可用")
+	}
+	key := Generate("a.txt", KeygenOptions{DateFormat: "2006-01-02T15", Timezone: "Asia/Shanghai"})
+	assert.Regexp(t, regexp.MustCompile(`^`+time.Now().In(loc).Format("2006-01-02T15")+`/`), key)
+}
+
+// 测试以分隔符生成扁平key，规范化后保持不变
+func TestGenerateSeparator(t *testing.T) {
+	date := time.Now().Format("2006_01_02")
+	key := Generate("image.jpg", KeygenOptions{Prefix: "media/avatars", DateFormat: "2006/01/02", Separator: "_"})
+	assert.Regexp(t, regexp.MustCompile(`^media_avatars_`+date+`_image_\d+\.jpg$`), key)
+	normalized, err := NormalizeKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, key, normalized)
+
+	seq := config.NewSequence(4, nil)
+	key, err = GenerateKey("a.txt", KeygenOptions{DateFormat: "2006/01/02", Strategy: config.KeyStrategySequential, Sequence: seq, Separator: "-"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, time.Now().Format("2006-01-02")+"-"))
+	assert.NotContains(t, key, "/")
+
+	assert.True(t, ValidSeparator(""))
+	assert.True(t, ValidSeparator("_"))
+	assert.False(t, ValidSeparator("/"))
+	assert.False(t, ValidSeparator("\\"))
+}
+
+func TestValidStrategy(t *testing.T) {
+	assert.True(t, ValidStrategy(""))
+	assert.True(t, ValidStrategy(config.KeyStrategyTimestamp))
+	assert.True(t, ValidStrategy(config.KeyStrategyUUID))
+	assert.True(t, ValidStrategy(config.KeyStrategySequential))
+	assert.False(t, ValidStrategy("random"))
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a/b.png", "a/b.png"},
+		{"/a//b.png", "a/b.png"},
+		{`a\b.png`, "a/b.png"},
+		{"./a/./b.png", "a/b.png"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeKey(tt.key)
+		assert.NoError(t, err, tt.key)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, key := range []string{"", "/", ".", "../a.png", `a\..\..\b`} {
+		_, err := NormalizeKey(key)
+		assert.ErrorIs(t, err, config.ErrInvalidKey, key)
+	}
+}
+
+// 测试先规范化再按字符策略转换，\ 视为目录分隔符而不是被替换的字符
+func TestPolicyKey(t *testing.T) {
+	key, err := PolicyKey(`a\b c.txt`, config.KeyCharPolicyURLSafe)
+	assert.NoError(t, err)
+	assert.Equal(t, "a/b-c.txt", key)
+
+	key, err = PolicyKey("/a//b.txt", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a/b.txt", key)
+
+	_, err = PolicyKey("../a.txt", config.KeyCharPolicyURLSafe)
+	assert.ErrorIs(t, err, config.ErrInvalidKey)
+	_, err = PolicyKey("a/x/b.txt", &config.KeyCharPolicy{Replace: map[rune]string{'x': ".."}})
+	assert.ErrorIs(t, err, config.ErrInvalidKey)
+}
+
+// 测试边界文件名在各策略下都能生成有效的唯一key
+func TestGenerateEdgeCaseNames(t *testing.T) {
+	long := strings.Repeat("长", 100)
+	tests := []struct {
+		name     string
+		wantBase string
+		wantExt  string
+	}{
+		{"", "file", ""},
+		{"photo", "photo", ""},
+		{".gitignore", "gitignore", ""},
+		{"...", "file", ""},
+		{".", "file", ""},
+		{"a.", "a", ""},
+		{"archive.tar.gz", "archive.tar", ".gz"},
+		{`C:\Users\a\photo.PNG`, "photo", ".PNG"},
+		{"dir/", "dir", ""},
+		{long + ".jpg", strings.Repeat("长", maxBaseLen), ".jpg"},
+	}
+	for _, tt := range tests {
+		base, ext := splitName(tt.name)
+		assert.Equal(t, tt.wantBase, base, tt.name)
+		assert.Equal(t, tt.wantExt, ext, tt.name)
+
+		for _, strategy := range []string{config.KeyStrategyTimestamp, config.KeyStrategyUUID, StrategyTimestampRandom} {
+			opts := KeygenOptions{Strategy: strategy}
+			key := Generate(tt.name, opts)
+			_, err := NormalizeKey(key)
+			assert.NoError(t, err, "%q %s", tt.name, strategy)
+			assert.False(t, strings.Contains(key, "/"), key)
+			assert.Equal(t, tt.wantExt, path.Ext(key), "%q %s", tt.name, strategy)
+			assert.NotEqual(t, key, Generate(tt.name, opts))
+		}
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	tests := []struct{ key, prefix, want string }{
+		{"media/a.png", "media", "a.png"},
+		{"media/a.png", "/media/", "a.png"},
+		{"media/2026/a.png", "media/2026", "a.png"},
+		{"mediax/a.png", "media", "mediax/a.png"},
+		{"media", "media", "media"},
+		{"a.png", "", "a.png"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, StripPrefix(tt.key, tt.prefix), "%s - %s", tt.key, tt.prefix)
+	}
+}
+
+// mapStore 测试用的编号持久化
+type mapStore struct {
+	saved map[string]uint64
+	fail  bool
+}
+
+func (s *mapStore) Load(scope string) (uint64, error) { return s.saved[scope], nil }
+
+func (s *mapStore) Save(scope string, n uint64) error {
+	if s.fail {
+		return errors.New("store unavailable")
+	}
+	s.saved[scope] = n
+	return nil
+}
+
+func TestGenerateSequential(t *testing.T) {
+	seq := config.NewSequence(0, nil)
+	opts := KeygenOptions{Prefix: "frames", Strategy: config.KeyStrategySequential, Sequence: seq}
+
+	key, err := GenerateKey("a.jpg", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "frames/00001.jpg", key)
+	key, err = GenerateKey("b.jpg", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "frames/00002.jpg", key)
+
+	// 不同目录分别计数
+	key, err = GenerateKey("a.jpg", KeygenOptions{Strategy: config.KeyStrategySequential, Sequence: seq})
+	assert.NoError(t, err)
+	assert.Equal(t, "00001.jpg", key)
+
+	// 并发分配不重复
+	var wg sync.WaitGroup
+	keys := make([]string, 100)
+	for i := range keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys[i], _ = GenerateKey("c.png", KeygenOptions{Prefix: "burst", Strategy: config.KeyStrategySequential, Sequence: seq})
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		seen[k] = true
+	}
+	assert.Len(t, seen, 100)
+	assert.True(t, seen["burst/00100.png"])
+
+	// 从持久化记录继续编号，保存失败时不消耗编号
+	store := &mapStore{saved: map[string]uint64{"frames": 41}}
+	seq = config.NewSequence(3, store)
+	opts.Sequence = seq
+	key, err = GenerateKey("a.jpg", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "frames/042.jpg", key)
+	assert.Equal(t, uint64(42), store.saved["frames"])
+
+	store.fail = true
+	_, err = GenerateKey("a.jpg", opts)
+	assert.Error(t, err)
+	store.fail = false
+	key, err = GenerateKey("a.jpg", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "frames/043.jpg", key)
+}
+
+func TestContentKey(t *testing.T) {
+	// sha256("hello")
+	const sum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	key, r, err := ContentKey("dir/Photo.PNG", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256/2c/f2/"+sum+".png", key)
+	data, _ := io.ReadAll(r)
+	assert.Equal(t, "hello", string(data), "读取后回到开头")
+
+	// 不可定位的Reader读入内存
+	key, r, err = ContentKey("noext", io.LimitReader(strings.NewReader("hello"), 5))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256/2c/f2/"+sum, key)
+	data, _ = io.ReadAll(r)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = GenerateKey("a.png", KeygenOptions{Strategy: config.KeyStrategyContentAddressed})
+	assert.Error(t, err)
+	assert.True(t, ValidStrategy(config.KeyStrategyContentAddressed))
+}
@@ -0,0 +1,77 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 19:20:33
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 09:02:14
+ * Description: 调用方传入key的规范化
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package keygen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// NormalizeKey 规范化调用方传入的key
+// 统一使用/分隔，清理多余的/与.，去除开头的/；空key或越出根目录(..)的key返回 config.ErrInvalidKey
+func NormalizeKey(key string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(key, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || hasParentRef(key) {
+		return "", fmt.Errorf("%w: %q", config.ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
+
+// PolicyKey 先规范化key再按字符策略转换，各后端对同一key得到相同的存储key，policy为nil时只规范化
+// 转换后出现..路径段(自定义替换引入)时同样返回 config.ErrInvalidKey
+func PolicyKey(key string, policy *config.KeyCharPolicy) (string, error) {
+	key, err := NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	if applied := policy.Apply(key); !hasParentRef(applied) {
+		return applied, nil
+	}
+	return "", fmt.Errorf("%w: %q", config.ErrInvalidKey, key)
+}
+
+// hasParentRef 判断key是否包含..路径段
+func hasParentRef(key string) bool {
+	for _, seg := range strings.FieldsFunc(key, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeKeyPair 规范化复制、移动操作的源key与目标key
+func NormalizeKeyPair(srcKey, dstKey string) (string, string, error) {
+	src, err := NormalizeKey(srcKey)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := NormalizeKey(dstKey)
+	if err != nil {
+		return "", "", err
+	}
+	return src, dst, nil
+}
+
+// StripPrefix 去除key开头的目录前缀，只在完整路径段上匹配，
+// 如前缀 media 会去除 media/a.png 中的 media/，但不影响 mediax/a.png；前缀为空或不匹配时原样返回
+func StripPrefix(key, prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return key
+	}
+	if rest, ok := strings.CutPrefix(key, prefix+"/"); ok {
+		return rest
+	}
+	return key
+}
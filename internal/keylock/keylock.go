@@ -0,0 +1,51 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 15:40:12
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 15:40:12
+ * Description: 按key加锁，同一key串行、不同key并行
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package keylock
+
+import "sync"
+
+// Locker 按key加锁的互斥锁，零值可直接使用，使用后不可复制
+// 锁仅在当前进程内的同一实例中生效，不是分布式锁
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*entry
+}
+
+// entry 单个key的锁及引用计数，引用归零时从map中移除
+type entry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock 锁定key，返回解锁函数
+func (l *Locker) Lock(key string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*entry)
+	}
+	e, ok := l.locks[key]
+	if !ok {
+		e = &entry{}
+		l.locks[key] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	e.mu.Lock()
+	return func() {
+		e.mu.Unlock()
+
+		l.mu.Lock()
+		e.refs--
+		if e.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
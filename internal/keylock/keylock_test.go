@@ -0,0 +1,52 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 15:52:30
+ * Description: 按key加锁测试
+ */
+package keylock
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试同一key串行、不同key并行，且用完后释放map条目
+func TestLocker(t *testing.T) {
+	var l Locker
+
+	unlock := l.Lock("a")
+
+	// 不同key不受影响
+	done := make(chan struct{})
+	go func() {
+		l.Lock("b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("不同key的加锁被阻塞")
+	}
+
+	// 同一key需等待解锁
+	var wg sync.WaitGroup
+	acquired := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.Lock("a")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("同一key未串行")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	wg.Wait()
+	assert.Empty(t, l.locks)
+}
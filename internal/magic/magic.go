@@ -0,0 +1,94 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 17:04:51
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:49:26
+ * Description: 基于文件头魔数的类型校验
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package magic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// headSize 读取的文件头最小长度
+const headSize = 8
+
+// Detect 根据文件头匹配魔数规则，规则设置了Match时还需通过Match校验
+func Detect(head []byte, rules []config.MagicRule) (config.MagicRule, bool) {
+	for _, rule := range rules {
+		if len(rule.Signature) > 0 && bytes.HasPrefix(head, rule.Signature) && (rule.Match == nil || rule.Match(head)) {
+			return rule, true
+		}
+	}
+	return config.MagicRule{}, false
+}
+
+// Validate 读取文件头并与文件扩展名交叉校验
+// 识别出的类型不允许该扩展名，或扩展名属于某条规则但内容无法识别时返回 config.ErrFileTypeMismatch
+// 返回的Reader包含已读取的文件头，可继续完整读取内容
+func Validate(r io.Reader, filename string, rules []config.MagicRule) (io.Reader, error) {
+	n := headSize
+	for _, rule := range rules {
+		n = max(n, len(rule.Signature), rule.HeadSize)
+	}
+
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	head = head[:read]
+
+	if err := check(head, filename, rules); err != nil {
+		return nil, err
+	}
+	return io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// check 校验文件头与扩展名是否一致
+func check(head []byte, filename string, rules []config.MagicRule) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	if detected, ok := Detect(head, rules); ok {
+		if !hasExtension(detected, ext) {
+			return fmt.Errorf("%w: extension %s does not match detected type %s",
+				config.ErrFileTypeMismatch, displayExt(ext), detected.ContentType)
+		}
+		return nil
+	}
+
+	// 内容无法识别，但扩展名声称是已知类型
+	for _, rule := range rules {
+		if hasExtension(rule, ext) {
+			return fmt.Errorf("%w: extension %s does not match content, expected type %s",
+				config.ErrFileTypeMismatch, ext, rule.ContentType)
+		}
+	}
+	return nil
+}
+
+// hasExtension 判断规则是否允许该扩展名
+func hasExtension(rule config.MagicRule, ext string) bool {
+	for _, e := range rule.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// displayExt 用于错误信息的扩展名
+func displayExt(ext string) string {
+	if ext == "" {
+		return "(none)"
+	}
+	return ext
+}
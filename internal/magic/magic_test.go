@@ -0,0 +1,76 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 17:12:36
+ * Description: 魔数类型校验测试
+ */
+package magic
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		wantErr  string
+	}{
+		{"PNG", "a.png", "\x89PNG\r\n\x1a\nrest", ""},
+		{"JPEG大写扩展名", "a.JPG", "\xFF\xD8\xFFdata", ""},
+		{"PNG改名为JPG", "a.jpg", "\x89PNG\r\n\x1a\n", "extension .jpg does not match detected type image/png"},
+		{"EXE改名为PDF", "a.pdf", peHead(0x80), "extension .pdf does not match detected type application/vnd.microsoft.portable-executable"},
+		{"EXE", "a.exe", peHead(0x80), ""},
+		{"MZ开头的文本", "a.txt", "MZ is a two-letter prefix, not an executable", ""},
+		{"MZ开头但无PE签名", "a.exe", "MZ" + strings.Repeat("\x00", 0x100), "extension .exe does not match content"},
+		{"文本声称PNG", "a.png", "hello", "extension .png does not match content"},
+		{"未知类型", "a.txt", "hello", ""},
+		{"短内容", "a.txt", "M", ""},
+		{"DOCX为ZIP容器", "a.docx", "PK\x03\x04", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Validate(strings.NewReader(tt.content), tt.filename, config.MagicByteRules)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, config.ErrFileTypeMismatch)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			// 读取的文件头需要还原
+			data, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.content, string(data))
+		})
+	}
+}
+
+// peHead 构造DOS头0x3C处指向offset、offset处为PE签名的文件头
+func peHead(offset int) string {
+	head := make([]byte, offset+8)
+	copy(head, "MZ")
+	binary.LittleEndian.PutUint32(head[0x3C:], uint32(offset))
+	copy(head[offset:], "PE\x00\x00")
+	return string(head)
+}
+
+// 测试自定义规则
+func TestValidateCustomRule(t *testing.T) {
+	rules := append([]config.MagicRule{
+		{Signature: []byte("GIF8"), ContentType: "image/gif", Extensions: []string{".gif"}},
+	}, config.MagicByteRules...)
+
+	_, err := Validate(strings.NewReader("GIF89a"), "a.png", rules)
+	assert.ErrorIs(t, err, config.ErrFileTypeMismatch)
+
+	_, err = Validate(strings.NewReader("GIF89a"), "a.gif", rules)
+	assert.NoError(t, err)
+}
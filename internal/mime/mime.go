@@ -0,0 +1,125 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 13:05:12
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 13:05:12
+ * Description: 内容类型推断与匹配
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package mime
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen http.DetectContentType 最多使用的字节数
+const sniffLen = 512
+
+// Resolver 综合显式类型、扩展名覆盖、内容嗅探与扩展名推断确定内容类型
+type Resolver struct {
+	Overrides  map[string]string // 扩展名(如 .webp 或 webp，不区分大小写)到内容类型的覆盖
+	DetectMIME bool              // 是否按内容开头嗅探类型
+}
+
+// ResolveContentType 确定内容类型，依次使用：
+// 1) explicitType；2) Overrides 中扩展名对应的类型；3) 开启 DetectMIME 时由 http.DetectContentType 嗅探content；
+// 4) 按扩展名推断。嗅探结果为 application/octet-stream 时视为无法识别，继续按扩展名推断
+// 都无法确定时返回空字符串，由存储服务自行处理
+func (r Resolver) ResolveContentType(filename string, content []byte, explicitType string) string {
+	if explicitType != "" {
+		return explicitType
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for k, v := range r.Overrides {
+		if k = strings.ToLower(k); !strings.HasPrefix(k, ".") {
+			k = "." + k
+		}
+		if k == ext && ext != "" {
+			return v
+		}
+	}
+
+	if r.DetectMIME && len(content) > 0 {
+		if len(content) > sniffLen {
+			content = content[:sniffLen]
+		}
+		if t := http.DetectContentType(content); t != "application/octet-stream" {
+			return t
+		}
+	}
+	return TypeByFilename(filename)
+}
+
+// Peek 读取r开头最多512字节用于嗅探，返回的reader仍包含完整内容
+func Peek(r io.Reader) ([]byte, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+	return head, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// TypeByFilename 根据文件扩展名推断内容类型，不含参数部分(如charset)
+// 无法识别时返回空字符串
+func TypeByFilename(name string) string {
+	t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+	if i := strings.IndexByte(t, ';'); i >= 0 {
+		t = t[:i]
+	}
+	return strings.TrimSpace(t)
+}
+
+// Match 判断内容类型是否匹配模式
+// 模式支持精确类型(text/html)、主类型通配(image/*)以及全部通配(*)
+func Match(pattern, contentType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	// 忽略参数部分，如 text/plain; charset=utf-8
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	switch {
+	case pattern == "*" || pattern == "*/*":
+		return true
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == contentType
+	}
+}
+
+// Lookup 在以内容类型模式为键的规则中查找最匹配的值
+// 精确类型优先于主类型通配，主类型通配优先于全部通配
+func Lookup(rules map[string]string, contentType string) (string, bool) {
+	best, bestRank := "", 0
+	for pattern, value := range rules {
+		if !Match(pattern, contentType) {
+			continue
+		}
+		if rank := specificity(pattern); rank > bestRank {
+			best, bestRank = value, rank
+		}
+	}
+	return best, bestRank > 0
+}
+
+// specificity 模式的具体程度，越大越具体
+func specificity(pattern string) int {
+	pattern = strings.TrimSpace(pattern)
+	switch {
+	case pattern == "*" || pattern == "*/*":
+		return 1
+	case strings.HasSuffix(pattern, "/*"):
+		return 2
+	default:
+		return 3
+	}
+}
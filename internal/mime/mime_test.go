@@ -0,0 +1,74 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 13:20:44
+ * Description: 内容类型匹配测试
+ */
+package mime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试规则按具体程度匹配
+func TestLookup(t *testing.T) {
+	rules := map[string]string{
+		"image/*":   "public, max-age=31536000",
+		"text/html": "public, max-age=300",
+		"*":         "no-cache",
+	}
+
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"image/png", "public, max-age=31536000"},
+		{"text/html", "public, max-age=300"},
+		{"text/plain", "no-cache"},
+		{"", "no-cache"},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(rules, tt.contentType)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, got, tt.contentType)
+	}
+
+	_, ok := Lookup(map[string]string{"image/*": "x"}, "video/mp4")
+	assert.False(t, ok)
+}
+
+// 测试根据文件名推断内容类型
+func TestTypeByFilename(t *testing.T) {
+	assert.Equal(t, "image/png", TypeByFilename("a/b.PNG"))
+	assert.Equal(t, "text/html", TypeByFilename("index.html"))
+	assert.Equal(t, "", TypeByFilename("noext"))
+}
+
+// 测试内容类型的确定顺序
+func TestResolveContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	r := Resolver{Overrides: map[string]string{"webp": "image/webp", ".LOG": "text/plain"}, DetectMIME: true}
+
+	tests := []struct {
+		name     string
+		resolver Resolver
+		filename string
+		content  []byte
+		explicit string
+		want     string
+	}{
+		{"显式类型优先", r, "a.png", png, "application/x-custom", "application/x-custom"},
+		{"扩展名覆盖", r, "a.WEBP", png, "", "image/webp"},
+		{"覆盖键带点且不区分大小写", r, "app.log", nil, "", "text/plain"},
+		{"嗅探内容", r, "a.bin", png, "", "image/png"},
+		{"嗅探失败时按扩展名", r, "a.pdf", []byte{0x00, 0x01}, "", "application/pdf"},
+		{"未开启嗅探按扩展名", Resolver{}, "a.txt", png, "", "text/plain"},
+		{"无法确定", Resolver{}, "a", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.resolver.ResolveContentType(tt.filename, tt.content, tt.explicit))
+		})
+	}
+}
@@ -0,0 +1,32 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:12:05
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:12:05
+ * Description: 为发往存储服务的请求设置User-Agent，用于SDK没有按客户端设置User-Agent的选项时
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package useragent
+
+import "net/http"
+
+// transport 设置请求的User-Agent头后交给base发送
+type transport struct {
+	base http.RoundTripper
+	ua   string
+}
+
+// Wrap 包装base，将每个请求的User-Agent设置为ua，base为nil时使用 http.DefaultTransport
+func Wrap(base http.RoundTripper, ua string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, ua: ua}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper不应修改传入的请求，复制后再设置请求头
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,52 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 20:31:46
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 20:31:46
+ * Description: 缓存最近创建的日期目录，避免每次上传都调用MkdirAll
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"os"
+	"sync"
+)
+
+// dirCache 记录最近一次创建成功的存储目录
+// 同一天内目录不变，命中缓存时跳过MkdirAll；日期切换时重新创建
+type dirCache struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// ensure 确保目录存在，目录与上次相同时直接返回
+func (c *dirCache) ensure(dir string) error {
+	c.mu.RLock()
+	hit := c.dir == dir
+	c.mu.RUnlock()
+	if hit {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 双重检查，避免多个协程在日期切换时重复创建
+	if c.dir == dir {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	c.dir = dir
+	return nil
+}
+
+// invalidate 清除缓存，用于检测到目录被外部删除时
+func (c *dirCache) invalidate(dir string) {
+	c.mu.Lock()
+	if c.dir == dir {
+		c.dir = ""
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,69 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 06:31:15
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 06:31:15
+ * Description: 本地存储的HTTP文件服务，支持Range请求，视频可拖动播放
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/mime"
+)
+
+// FileServer 返回对外提供本地文件的HTTP处理器，请求路径为 /<key>，挂载到子路径时配合 http.StripPrefix 使用
+//
+// 由 http.ServeContent 输出文件：响应带 Accept-Ranges: bytes，Range请求返回206，
+// 并按文件修改时间处理 If-Modified-Since 等条件请求。内容类型按扩展名确定，无法识别时由 ServeContent 嗅探。
+// 不提供目录列表与标签旁路文件；需要私有访问时先用 VerifyLocalSignedURL 校验请求
+func (u *LocalUploader) FileServer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key, err := keygen.NormalizeKey(r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if key == tagsDir || strings.HasPrefix(key, tagsDir+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		fullPath := filepath.Join(u.basePath, filepath.FromSlash(key))
+
+		f, err := os.Open(fullPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Accept-Ranges", "bytes")
+		if ct := mime.TypeByFilename(key); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		http.ServeContent(w, r, path.Base(key), stat.ModTime(), f)
+	})
+}
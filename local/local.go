@@ -9,24 +9,57 @@
 package local
 
 import (
-	"encoding/base64"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/b64"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/keylock"
+	"github.com/zjguoxin/gosuploader/internal/magic"
+	"github.com/zjguoxin/gosuploader/internal/mime"
 )
 
 // LocalUploader 本地文件上传处理器
 type LocalUploader struct {
-	basePath string // 基础存储路径
+	basePath      string                // 基础存储路径
+	keyStrategy   string                // 唯一文件名生成策略
+	sequence      *config.Sequence      // sequential策略的计数器
+	keyCharPolicy *config.KeyCharPolicy // 存储key的字符策略
+	keySeparator  string                // 生成key时替换/的分隔符，非空时文件都位于basePath下
+	routingRules  *config.RoutingRules  // 按文件类型选择生成key的前缀目录
+	keys          keylock.Locker        // WithKey上传时的按key锁，仅在本实例内生效，不是分布式锁
+
+	maxFilesPerDir  int       // 单个目录的文件数上限，0表示不限制
+	maxBase64Length int64     // Base64上传解码后的最大字节数
+	roller          dirRoller // 编号子目录的滚动状态
+	dirs            dirCache  // 最近创建的日期目录
+
+	provenanceTags   map[string]string // 每次上传自动写入的来源标签
+	collisionRetries int               // 自动生成的文件名冲突时的重试次数
+
+	signingSecret []byte // 签名URL的HMAC密钥
+	baseURL       string // 对外访问文件的地址前缀
 }
 
+// defaultCollisionRetries 文件名冲突的默认重试次数
+const defaultCollisionRetries = 3
+
 // New 创建本地文件上传处理器
 // 如果需要自定义路径，可以传入config.LocalConfig结构体
 // 例如：config.LocalConfig{BasePath: "custom/path/to/uploads"}
@@ -42,18 +75,48 @@ func New(cfg config.LocalConfig) *LocalUploader {
 	if cfg.BasePath == "" {
 		cfg.BasePath = "storage/uploads"
 	}
+	if cfg.CollisionRetries <= 0 {
+		cfg.CollisionRetries = defaultCollisionRetries
+	}
+	// 没有持久化的计数器在重启后从1开始，会与已有文件冲突，改为从目录中已有的最大编号继续
+	if cfg.KeyStrategy == config.KeyStrategySequential && !cfg.Sequence.Persistent() {
+		width := config.DefaultSequenceWidth
+		if cfg.Sequence != nil {
+			width = cfg.Sequence.Width
+		}
+		cfg.Sequence = config.NewSequence(width, dirSequenceStore{basePath: cfg.BasePath, separator: cfg.KeySeparator})
+	}
 
 	return &LocalUploader{
-		basePath: cfg.BasePath,
+		basePath:      cfg.BasePath,
+		keyStrategy:   cfg.KeyStrategy,
+		sequence:      cfg.Sequence,
+		keyCharPolicy: cfg.KeyCharPolicy,
+		keySeparator:  cfg.KeySeparator,
+		routingRules:  cfg.RoutingRules,
+
+		maxFilesPerDir:  cfg.MaxFilesPerDir,
+		maxBase64Length: cfg.MaxBase64Length,
+
+		provenanceTags:   cfg.ProvenanceTags,
+		collisionRetries: cfg.CollisionRetries,
+
+		signingSecret: []byte(cfg.SigningSecret),
+		baseURL:       strings.TrimSuffix(cfg.BaseURL, "/"),
 	}
 }
 
 // UploadFile 上传multipart表单文件
-func (u *LocalUploader) UploadFile(file *multipart.FileHeader) (string, error) {
+func (u *LocalUploader) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
 	if file == nil {
 		return "", errors.New("file header cannot be nil")
 	}
 
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+
 	// 打开上传文件
 	src, err := file.Open()
 	if err != nil {
@@ -61,60 +124,66 @@ func (u *LocalUploader) UploadFile(file *multipart.FileHeader) (string, error) {
 	}
 	defer src.Close()
 
-	// 生成存储路径和文件名
-	filePath, err := u.generateFilePath(file.Filename)
+	return u.save(file.Filename, src, file.Size, o)
+}
+
+// UploadFSFile 上传 fs.File 的内容，适用于 os.DirFS、embed.FS 等非HTTP场景
+// key为空时按文件名生成存储路径，ctx取消或超时时中止写入并删除写了一半的文件；调用方负责关闭f
+func (u *LocalUploader) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate file path: %w", err)
+		return config.UploadResult{}, err
 	}
-
-	// 创建目标文件
-	dst, err := os.Create(filePath)
+	file, err := fsfile.Open(f, mime.Resolver{}, o)
 	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %w", err)
+		return config.UploadResult{}, err
 	}
-	defer dst.Close()
 
-	// 复制文件内容
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", fmt.Errorf("failed to save file: %w", err)
+	result := config.UploadResult{Size: file.Size, ContentType: file.ContentType, RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	path, err := u.save(file.Name, file.Reader, file.Size, o)
+	if err != nil {
+		return config.UploadResult{}, err
 	}
+	result.Path = path
+	return result, nil
+}
 
-	// 返回相对路径
-	relPath, err := filepath.Rel(u.basePath, filePath)
+// UploadStream 从r流式写入大小未知的内容，key为空时按 WithFilename 指定的文件名生成存储路径，返回的 UploadResult.Path 为相对路径
+// ctx取消、超时或读取失败(如 UploadWriter.Abort)时删除已写入的部分；指定key时先写入临时文件，
+// 中止后同名的原文件保持不变
+func (u *LocalUploader) UploadStream(ctx context.Context, key string, r io.Reader, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
 	if err != nil {
-		return filePath, nil // 如果获取相对路径失败，返回绝对路径
+		return config.UploadResult{}, err
 	}
 
-	return relPath, nil
+	result := config.UploadResult{RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	path, err := u.save("", r, -1, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	result.Path = path
+	return result, nil
 }
 
 // UploadBinary 上传二进制数据
 // filename: 原始文件名，用于生成存储路径和文件名
 // content: 二进制内容，不能为空
+// opts: 上传选项，本地存储会忽略请求头类选项
 // 返回值: 相对路径或绝对路径，上传失败时返回错误
-func (u *LocalUploader) UploadBinary(filename string, content []byte) (string, error) {
+func (u *LocalUploader) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
 	if len(content) == 0 {
 		return "", errors.New("content cannot be empty")
 	}
 
-	// 生成存储路径和文件名
-	filePath, err := u.generateFilePath(filename)
+	o, err := config.NewUploadOptions(opts...)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate file path: %w", err)
+		return "", err
 	}
 
-	// 写入文件
-	if err := os.WriteFile(filePath, content, 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	// 返回相对路径
-	relPath, err := filepath.Rel(u.basePath, filePath)
-	if err != nil {
-		return filePath, nil
-	}
-
-	return relPath, nil
+	return u.save(filename, bytes.NewReader(content), int64(len(content)), o)
 }
 
 // UploadBase64 上传Base64编码的文件
@@ -122,18 +191,18 @@ func (u *LocalUploader) UploadBinary(filename string, content []byte) (string, e
 // base64Str: Base64编码的字符串，不能为空
 // 返回值: 相对路径或绝对路径，上传失败时返回错误
 // 注意：Base64字符串必须是有效的Base64编码，否则会返回解码错误
-func (u *LocalUploader) UploadBase64(filename string, base64Str string) (string, error) {
+func (u *LocalUploader) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
 	if base64Str == "" {
 		return "", errors.New("base64 content cannot be empty")
 	}
 
 	// 解码Base64数据
-	data, err := base64.StdEncoding.DecodeString(base64Str)
+	data, err := b64.Decode(base64Str, u.maxBase64Length)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	return u.UploadBinary(filename, data)
+	return u.UploadBinary(filename, data, opts...)
 }
 
 // Delete 删除文件
@@ -142,44 +211,377 @@ func (u *LocalUploader) UploadBase64(filename string, base64Str string) (string,
 // 注意：如果文件不存在，会返回错误
 // 如果filePath是相对路径，则相对于basePath进行查找
 // 如果filePath是绝对路径，则直接使用该路径进行删除
-// 如果filePath是一个目录，则会返回错误
+// 如果filePath是一个目录，则返回 config.ErrIsDirectory，删除目录请使用 DeleteDir
 func (u *LocalUploader) Delete(filePath string) error {
-	fullPath := filepath.Join(u.basePath, filePath)
+	// 文件与标签旁路文件都按字符策略转换后的key定位
+	key := u.keyCharPolicy.Apply(filepath.ToSlash(filePath))
+	fullPath := filepath.Join(u.basePath, filepath.FromSlash(key))
 
 	// 检查文件是否存在
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		return fmt.Errorf("file not exists: %s", fullPath)
+	stat, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("file not exists: %s: %w", fullPath, config.ErrNotFound)
+	}
+	if err == nil && stat.IsDir() {
+		return fmt.Errorf("%w: %s", config.ErrIsDirectory, filePath)
 	}
 
 	// 删除文件
-	err := os.Remove(fullPath)
+	err = os.Remove(fullPath)
 	if err != nil {
 		return fmt.Errorf("failed to delete file: %v", err)
 	}
+	u.deleteTags(key)
 
 	return nil
 }
 
-// generateFilePath 生成完整的文件存储路径
-func (u *LocalUploader) generateFilePath(originalName string) (string, error) {
-	// 生成日期目录
-	dateDir := time.Now().Format("2006/01/02")
-	storageDir := filepath.Join(u.basePath, dateDir)
+// DeleteDir 递归删除basePath下的目录及其中文件的标签，用于有意删除整个目录
+// dirPath按存储key规范化，不能为空、不能是basePath本身或超出basePath(包括经由符号链接)；
+// 目录不存在时返回 config.ErrNotFound，不是目录时返回错误
+func (u *LocalUploader) DeleteDir(dirPath string) error {
+	fullPath, err := u.fullPath(dirPath)
+	if err != nil {
+		return err
+	}
+	key, _ := keygen.NormalizeKey(dirPath)
+	if key == tagsDir || strings.HasPrefix(key, tagsDir+"/") {
+		return fmt.Errorf("%w: %s is reserved", config.ErrInvalidKey, tagsDir)
+	}
+
+	stat, err := os.Lstat(fullPath)
+	if os.IsNotExist(err) {
+		return config.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat directory: %w", err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("not a directory: %s", dirPath)
+	}
+	// 上级目录可能是指向basePath之外的符号链接
+	if err := u.checkContained(fullPath); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(fullPath); err != nil {
+		return fmt.Errorf("failed to delete directory: %w", err)
+	}
+	os.RemoveAll(filepath.Join(u.basePath, tagsDir, filepath.FromSlash(key)))
+	return nil
+}
+
+// checkContained 确认解析符号链接后的路径仍在basePath之内
+func (u *LocalUploader) checkContained(fullPath string) error {
+	base, err := filepath.EvalSymlinks(u.basePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base path: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: path escapes base directory", config.ErrInvalidKey)
+	}
+	return nil
+}
+
+// BucketUsage 统计基础路径下的磁盘占用(字节)与文件数量
+func (u *LocalUploader) BucketUsage() (int64, int64, error) {
+	var used, count int64
+	err := filepath.WalkDir(u.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if u.isTagsDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		used += info.Size()
+		count++
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, fmt.Errorf("failed to stat storage usage: %w", err)
+	}
+	return used, count, nil
+}
+
+// Ping 检查基础路径是否存在且可写
+// 探测文件写在列举与用量统计都会跳过的标签目录中，检查期间不会出现在存储的文件里
+func (u *LocalUploader) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := u.ensureBasePathExists(); err != nil {
+		return fmt.Errorf("failed to create base path: %w", err)
+	}
+
+	dir := filepath.Join(u.basePath, tagsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("base path is not writable: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("base path is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// save 将数据写入新生成的存储路径
+// 返回相对路径，获取相对路径失败时返回绝对路径
+func (u *LocalUploader) save(filename string, r io.Reader, size int64, o *config.UploadOptions) (string, error) {
+	if o.Retention != nil {
+		return "", fmt.Errorf("local storage does not support retention: %w", config.ErrNotSupported)
+	}
+	if o.CustomerKey != nil {
+		return "", fmt.Errorf("local storage does not support customer-provided encryption keys: %w", config.ErrNotSupported)
+	}
+	if o.StrictTypeValidation {
+		var err error
+		if r, err = magic.Validate(r, o.FilenameOr(filename), config.MagicByteRules); err != nil {
+			return "", err
+		}
+	}
+
+	// 内容寻址时key由内容摘要决定：先写入basePath下的临时文件，写入的同时计算摘要，
+	// 大小限制与内容扫描在同一次读取中生效，不需要将内容读入内存
+	contentAddressed := o.Key == "" && u.keyStrategy == config.KeyStrategyContentAddressed
+	var filePath string
+	var err error
+	if contentAddressed {
+		if err = u.ensureBasePathExists(); err != nil {
+			return "", fmt.Errorf("failed to create base path: %w", err)
+		}
+		filePath = filepath.Join(u.basePath, "content")
+	} else if filePath, err = u.filePath(filename, o); err != nil {
+		// 生成存储路径和文件名
+		return "", fmt.Errorf("failed to generate file path: %w", err)
+	}
+
+	// 同一key的并发写入串行执行，避免内容交错
+	if o.Key != "" {
+		defer u.keys.Lock(filePath)()
+	}
+	r, done := audit.Wrap(r, size, o)
+	var contentHash hash.Hash
+	if contentAddressed {
+		contentHash = sha256.New()
+		r = io.TeeReader(r, contentHash)
+	}
+
+	// 指定key时先写入同目录的临时文件，完成后重命名覆盖同名文件，中止时原文件保持不变；
+	// 自动生成的文件名以独占方式创建，与并发写入者冲突时重新生成文件名，超过重试次数返回 ErrTooManyCollisions
+	var dst *os.File
+	if o.Key != "" || contentAddressed {
+		dst, err = u.createTemp(filePath)
+	} else {
+		for attempt := 0; ; attempt++ {
+			dst, err = u.openFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+			if !errors.Is(err, fs.ErrExist) {
+				break
+			}
+			if attempt >= u.collisionRetries {
+				return "", fmt.Errorf("%w: %s", config.ErrTooManyCollisions, filePath)
+			}
+			if filePath, err = u.filePath(filename, o); err != nil {
+				return "", fmt.Errorf("failed to generate file path: %w", err)
+			}
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create destination file: %w", err)
+	}
+
+	// 复制文件内容，任何失败(超时、取消、读取中断、超出大小限制、扫描拒绝)都删除已写入的部分
+	ctx, cancel := o.Context(size)
+	defer cancel()
+	written, err := io.Copy(dst, contextReader{ctx: ctx, r: r})
+	if err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	if err = dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	if contentAddressed {
+		key := keygen.ContentKeyOf(o.FilenameOr(filename), contentHash.Sum(nil))
+		if filePath, err = u.fullPath(key); err == nil {
+			err = os.MkdirAll(filepath.Dir(filePath), 0755)
+		}
+		if err != nil {
+			os.Remove(dst.Name())
+			return "", err
+		}
+		// 已存在的文件内容相同，丢弃本次写入
+		if _, err := os.Stat(filePath); err == nil {
+			os.Remove(dst.Name())
+			return filepath.FromSlash(key), nil
+		}
+	}
+	if dst.Name() != filePath {
+		if err = os.Rename(dst.Name(), filePath); err != nil {
+			os.Remove(dst.Name())
+			return "", fmt.Errorf("failed to save file: %w", err)
+		}
+	}
+	if o.VerifyAfterUpload {
+		if err := config.VerifySize(func() (int64, error) { return fileSize(filePath) }, written); err != nil {
+			return "", err
+		}
+	}
+
+	// 返回相对路径
+	relPath, err := filepath.Rel(u.basePath, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	tags := config.ObjectTags(u.provenanceTags, o)
+	if o.RecordUploadedAt {
+		if tags == nil {
+			tags = make(map[string]string, 1)
+		}
+		tags[uploadedAtTag] = config.FormatUploadedAt(time.Now())
+	}
+	if err := u.writeTags(relPath, tags); err != nil {
+		return "", fmt.Errorf("failed to save tags: %w", err)
+	}
+
+	done(relPath)
+	return relPath, nil
+}
+
+// fileSize 返回落盘文件的大小
+func fileSize(filePath string) (int64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// openFile 打开目标文件，目录被外部删除时重新创建目录后重试一次
+func (u *LocalUploader) openFile(filePath string, flag int) (*os.File, error) {
+	f, err := os.OpenFile(filePath, flag, 0644)
+	if errors.Is(err, fs.ErrNotExist) {
+		u.dirs.invalidate(filepath.Dir(filePath))
+		if err = u.dirs.ensure(filepath.Dir(filePath)); err == nil {
+			f, err = os.OpenFile(filePath, flag, 0644)
+		}
+	}
+	return f, err
+}
+
+// createTemp 在filePath所在目录创建写入用的临时文件，文件名以 . 开头
+func (u *LocalUploader) createTemp(filePath string) (*os.File, error) {
+	f, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// filePath 确定文件存储路径，优先使用上传选项指定的key
+func (u *LocalUploader) filePath(originalName string, o *config.UploadOptions) (string, error) {
+	if o.Key == "" {
+		return u.generateFilePath(o.FilenameOr(originalName), o.ContentType)
+	}
 
-	// 创建目录
-	if err := os.MkdirAll(storageDir, 0755); err != nil {
+	key, err := keygen.PolicyKey(o.Key, u.keyCharPolicy)
+	if err != nil {
+		return "", err
+	}
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 		return "", fmt.Errorf("failed to create storage directory: %w", err)
 	}
+	return fullPath, nil
+}
 
-	// 生成唯一文件名
-	ext := filepath.Ext(originalName)
-	baseName := strings.TrimSuffix(filepath.Base(originalName), ext)
-	uniqueName := fmt.Sprintf("%s_%d%s", baseName, time.Now().UnixNano(), ext)
+// generateFilePath 生成完整的文件存储路径，contentType为空时按扩展名推断，用于匹配路由规则
+func (u *LocalUploader) generateFilePath(originalName, contentType string) (string, error) {
+	// 生成[路由前缀/]日期目录和唯一文件名
+	key, err := keygen.GenerateKey(originalName, keygen.KeygenOptions{
+		DateFormat: "2006/01/02",
+		Prefix:     u.routingRules.Prefix(originalName, cmp.Or(contentType, mime.TypeByFilename(originalName))),
+		Strategy:   u.keyStrategy,
+		Sequence:   u.sequence,
+		Separator:  u.keySeparator,
+	})
+	if err != nil {
+		return "", err
+	}
+	// local.New 不校验路由前缀，拼接到basePath前确认key不会指向存储目录之外
+	key, err = keygen.PolicyKey(key, u.keyCharPolicy)
+	if err != nil {
+		return "", err
+	}
+	dateDir, uniqueName := path.Split(key)
+	if u.maxFilesPerDir > 0 && u.keySeparator == "" {
+		dateDir = u.roller.next(u.basePath, path.Clean(dateDir), u.maxFilesPerDir)
+	}
+	storageDir := filepath.Join(u.basePath, filepath.FromSlash(dateDir))
+
+	// 创建目录，与上次相同时跳过
+	if err := u.dirs.ensure(storageDir); err != nil {
+		return "", fmt.Errorf("failed to create storage directory: %w", err)
+	}
 
 	return filepath.Join(storageDir, uniqueName), nil
 }
 
+// ProviderLimits 本地存储没有上传大小限制
+func (u *LocalUploader) ProviderLimits() config.ProviderLimits {
+	return config.ProviderLimits{}
+}
+
+// Warm 预先创建当天的日期目录，可在启动时调用以减少首次上传的延迟
+func (u *LocalUploader) Warm() error {
+	dir := filepath.Join(u.basePath, filepath.FromSlash(time.Now().Format("2006/01/02")))
+	ensure := u.dirs.ensure
+	if u.maxFilesPerDir > 0 {
+		// 启用目录滚动时只创建日期目录，编号子目录在分配时创建
+		ensure = func(dir string) error { return os.MkdirAll(dir, 0755) }
+	}
+	if err := ensure(dir); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return nil
+}
+
 // ensureBasePathExists 确保基础路径存在
 func (u *LocalUploader) ensureBasePathExists() error {
 	return os.MkdirAll(u.basePath, 0755)
 }
+
+// contextReader 在上下文结束后停止读取，用于让本地写入遵守上传超时
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
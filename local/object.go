@@ -0,0 +1,357 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 14:31:18
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 14:31:18
+ * Description: 本地存储文件管理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/mime"
+)
+
+// Copy 复制文件，key为相对于basePath的路径，目标已存在时覆盖
+func (u *LocalUploader) Copy(ctx context.Context, srcKey, dstKey string) error {
+	src, err := u.fullPath(srcKey)
+	if err != nil {
+		return err
+	}
+	dst, err := u.fullPath(dstKey)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if src == dst {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config.ErrNotFound
+		}
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := u.moveTags(srcKey, dstKey, true); err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
+	}
+	return nil
+}
+
+// Move 移动文件，key为相对于basePath的路径
+func (u *LocalUploader) Move(srcKey, dstKey string) error {
+	src, err := u.fullPath(srcKey)
+	if err != nil {
+		return err
+	}
+	dst, err := u.fullPath(dstKey)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if err := u.moveTags(srcKey, dstKey, false); err != nil {
+		return fmt.Errorf("failed to move tags: %w", err)
+	}
+	return nil
+}
+
+// GetObjectInfo 获取文件信息，文件不存在时返回 config.ErrNotFound
+func (u *LocalUploader) GetObjectInfo(key string) (config.ObjectInfo, error) {
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return config.ObjectInfo{}, err
+	}
+
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config.ObjectInfo{}, config.ErrNotFound
+		}
+		return config.ObjectInfo{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		return config.ObjectInfo{}, config.ErrNotFound
+	}
+
+	// 上传时间记录在标签旁路文件中，读取失败时与云存储一致地回退为修改时间
+	tags, _ := u.readTags(key)
+	return config.ObjectInfo{
+		Key:          filepath.ToSlash(key),
+		Size:         stat.Size(),
+		ContentType:  mime.TypeByFilename(key),
+		LastModified: stat.ModTime(),
+		UploadedAt:   config.ParseUploadedAt(tags[uploadedAtTag], stat.ModTime()),
+	}, nil
+}
+
+// Size 返回文件大小，文件不存在时返回 config.ErrNotFound
+func (u *LocalUploader) Size(key string) (int64, error) {
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return 0, err
+	}
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, config.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		return 0, config.ErrNotFound
+	}
+	return stat.Size(), nil
+}
+
+// Exists 判断文件是否存在
+func (u *LocalUploader) Exists(key string) (bool, error) {
+	_, err := u.GetObjectInfo(u.keyCharPolicy.Apply(key))
+	if errors.Is(err, config.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ExistsMany 逐个判断文件是否存在，检查失败的key不出现在结果中，错误汇总返回
+func (u *LocalUploader) ExistsMany(keys []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(keys))
+	var errs []error
+	for _, key := range keys {
+		exists, err := u.Exists(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		result[key] = exists
+	}
+	return result, errors.Join(errs...)
+}
+
+// List 列出前缀下的所有文件key，key使用/分隔，前缀按原样匹配
+// 配置了 KeySeparator 时生成的key需以分隔后的形式查询，如 2026_10_，WithKey 指定的含/的key仍按/查询
+func (u *LocalUploader) List(prefix string) ([]string, error) {
+	var keys []string
+	err := u.walk(context.Background(), prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Count 统计前缀下的文件数量，需要遍历目录，耗时与文件数成正比
+func (u *LocalUploader) Count(ctx context.Context, prefix string) (int64, error) {
+	var count int64
+	err := u.walk(ctx, prefix, func(string) { count++ })
+	return count, err
+}
+
+// walk 遍历前缀下的文件，跳过标签目录，ctx结束时停止遍历
+// 前缀的目录部分含..等越出basePath的路径段时返回 config.ErrInvalidKey
+func (u *LocalUploader) walk(ctx context.Context, prefix string, fn func(key string)) error {
+	// 从前缀中最深的完整目录开始遍历，避免扫描整个basePath
+	root := u.basePath
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir, err := keygen.NormalizeKey(prefix[:i])
+		if err != nil {
+			return err
+		}
+		root = filepath.Join(u.basePath, filepath.FromSlash(dir))
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if u.isTagsDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(u.basePath, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); !strings.HasPrefix(key, "../") && strings.HasPrefix(key, prefix) {
+			fn(key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	return nil
+}
+
+// fullPath 将key规范化后转换为basePath下的完整路径，拒绝越出basePath的key
+func (u *LocalUploader) fullPath(key string) (string, error) {
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(u.basePath, filepath.FromSlash(key)), nil
+}
+
+// Head 读取文件的前n个字节，文件小于n时返回全部内容
+func (u *LocalUploader) Head(key string, n int64) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("head length must be positive")
+	}
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// ReadRangeInto 从off开始读取至多len(buf)字节到buf，使用ReadAt，语义同 io.ReaderAt
+func (u *LocalUploader) ReadRangeInto(key string, off int64, buf []byte) (int, error) {
+	if off < 0 {
+		return 0, errors.New("range offset must not be negative")
+	}
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, config.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	n, err := f.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("failed to read file: %w", err)
+	}
+	return n, err
+}
+
+// DownloadRange 从offset开始读取length字节，length小于0时读取到文件末尾
+// 调用方负责关闭返回的ReadCloser
+func (u *LocalUploader) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("range offset must not be negative")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to seek file: %w", err)
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+// DownloadIfNoneMatch 读取完整文件并返回其ETag，etag与文件当前ETag相同时返回 ErrNotModified
+// 本地文件没有服务端ETag，以文件大小与修改时间生成，文件被重写后即发生变化
+func (u *LocalUploader) DownloadIfNoneMatch(ctx context.Context, key, etag string) (io.ReadCloser, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	fullPath, err := u.fullPath(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", config.ErrNotFound
+		}
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		f.Close()
+		return nil, "", config.ErrNotFound
+	}
+
+	current := fmt.Sprintf("%x-%x", stat.Size(), stat.ModTime().UnixNano())
+	if strings.Trim(strings.TrimPrefix(etag, "W/"), `"`) == current {
+		f.Close()
+		return nil, current, config.ErrNotModified
+	}
+	return f, current, nil
+}
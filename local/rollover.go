@@ -0,0 +1,76 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 18:42:09
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 18:42:09
+ * Description: 按单目录文件数上限滚动到编号子目录
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// dirRoller 记录当前日期目录下正在写入的编号子目录及其文件数
+type dirRoller struct {
+	mu      sync.Mutex
+	dateDir string // 当前日期目录(相对basePath)
+	index   int    // 当前编号子目录
+	count   int    // 当前编号子目录已分配的文件数
+}
+
+// next 为一个新文件分配编号子目录，返回相对basePath的目录，如 2006/01/02/00
+// 切换日期目录时从磁盘恢复已有的编号与文件数，使重启后继续使用原有子目录
+func (r *dirRoller) next(basePath, dateDir string, max int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dateDir != dateDir {
+		r.dateDir = dateDir
+		r.index, r.count = scanBuckets(filepath.Join(basePath, dateDir))
+	}
+	if r.count >= max {
+		r.index++
+		r.count = 0
+	}
+	r.count++
+
+	return filepath.Join(dateDir, fmt.Sprintf("%02d", r.index))
+}
+
+// scanBuckets 查找目录下编号最大的子目录及其中的文件数，目录不存在时返回0, 0
+func scanBuckets(dir string) (index int, count int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+
+	found := false
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if n, err := strconv.Atoi(e.Name()); err == nil && n >= index {
+			index, found = n, true
+		}
+	}
+	if !found {
+		return 0, 0
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, fmt.Sprintf("%02d", index)))
+	if err != nil {
+		return index, 0
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			count++
+		}
+	}
+	return index, count
+}
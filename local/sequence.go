@@ -0,0 +1,67 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 08:36:20
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:36:20
+ * Description: 从已有文件恢复顺序编号，使重启后编号延续
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// dirSequenceStore 未配置 SequenceStore 时使用的编号来源：按目录中已有文件的最大编号恢复计数，
+// 与 dirRoller.scanBuckets 一样在每个目录首次使用时扫描磁盘；编号保存在文件名中，Save不需要记录
+type dirSequenceStore struct {
+	basePath  string
+	separator string // 生成key时替换/的分隔符，非空时文件都位于basePath下
+}
+
+// Load 返回scope目录(含 MaxFilesPerDir 的编号子目录)中文件名为纯数字编号的最大值
+func (s dirSequenceStore) Load(scope string) (uint64, error) {
+	if s.separator != "" {
+		prefix := keygen.Flatten(scope, s.separator)
+		if prefix != "" {
+			prefix += s.separator
+		}
+		return maxNumber(s.basePath, prefix, false), nil
+	}
+	return maxNumber(filepath.Join(s.basePath, filepath.FromSlash(scope)), "", true), nil
+}
+
+// Save 编号由文件本身记录，无需保存
+func (dirSequenceStore) Save(string, uint64) error { return nil }
+
+// maxNumber 查找dir中以prefix开头、其余部分(去除扩展名)为纯数字的文件的最大编号，
+// subdirs为true时同时查找编号子目录，目录不存在时返回0
+func maxNumber(dir, prefix string, subdirs bool) uint64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	var highest uint64
+	for _, e := range entries {
+		if e.IsDir() {
+			if _, err := strconv.Atoi(e.Name()); subdirs && err == nil {
+				highest = max(highest, maxNumber(filepath.Join(dir, e.Name()), prefix, false))
+			}
+			continue
+		}
+		name, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(strings.TrimSuffix(name, filepath.Ext(name)), 10, 64); err == nil {
+			highest = max(highest, n)
+		}
+	}
+	return highest
+}
@@ -0,0 +1,96 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 23:31:52
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 23:31:52
+ * Description: 本地存储的HMAC签名URL，由反向代理对外提供文件时模拟私有访问
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// 签名URL的查询参数
+const (
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+// GenerateLocalSignedURL 生成expires后过期的签名URL：<BaseURL>/<key>?expires=<unix>&signature=<hex>
+// 签名为 HMAC-SHA256(SigningSecret, key + "|" + expires)，未配置 SigningSecret 时返回 config.ErrNotSupported
+// 不检查文件是否存在
+func (u *LocalUploader) GenerateLocalSignedURL(key string, expires time.Duration) (string, error) {
+	if len(u.signingSecret) == 0 {
+		return "", fmt.Errorf("local signed URL requires SigningSecret: %w", config.ErrNotSupported)
+	}
+	if expires <= 0 {
+		return "", errors.New("signed URL expiry must be positive")
+	}
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	expiry := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{
+		expiresParam:   {expiry},
+		signatureParam: {u.sign(key, expiry)},
+	}
+	return u.baseURL + (&url.URL{Path: "/" + key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// VerifyLocalSignedURL 校验签名URL并返回其中的key，可传入完整URL或反向代理收到的请求URI
+// 签名不正确或URL不属于 BaseURL 时返回 config.ErrInvalidSignature，已过期返回 config.ErrSignatureExpired
+func (u *LocalUploader) VerifyLocalSignedURL(rawURL string) (string, error) {
+	if len(u.signingSecret) == 0 {
+		return "", fmt.Errorf("local signed URL requires SigningSecret: %w", config.ErrNotSupported)
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", config.ErrInvalidSignature, err)
+	}
+	base, err := url.Parse(u.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid BaseURL: %w", err)
+	}
+
+	key, ok := strings.CutPrefix(parsed.Path, base.Path+"/")
+	if !ok {
+		return "", fmt.Errorf("%w: path outside base URL", config.ErrInvalidSignature)
+	}
+	query := parsed.Query()
+	expiry := query.Get(expiresParam)
+	if !hmac.Equal([]byte(query.Get(signatureParam)), []byte(u.sign(key, expiry))) {
+		return "", config.ErrInvalidSignature
+	}
+
+	// 签名校验通过后expires一定是生成时写入的时间戳
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: bad expiry", config.ErrInvalidSignature)
+	}
+	if time.Now().Unix() > unix {
+		return "", config.ErrSignatureExpired
+	}
+	return key, nil
+}
+
+// sign 计算 HMAC-SHA256(secret, key + "|" + expiry) 的十六进制字符串
+func (u *LocalUploader) sign(key, expiry string) string {
+	mac := hmac.New(sha256.New, u.signingSecret)
+	mac.Write([]byte(key + "|" + expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,126 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 20:58:13
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 20:58:13
+ * Description: 本地存储的对象标签，保存在basePath/.tags下的旁路文件中
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// tagsDir 标签旁路文件的根目录(相对basePath)，列举与用量统计时会跳过
+const tagsDir = ".tags"
+
+// uploadedAtTag 以 WithUploadedAt 上传时记录上传时间的标签，使用来源标签前缀，不会与用户标签冲突
+const uploadedAtTag = config.ProvenanceTagPrefix + config.UploadedAtMeta
+
+// GetTags 读取文件标签，文件不存在时返回 config.ErrNotFound
+func (u *LocalUploader) GetTags(key string) (map[string]string, error) {
+	if _, err := u.GetObjectInfo(key); err != nil {
+		return nil, err
+	}
+	return u.readTags(key)
+}
+
+// readTags 读取标签旁路文件，没有标签时返回空map
+func (u *LocalUploader) readTags(key string) (map[string]string, error) {
+	path, err := u.tagsPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	tags := map[string]string{}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	return tags, nil
+}
+
+// tagsPath 返回key对应的标签旁路文件路径
+func (u *LocalUploader) tagsPath(key string) (string, error) {
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(u.basePath, tagsDir, filepath.FromSlash(key)+".json"), nil
+}
+
+// writeTags 保存文件标签，没有标签时删除旧的旁路文件，避免覆盖上传后残留旧标签
+func (u *LocalUploader) writeTags(key string, tags map[string]string) error {
+	path, err := u.tagsPath(key)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// moveTags 随文件移动标签，源文件没有标签时清除目标的旧标签
+func (u *LocalUploader) moveTags(srcKey, dstKey string, keep bool) error {
+	src, err := u.tagsPath(srcKey)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return u.writeTags(dstKey, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	if err := u.writeTags(dstKey, tags); err != nil {
+		return err
+	}
+	if !keep {
+		return os.Remove(src)
+	}
+	return nil
+}
+
+// isTagsDir 判断遍历到的目录是否为标签旁路目录
+func (u *LocalUploader) isTagsDir(path string) bool {
+	return path == filepath.Join(u.basePath, tagsDir)
+}
+
+// deleteTags 删除文件的标签，标签不存在时忽略
+func (u *LocalUploader) deleteTags(key string) {
+	if path, err := u.tagsPath(key); err == nil {
+		os.Remove(path)
+	}
+}
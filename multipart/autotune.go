@@ -0,0 +1,63 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 01:58:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 01:58:40
+ * Description: 按进程可用内存自动选择分片大小与分片上传阈值
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package multipart
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// 自动调优的边界
+const (
+	// DefaultThreshold 未设置内存上限时的分片上传阈值
+	DefaultThreshold int64 = 32 << 20
+
+	minAutoPartSize int64 = 5 << 20  // 不低于各服务商的最小分片
+	maxAutoPartSize int64 = 64 << 20 // 更大的分片对吞吐几乎没有帮助，只会增加重传代价
+)
+
+// memoryLimit 返回进程的内存上限(GOMEMLIMIT 或 debug.SetMemoryLimit)，未设置时返回0
+// 运行时无法可靠得知机器的可用内存，因此只在显式设置了上限时按上限调优
+var memoryLimit = func() int64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
+
+// AutoPartSize 按内存上限选择分片大小
+// 分片上传最多同时占用 (workers+1) 个分片的内存，取内存上限的1/4分摊到这些分片，
+// 按MB向下取整并限制在5MB~64MB之间；未设置内存上限时返回 DefaultPartSize
+func AutoPartSize(workers int) int64 {
+	limit := memoryLimit()
+	if limit == 0 {
+		return DefaultPartSize
+	}
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	size := limit / 4 / int64(workers+1) >> 20 << 20
+	if size < minAutoPartSize {
+		return minAutoPartSize
+	}
+	if size > maxAutoPartSize {
+		return maxAutoPartSize
+	}
+	return size
+}
+
+// AutoThreshold 按内存上限选择分片上传阈值，超过阈值的内容应使用分片上传而不是整体读入内存
+// 取默认并发数下分片大小的4倍(20MB~256MB)；未设置内存上限时返回 DefaultThreshold
+func AutoThreshold() int64 {
+	if memoryLimit() == 0 {
+		return DefaultThreshold
+	}
+	return 4 * AutoPartSize(DefaultWorkers)
+}
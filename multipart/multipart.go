@@ -0,0 +1,251 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 11:02:18
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 11:02:18
+ * Description: 与具体存储无关的并发分片上传
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package multipart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+const (
+	// DefaultPartSize 未设置内存上限时的默认分片大小
+	DefaultPartSize int64 = 8 << 20
+	// DefaultWorkers 默认并发数
+	DefaultWorkers = 4
+)
+
+// Part 已上传的分片
+type Part struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// Backend 支持分片上传的存储后端
+// 阿里云OSS、腾讯云COS、七牛云上传器均实现了该接口
+type Backend interface {
+	InitiateMultipart(ctx context.Context, key string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error)
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error
+	AbortMultipart(ctx context.Context, key, uploadID string) error
+}
+
+// IncompleteUpload 未完成(未合并也未中止)的分片上传
+type IncompleteUpload struct {
+	Key           string
+	UploadID      string
+	InitiatedAt   time.Time
+	PartCount     int   // 已上传的分片数
+	BytesUploaded int64 // 已上传分片的总字节数
+}
+
+// ProgressFunc 进度回调，uploaded为已完成的字节数，total未知时为-1
+type ProgressFunc func(uploaded, total int64)
+
+// Options 分片上传参数
+type Options struct {
+	PartSize     int64        // 分片大小，为0时按内存上限自动选择，见 AutoPartSize
+	Threshold    int64        // 分片上传阈值，为0时按内存上限自动选择，见 AutoThreshold
+	Workers      int          // 并发上传的分片数，默认4
+	Progress     ProgressFunc // 进度回调，调用是串行的
+	RetryPerPart int          // 单个分片失败后的重试次数
+
+	// VerifyCompletion 合并后查询对象信息，核对大小与分片总大小一致，ETag为"<哈希>-<分片数>"格式时同时核对分片数与合成ETag，
+	// 不一致时返回 config.ErrVerificationFailed；需要后端实现 ObjectInfoGetter，会多一次HEAD请求
+	VerifyCompletion bool
+}
+
+// ObjectInfoGetter 可查询对象信息的后端，用于 Options.VerifyCompletion
+type ObjectInfoGetter interface {
+	GetObjectInfo(key string) (config.ObjectInfo, error)
+}
+
+// MultipartUploadOrchestrator 分片上传编排器
+// 负责切分、并发上传分片、汇总进度，失败时中止分片上传以免残留分片产生费用
+type MultipartUploadOrchestrator struct {
+	backend Backend
+	opts    Options
+}
+
+// NewOrchestrator 创建分片上传编排器
+func NewOrchestrator(backend Backend, opts Options) *MultipartUploadOrchestrator {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = AutoPartSize(opts.Workers)
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = AutoThreshold()
+	}
+	if opts.RetryPerPart < 0 {
+		opts.RetryPerPart = 0
+	}
+	return &MultipartUploadOrchestrator{backend: backend, opts: opts}
+}
+
+// UseMultipart 判断大小为size的内容是否应使用分片上传，大小未知(小于0)或不小于阈值时返回true
+func (m *MultipartUploadOrchestrator) UseMultipart(size int64) bool {
+	return size < 0 || size >= m.opts.Threshold
+}
+
+// Upload 从r读取内容并分片上传到key，size未知时传-1
+// 同时占用的内存约为 (Workers+1)*PartSize
+// 合并成功前的任何失败(包括ctx取消与panic)都会中止分片上传，不会残留已上传的分片
+func (m *MultipartUploadOrchestrator) Upload(ctx context.Context, key string, r io.Reader, size int64) (err error) {
+	getter, ok := m.backend.(ObjectInfoGetter)
+	if m.opts.VerifyCompletion && !ok {
+		return fmt.Errorf("backend cannot verify completed uploads: %w", config.ErrNotSupported)
+	}
+
+	uploadID, err := m.backend.InitiateMultipart(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// ctx可能已被取消，使用独立的context清理已上传的分片
+		if abortErr := m.backend.AbortMultipart(context.Background(), key, uploadID); abortErr != nil && err != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort multipart upload: %w", abortErr))
+		}
+	}()
+
+	parts, err := m.uploadParts(ctx, key, uploadID, r, size)
+	if err != nil {
+		return err
+	}
+	// 分片全部完成后ctx才被取消时同样放弃合并
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if err = m.backend.CompleteMultipart(ctx, key, uploadID, parts); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	completed = true
+
+	// 校验失败时对象已经合并，不会删除，由调用方决定如何处理
+	if m.opts.VerifyCompletion {
+		return verifyCompletion(getter, key, parts)
+	}
+	return nil
+}
+
+// uploadParts 切分并并发上传所有分片，返回按序号排序的分片列表
+func (m *MultipartUploadOrchestrator) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, size int64) ([]Part, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []Part
+		firstErr error
+		uploaded int64
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	sem := make(chan struct{}, m.opts.Workers)
+	for number := 1; ; number++ {
+		buf := make([]byte, m.opts.PartSize)
+		n, readErr := io.ReadFull(r, buf)
+		last := readErr == io.EOF || readErr == io.ErrUnexpectedEOF
+		if readErr != nil && !last {
+			fail(fmt.Errorf("failed to read part %d: %w", number, readErr))
+			break
+		}
+		// 内容为空时仍上传一个空分片，保证对象被创建
+		if n == 0 && readErr == io.EOF && number > 1 {
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+		}
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+
+		wg.Add(1)
+		go func(number int, data []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			part, err := m.uploadPart(ctx, key, uploadID, number, data)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			parts = append(parts, part)
+			uploaded += part.Size
+			if m.opts.Progress != nil {
+				m.opts.Progress(uploaded, size)
+			}
+		}(number, buf[:n])
+
+		if last {
+			break
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// uploadPart 上传单个分片，失败时按 RetryPerPart 重试
+func (m *MultipartUploadOrchestrator) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (Part, error) {
+	var err error
+	for attempt := 0; attempt <= m.opts.RetryPerPart; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Part{}, ctxErr
+		}
+
+		var part Part
+		part, err = m.backend.UploadPart(ctx, key, uploadID, number, bytes.NewReader(data), int64(len(data)))
+		if err == nil {
+			part.Number = number
+			part.Size = int64(len(data))
+			return part, nil
+		}
+	}
+	return Part{}, fmt.Errorf("failed to upload part %d: %w", number, err)
+}
@@ -0,0 +1,270 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 11:20:05
+ * Description: 分片上传编排器测试
+ */
+package multipart
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// memoryBackend 内存中的分片上传后端
+type memoryBackend struct {
+	mu        sync.Mutex
+	parts     map[int][]byte
+	objects   map[string][]byte
+	aborted   bool
+	uploads   map[string]string // 未完成的分片上传，uploadID到key
+	failPart  int               // 该分片号总是失败
+	failTimes int               // 每个分片前几次失败
+	attempts  map[int]int
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{parts: map[int][]byte{}, objects: map[string][]byte{}, attempts: map[int]int{}, uploads: map[string]string{}}
+}
+
+func (b *memoryBackend) InitiateMultipart(ctx context.Context, key string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads["upload-1"] = key
+	return "upload-1", nil
+}
+
+func (b *memoryBackend) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Part{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts[number]++
+	if number == b.failPart || b.attempts[number] <= b.failTimes {
+		return Part{}, errors.New("part failed")
+	}
+	b.parts[number] = data
+	return Part{ETag: fmt.Sprintf("etag-%d", number)}, nil
+}
+
+func (b *memoryBackend) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error {
+	var buf bytes.Buffer
+	for _, p := range parts {
+		buf.Write(b.parts[p.Number])
+	}
+	b.objects[key] = buf.Bytes()
+	delete(b.uploads, uploadID)
+	return nil
+}
+
+func (b *memoryBackend) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.aborted = true
+	delete(b.uploads, uploadID)
+	return nil
+}
+
+func (b *memoryBackend) ListIncompleteMultipartUploads(ctx context.Context, prefix string) ([]IncompleteUpload, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var uploads []IncompleteUpload
+	for id, key := range b.uploads {
+		uploads = append(uploads, IncompleteUpload{Key: key, UploadID: id})
+	}
+	return uploads, nil
+}
+
+// cancelReader 读取超过after字节后取消ctx，模拟上传中途被取消
+type cancelReader struct {
+	r      io.Reader
+	after  int
+	read   int
+	cancel context.CancelFunc
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	if c.read > c.after {
+		c.cancel()
+	}
+	return n, err
+}
+
+// 测试分片上传后内容按序拼接，进度到达总大小
+func TestOrchestratorUpload(t *testing.T) {
+	backend := newMemoryBackend()
+	content := bytes.Repeat([]byte("0123456789"), 105)
+
+	var last int64
+	o := NewOrchestrator(backend, Options{
+		PartSize: 100,
+		Workers:  3,
+		Progress: func(uploaded, total int64) { last = uploaded },
+	})
+
+	err := o.Upload(context.Background(), "a.bin", bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+	assert.Equal(t, content, backend.objects["a.bin"])
+	assert.Len(t, backend.parts, 11)
+	assert.Equal(t, int64(len(content)), last)
+	assert.False(t, backend.aborted)
+}
+
+// 测试分片失败时重试
+func TestOrchestratorRetry(t *testing.T) {
+	backend := newMemoryBackend()
+	backend.failTimes = 1
+	content := bytes.Repeat([]byte("x"), 250)
+
+	o := NewOrchestrator(backend, Options{PartSize: 100, RetryPerPart: 1})
+	err := o.Upload(context.Background(), "b.bin", bytes.NewReader(content), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, content, backend.objects["b.bin"])
+}
+
+// 测试分片失败时中止上传
+func TestOrchestratorAbortOnFailure(t *testing.T) {
+	backend := newMemoryBackend()
+	backend.failPart = 2
+	content := bytes.Repeat([]byte("x"), 500)
+
+	o := NewOrchestrator(backend, Options{PartSize: 100, Workers: 2, RetryPerPart: 2})
+	err := o.Upload(context.Background(), "c.bin", bytes.NewReader(content), int64(len(content)))
+	assert.Error(t, err)
+	assert.True(t, backend.aborted)
+	assert.NotContains(t, backend.objects, "c.bin")
+	assert.Equal(t, 3, backend.attempts[2])
+}
+
+// 测试上传中途取消ctx时中止分片上传，不残留未完成的上传
+func TestOrchestratorAbortOnCancel(t *testing.T) {
+	backend := newMemoryBackend()
+	content := bytes.Repeat([]byte("x"), 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancelReader{r: bytes.NewReader(content), after: 250, cancel: cancel}
+
+	o := NewOrchestrator(backend, Options{PartSize: 100, Workers: 2})
+	err := o.Upload(ctx, "d.bin", r, int64(len(content)))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, backend.aborted)
+	assert.NotContains(t, backend.objects, "d.bin")
+
+	uploads, err := backend.ListIncompleteMultipartUploads(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, uploads)
+}
+
+// 测试按内存上限自动选择分片大小与阈值
+func TestAutoTune(t *testing.T) {
+	defer func(f func() int64) { memoryLimit = f }(memoryLimit)
+
+	// 未设置内存上限时使用默认值
+	memoryLimit = func() int64 { return 0 }
+	assert.Equal(t, DefaultPartSize, AutoPartSize(4))
+	assert.Equal(t, DefaultThreshold, AutoThreshold())
+
+	// 1GB上限、4并发：1GB/4/5 约51.2MB，按MB取整
+	memoryLimit = func() int64 { return 1 << 30 }
+	assert.Equal(t, int64(51<<20), AutoPartSize(4))
+	assert.Equal(t, int64(4*51<<20), AutoThreshold())
+
+	// 上下限
+	memoryLimit = func() int64 { return 64 << 20 }
+	assert.Equal(t, minAutoPartSize, AutoPartSize(4))
+	memoryLimit = func() int64 { return 64 << 30 }
+	assert.Equal(t, maxAutoPartSize, AutoPartSize(4))
+
+	// 显式设置优先
+	o := NewOrchestrator(newMemoryBackend(), Options{PartSize: 100, Threshold: 1000})
+	assert.False(t, o.UseMultipart(999))
+	assert.True(t, o.UseMultipart(1000))
+	assert.True(t, o.UseMultipart(-1))
+}
+
+// inspectingBackend 可查询合并后对象信息的内存后端，etag与sizeDelta用于模拟服务端不一致
+type inspectingBackend struct {
+	*memoryBackend
+	etag      string
+	sizeDelta int64
+}
+
+func (b *inspectingBackend) GetObjectInfo(key string) (config.ObjectInfo, error) {
+	data, ok := b.objects[key]
+	if !ok {
+		return config.ObjectInfo{}, config.ErrNotFound
+	}
+	return config.ObjectInfo{Key: key, Size: int64(len(data)) + b.sizeDelta, ETag: b.etag}, nil
+}
+
+// 测试合并后的校验
+func TestOrchestratorVerifyCompletion(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 25)
+	upload := func(backend Backend) error {
+		o := NewOrchestrator(backend, Options{PartSize: 100, VerifyCompletion: true})
+		return o.Upload(context.Background(), "a.bin", bytes.NewReader(content), int64(len(content)))
+	}
+
+	backend := &inspectingBackend{memoryBackend: newMemoryBackend()}
+	assert.NoError(t, upload(backend))
+
+	backend = &inspectingBackend{memoryBackend: newMemoryBackend(), sizeDelta: -1}
+	assert.ErrorIs(t, upload(backend), config.ErrVerificationFailed)
+	assert.False(t, backend.aborted)
+
+	// 分片数不符
+	backend = &inspectingBackend{memoryBackend: newMemoryBackend(), etag: `"abc-2"`}
+	assert.ErrorIs(t, upload(backend), config.ErrVerificationFailed)
+
+	// 后端不支持查询时不开始上传
+	plain := newMemoryBackend()
+	assert.ErrorIs(t, upload(plain), config.ErrNotSupported)
+	assert.Empty(t, plain.uploads)
+}
+
+// 测试分片ETag为MD5时的合成ETag
+func TestCompositeETag(t *testing.T) {
+	a, b := md5.Sum([]byte("a")), md5.Sum([]byte("b"))
+	parts := []Part{
+		{Number: 1, ETag: `"` + hex.EncodeToString(a[:]) + `"`, Size: 1},
+		{Number: 2, ETag: strings.ToUpper(hex.EncodeToString(b[:])), Size: 1},
+	}
+	sum := md5.Sum(append(a[:], b[:]...))
+	want := hex.EncodeToString(sum[:])
+
+	etag, ok := compositeETag(parts)
+	assert.True(t, ok)
+	assert.Equal(t, want, etag)
+
+	objects := map[string]config.ObjectInfo{
+		"ok":  {Size: 2, ETag: `"` + strings.ToUpper(want) + `-2"`},
+		"bad": {Size: 2, ETag: `"` + strings.Repeat("0", 32) + `-2"`},
+	}
+	getter := objectInfoFunc(func(key string) (config.ObjectInfo, error) { return objects[key], nil })
+	assert.NoError(t, verifyCompletion(getter, "ok", parts))
+	assert.ErrorIs(t, verifyCompletion(getter, "bad", parts), config.ErrVerificationFailed)
+
+	// 分片ETag不是MD5时只核对大小与分片数
+	_, ok = compositeETag([]Part{{ETag: "etag-1"}})
+	assert.False(t, ok)
+}
+
+type objectInfoFunc func(key string) (config.ObjectInfo, error)
+
+func (f objectInfoFunc) GetObjectInfo(key string) (config.ObjectInfo, error) { return f(key) }
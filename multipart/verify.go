@@ -0,0 +1,63 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 05:41:19
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 05:41:19
+ * Description: 分片合并后的大小与ETag校验
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package multipart
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// verifyCompletion 查询合并后的对象，核对大小与分片总大小一致
+// 分片上传的ETag不是内容的MD5：OSS与COS为各分片MD5拼接后再取MD5并加上"-<分片数>"，七牛云为自有哈希，
+// 因此只在ETag带有分片数后缀时核对分片数，分片ETag均为MD5时再核对合成值
+func verifyCompletion(getter ObjectInfoGetter, key string, parts []Part) error {
+	info, err := getter.GetObjectInfo(key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", config.ErrVerificationFailed, err)
+	}
+
+	var size int64
+	for _, p := range parts {
+		size += p.Size
+	}
+	if info.Size != size {
+		return fmt.Errorf("%w: size %d, want %d", config.ErrVerificationFailed, info.Size, size)
+	}
+
+	etag := strings.ToLower(strings.Trim(info.ETag, `"`))
+	hash, count, ok := strings.Cut(etag, "-")
+	if !ok {
+		return nil
+	}
+	if n, err := strconv.Atoi(count); err != nil || n != len(parts) {
+		return fmt.Errorf("%w: etag %s, want %d parts", config.ErrVerificationFailed, info.ETag, len(parts))
+	}
+	if want, ok := compositeETag(parts); ok && hash != want {
+		return fmt.Errorf("%w: etag %s, want %s-%d", config.ErrVerificationFailed, info.ETag, want, len(parts))
+	}
+	return nil
+}
+
+// compositeETag 按各分片MD5计算合成ETag的哈希部分，分片ETag不是MD5时返回false
+func compositeETag(parts []Part) (string, bool) {
+	h := md5.New()
+	for _, p := range parts {
+		sum, err := hex.DecodeString(strings.Trim(p.ETag, `"`))
+		if err != nil || len(sum) != md5.Size {
+			return "", false
+		}
+		h.Write(sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
@@ -0,0 +1,141 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 03:40:26
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 03:40:26
+ * Description: 七牛云存储空间信息与用量统计查询
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// QiniuBucketInfo 存储空间基本信息
+type QiniuBucketInfo struct {
+	Name      string
+	Region    string // 存储区域，如z0
+	Private   bool   // 是否为私有空间
+	CreatedAt time.Time
+}
+
+// QiniuBucketStats 存储空间在统计区间内的用量
+type QiniuBucketStats struct {
+	StorageBytes  int64 // 区间内最后一天的存储量(字节)
+	ObjectCount   int64 // 区间内最后一天的文件数
+	OutboundBytes int64 // 区间内源站外网流出流量合计(字节)，不含CDN流量
+}
+
+// statHost 七牛云数据统计接口地址
+var statHost = "https://api.qiniuapi.com"
+
+// GetBucketInfo 查询存储空间的区域、访问权限与创建时间
+func (h *qiniuUploader) GetBucketInfo(ctx context.Context) (QiniuBucketInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return QiniuBucketInfo{}, err
+	}
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	info, err := bucketManager.GetBucketInfo(h.bucket)
+	if err != nil {
+		return QiniuBucketInfo{}, fmt.Errorf("查询七牛云存储空间信息失败: %w", err)
+	}
+	return QiniuBucketInfo{
+		Name:      h.bucket,
+		Region:    info.Region,
+		Private:   info.Private == 1,
+		CreatedAt: info.Ctime,
+	}, nil
+}
+
+// GetBucketStats 按天查询存储空间在[startDate, endDate]区间内的存储量、文件数与外网流出流量
+// 统计数据有延迟，当天的数据可能不完整
+func (h *qiniuUploader) GetBucketStats(ctx context.Context, startDate, endDate time.Time) (QiniuBucketStats, error) {
+	if endDate.Before(startDate) {
+		return QiniuBucketStats{}, errors.New("统计结束时间不能早于开始时间")
+	}
+	query := url.Values{
+		"bucket": {h.bucket},
+		"begin":  {startDate.Format("20060102150405")},
+		"end":    {endDate.Format("20060102150405")},
+		"g":      {"day"},
+	}
+
+	var stats QiniuBucketStats
+	var series struct {
+		Datas []int64 `json:"datas"`
+	}
+	if err := h.getStat(ctx, "/v6/space", query, &series); err != nil {
+		return stats, err
+	}
+	if n := len(series.Datas); n > 0 {
+		stats.StorageBytes = series.Datas[n-1]
+	}
+
+	series.Datas = nil
+	if err := h.getStat(ctx, "/v6/count", query, &series); err != nil {
+		return stats, err
+	}
+	if n := len(series.Datas); n > 0 {
+		stats.ObjectCount = series.Datas[n-1]
+	}
+
+	flowQuery := url.Values{
+		"begin":   query["begin"],
+		"end":     query["end"],
+		"g":       query["g"],
+		"select":  {"flow"},
+		"$bucket": {h.bucket},
+		"$src":    {"origin"},
+	}
+	var flows []struct {
+		Values struct {
+			Flow int64 `json:"flow"`
+		} `json:"values"`
+	}
+	if err := h.getStat(ctx, "/v6/blob_io", flowQuery, &flows); err != nil {
+		return stats, err
+	}
+	for _, f := range flows {
+		stats.OutboundBytes += f.Values.Flow
+	}
+	return stats, nil
+}
+
+// getStat 以管理凭证请求数据统计接口并解析JSON响应
+func (h *qiniuUploader) getStat(ctx context.Context, path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statHost+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("创建统计请求失败: %v", err)
+	}
+	if err := h.mac.AddToken(auth.TokenQBox, req); err != nil {
+		return fmt.Errorf("签名统计请求失败: %v", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("查询七牛云用量统计失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("查询七牛云用量统计失败: %s %s", resp.Status, e.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析七牛云用量统计失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 22:24:52
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 22:24:52
+ * Description: 七牛云图片基本信息查询
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// QiniuImageInfo 七牛云imageInfo接口返回的图片基本信息
+type QiniuImageInfo struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Format      string `json:"format"`      // 图片格式，如png、jpeg、gif
+	ColorModel  string `json:"colorModel"`  // 颜色模型，如ycbcr、nrgba
+	FrameNumber int    `json:"frameNumber"` // 帧数，仅gif等多帧图片返回
+}
+
+// GetImageInfo 通过数据处理接口imageInfo查询图片的尺寸、格式等信息，文件不存在时返回 config.ErrNotFound
+// 请求地址带有签名，私有空间同样适用；非图片文件返回错误
+func (h *qiniuUploader) GetImageInfo(ctx context.Context, key string) (QiniuImageInfo, error) {
+	if h.domain == "" {
+		return QiniuImageInfo{}, errors.New("未配置访问域名")
+	}
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return QiniuImageInfo{}, err
+	}
+
+	deadline := time.Now().Add(time.Hour).Unix()
+	u := storage.MakePrivateURLv2WithQueryString(h.mac, "https://"+h.domain, key, "imageInfo", deadline)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return QiniuImageInfo{}, fmt.Errorf("创建图片信息请求失败: %v", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return QiniuImageInfo{}, fmt.Errorf("查询图片信息失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return QiniuImageInfo{}, config.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		if e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return QiniuImageInfo{}, fmt.Errorf("查询图片信息失败: HTTP %d %s", resp.StatusCode, e.Error)
+	}
+
+	var info QiniuImageInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return QiniuImageInfo{}, fmt.Errorf("解析图片信息失败: %v", err)
+	}
+	return info, nil
+}
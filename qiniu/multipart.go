@@ -0,0 +1,116 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 11:45:30
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 11:45:30
+ * Description: 七牛云分片上传(v2)，实现 multipart.Backend
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/multipart"
+)
+
+var _ multipart.Backend = (*qiniuUploader)(nil)
+
+// InitiateMultipart 初始化分片上传
+func (h *qiniuUploader) InitiateMultipart(ctx context.Context, key string) (string, error) {
+	resumeUploader, upHost, err := h.resumeUploader()
+	if err != nil {
+		return "", err
+	}
+
+	var ret storage.InitPartsRet
+	if err := resumeUploader.InitParts(ctx, h.getUpToken(""), upHost, h.bucket, key, true, &ret); err != nil {
+		return "", err
+	}
+	return ret.UploadID, nil
+}
+
+// UploadPart 上传单个分片
+func (h *qiniuUploader) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (multipart.Part, error) {
+	resumeUploader, upHost, err := h.resumeUploader()
+	if err != nil {
+		return multipart.Part{}, err
+	}
+
+	var ret storage.UploadPartsRet
+	err = resumeUploader.UploadParts(ctx, h.getUpToken(""), upHost, h.bucket, key, true, uploadID, int64(number), "", &ret, r, int(size))
+	if err != nil {
+		return multipart.Part{}, err
+	}
+	return multipart.Part{Number: number, ETag: ret.Etag, Size: size}, nil
+}
+
+// CompleteMultipart 合并分片
+func (h *qiniuUploader) CompleteMultipart(ctx context.Context, key, uploadID string, parts []multipart.Part) error {
+	resumeUploader, upHost, err := h.resumeUploader()
+	if err != nil {
+		return err
+	}
+
+	extra := &storage.RputV2Extra{}
+	for _, p := range parts {
+		extra.Progresses = append(extra.Progresses, storage.UploadPartInfo{Etag: p.ETag, PartNumber: int64(p.Number)})
+	}
+	ret := storage.PutRet{}
+	return resumeUploader.CompleteParts(ctx, h.getUpToken(""), upHost, &ret, h.bucket, key, true, uploadID, extra)
+}
+
+// AbortMultipart 七牛云SDK未提供中止接口，未完成的分片会在过期后由服务端自动清理
+func (h *qiniuUploader) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	return nil
+}
+
+// resumeUploader 创建分片上传对象并获取上传域名
+func (h *qiniuUploader) resumeUploader() (*storage.ResumeUploaderV2, string, error) {
+	resumeUploader := storage.NewResumeUploaderV2Ex(&h.cfg, h.sdkClient)
+	upHost, err := resumeUploader.UpHost(h.mac.AccessKey, h.bucket)
+	if err != nil {
+		return nil, "", err
+	}
+	return resumeUploader, upHost, nil
+}
+
+// UploadStream 从r流式上传大小未知的内容到key，以分片上传写入，返回的 UploadResult.Path 为文件访问URL
+// key为空时按 WithFilename 指定的文件名生成，两者都为空时返回 ErrInvalidKey
+// ctx取消、超时或读取失败(如 UploadWriter.Abort)时中止分片上传，不会生成对象，也不会残留已上传的分片；
+// 大小限制、扫描、审计与 WithResult 照常生效，请求头、元数据与存储类型等选项不会应用到分片上传
+func (h *qiniuUploader) UploadStream(ctx context.Context, key string, r io.Reader, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	if o.Key != "" || o.Filename == "" {
+		key, err = keygen.PolicyKey(o.Key, h.keyCharPolicy)
+	} else {
+		// 未指定key时按文件名生成，content-addressed策略需要预先读取全部内容，返回错误
+		key, err = h.generateUniqueKey(o.Filename, o.ContentType)
+		key = h.keyCharPolicy.Apply(key)
+	}
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+
+	result := config.UploadResult{RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	r, done := audit.Wrap(r, -1, o)
+	ctx, cancel := o.Context(-1)
+	defer cancel()
+	if err := multipart.NewOrchestrator(h, multipart.Options{}).Upload(ctx, key, r, -1); err != nil {
+		return config.UploadResult{}, fmt.Errorf("七牛云上传 %s 失败: %w", key, withRequestID(err))
+	}
+	done(key)
+	result.Path = h.getFileURL(key)
+	return result, nil
+}
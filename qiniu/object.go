@@ -0,0 +1,398 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 14:25:03
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 14:25:03
+ * Description: 七牛云对象管理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// Copy 服务端复制文件，目标已存在时覆盖
+// 七牛云SDK的复制接口不支持context，仅在调用前检查ctx是否已取消
+func (h *qiniuUploader) Copy(ctx context.Context, srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	if err := bucketManager.Copy(h.bucket, srcKey, h.bucket, dstKey, true); err != nil {
+		if isNotFound(err) {
+			return config.ErrNotFound
+		}
+		return fmt.Errorf("复制七牛云文件失败: %w", err)
+	}
+	return nil
+}
+
+// Move 移动文件，目标已存在时返回错误
+func (h *qiniuUploader) Move(srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	if err := bucketManager.Move(h.bucket, srcKey, h.bucket, dstKey, false); err != nil {
+		return fmt.Errorf("移动七牛云文件失败: %w", err)
+	}
+	return nil
+}
+
+// GetObjectInfo 获取文件信息，文件不存在时返回 config.ErrNotFound
+// 七牛云不返回ETag，这里以文件Hash代替
+func (h *qiniuUploader) GetObjectInfo(key string) (config.ObjectInfo, error) {
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	fileInfo, err := bucketManager.Stat(h.bucket, key)
+	if err != nil {
+		if isNotFound(err) {
+			return config.ObjectInfo{}, config.ErrNotFound
+		}
+		return config.ObjectInfo{}, fmt.Errorf("获取七牛云文件信息失败: %w", err)
+	}
+
+	info := config.ObjectInfo{
+		Key:           key,
+		Size:          fileInfo.Fsize,
+		ContentType:   fileInfo.MimeType,
+		ETag:          fileInfo.Hash,
+		LastModified:  storage.ParsePutTime(fileInfo.PutTime),
+		StorageClass:  storageClassOf(fileInfo.Type),
+		CustomHeaders: make(map[string]string, len(fileInfo.MetaData)),
+	}
+	for name, value := range fileInfo.MetaData {
+		info.CustomHeaders[strings.ToLower(name)] = value
+	}
+	info.UploadedAt = config.ParseUploadedAt(info.CustomHeaders[config.UploadedAtMeta], info.LastModified)
+	return info, nil
+}
+
+// Size 返回文件大小，文件不存在时返回 config.ErrNotFound
+func (h *qiniuUploader) Size(key string) (int64, error) {
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	fileInfo, err := bucketManager.Stat(h.bucket, key)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, config.ErrNotFound
+		}
+		return 0, fmt.Errorf("获取七牛云文件信息失败: %w", err)
+	}
+	return fileInfo.Fsize, nil
+}
+
+// Exists 判断文件是否存在
+func (h *qiniuUploader) Exists(key string) (bool, error) {
+	_, err := h.GetObjectInfo(h.keyCharPolicy.Apply(key))
+	if errors.Is(err, config.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List 列出前缀下的所有文件key，前缀按原样匹配
+// 配置了 KeySeparator 时生成的key需以分隔后的形式查询，如 2026_10_，WithKey 指定的含/的key仍按/查询
+func (h *qiniuUploader) List(prefix string) ([]string, error) {
+	var keys []string
+	err := h.walk(context.Background(), prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Count 统计前缀下的文件数量，七牛云没有计数接口，逐页列举计数，耗时与文件数成正比
+func (h *qiniuUploader) Count(ctx context.Context, prefix string) (int64, error) {
+	var count int64
+	err := h.walk(ctx, prefix, func(string) { count++ })
+	return count, err
+}
+
+// walk 逐页列举前缀下的文件，每页请求前检查ctx
+func (h *qiniuUploader) walk(ctx context.Context, prefix string, fn func(key string)) error {
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+
+	marker := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ret, hasNext, err := bucketManager.ListFilesWithContext(ctx, h.bucket, storage.ListInputOptionsPrefix(prefix),
+			storage.ListInputOptionsMarker(marker), storage.ListInputOptionsLimit(qiniuBatchLimit))
+		if err != nil {
+			return fmt.Errorf("列举七牛云文件失败: %w", err)
+		}
+		for _, entry := range ret.Items {
+			fn(entry.Key)
+		}
+		// 七牛云以返回的marker为空表示列举结束，不会返回EOF标志
+		if !hasNext {
+			return nil
+		}
+		marker = ret.Marker
+	}
+}
+
+// DeletePrefix 删除前缀下的所有文件，返回删除的数量，前缀不能为空
+// 列举完成后通过批量操作接口删除，每批不超过1000个；单个文件失败不会中断，返回汇总的错误，
+// 列举期间已被删除(612)的文件不计入数量也不视为错误
+func (h *qiniuUploader) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, config.ErrInvalidPrefix
+	}
+
+	var keys []string
+	if err := h.walk(ctx, prefix, func(key string) { keys = append(keys, key) }); err != nil {
+		return 0, err
+	}
+
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	deleted := 0
+	var errs []error
+	for start := 0; start < len(keys); start += qiniuBatchLimit {
+		end := start + qiniuBatchLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		ops := make([]string, 0, len(batch))
+		for _, key := range batch {
+			ops = append(ops, storage.URIDelete(h.bucket, key))
+		}
+
+		rets, err := bucketManager.BatchWithContext(ctx, h.bucket, ops)
+		if err != nil && len(rets) == 0 {
+			errs = append(errs, fmt.Errorf("批量删除七牛云文件失败: %w", withRequestID(err)))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		for i, ret := range rets {
+			switch ret.Code {
+			case http.StatusOK:
+				deleted++
+			case 612:
+			default:
+				errs = append(errs, fmt.Errorf("%s: %s (code %d)", batch[i], ret.Data.Error, ret.Code))
+			}
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// qiniuBatchLimit 七牛云单次列举与批量操作的最大数量
+const qiniuBatchLimit = 1000
+
+// storageClassOf 将七牛云的文件存储类型转换为通用存储类型，深度归档等其他类型返回其数值
+func storageClassOf(fileType int) string {
+	for generic, t := range fileTypes {
+		if t == fileType {
+			return generic
+		}
+	}
+	return strconv.Itoa(fileType)
+}
+
+// isNotFound 判断是否为文件不存在错误(612)
+func isNotFound(err error) bool {
+	var e *storage.ErrorInfo
+	return errors.As(err, &e) && e.Code == 612
+}
+
+// Head 读取文件的前n个字节，文件小于n时返回全部内容
+// 通过绑定域名的带签名下载地址发起Range请求，公开空间同样适用
+func (h *qiniuUploader) Head(key string, n int64) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("读取长度必须大于0")
+	}
+
+	downloadURL, err := h.downloadURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建下载请求失败: %v", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载七牛云文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusNotFound:
+		return nil, config.ErrNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 空文件
+		return []byte{}, nil
+	default:
+		return nil, fmt.Errorf("下载七牛云文件失败: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, n))
+	if err != nil {
+		return nil, fmt.Errorf("读取七牛云文件失败: %v", err)
+	}
+	return data, nil
+}
+
+// DownloadRange 从offset开始读取length字节，length小于0时读取到文件末尾，length为0或offset超出文件末尾时返回空内容
+// 调用方负责关闭返回的ReadCloser
+func (h *qiniuUploader) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("读取位置不能为负数")
+	}
+	// bytes=N-(N-1)是无效范围，服务端会忽略它返回完整文件，因此不发起请求
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	downloadURL, err := h.downloadURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建下载请求失败: %v", err)
+	}
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	req.Header.Set("Range", rng)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载七牛云文件失败: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, config.ErrNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("下载七牛云文件失败: HTTP %d", resp.StatusCode)
+	}
+}
+
+// DownloadIfNoneMatch 下载完整文件并返回其ETag，etag与文件当前ETag相同时返回 ErrNotModified
+// 条件判断由访问域名(CDN或源站)完成，七牛云的ETag为文件Hash，与 GetObjectInfo 返回的ETag一致
+func (h *qiniuUploader) DownloadIfNoneMatch(ctx context.Context, key, etag string) (io.ReadCloser, string, error) {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	downloadURL, err := h.downloadURL(key)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("创建下载请求失败: %v", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", `"`+etag+`"`)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("下载七牛云文件失败: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, strings.Trim(resp.Header.Get("ETag"), `"`), nil
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, etag, config.ErrNotModified
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, "", config.ErrNotFound
+	default:
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("下载七牛云文件失败: HTTP %d", resp.StatusCode)
+	}
+}
+
+// GetDownloadURL 生成expires后过期的带签名下载地址，私有空间与公开空间均可使用
+// 签名在本地以AccessKey/SecretKey计算，不请求七牛云接口，因此不存在需要重试的网络调用
+func (h *qiniuUploader) GetDownloadURL(key string, expires time.Duration) (string, error) {
+	if h.domain == "" {
+		return "", errors.New("未配置访问域名")
+	}
+	if expires <= 0 {
+		return "", errors.New("有效期必须大于0")
+	}
+	key, err := keygen.NormalizeKey(key)
+	if err != nil {
+		return "", err
+	}
+	return h.signedURL(key, expires), nil
+}
+
+// downloadURL 生成一小时内有效的带签名下载地址，未配置访问域名(只返回key的KeyAsURL模式)时返回错误
+func (h *qiniuUploader) downloadURL(key string) (string, error) {
+	if h.domain == "" {
+		return "", errors.New("未配置访问域名")
+	}
+	return h.signedURL(key, time.Hour), nil
+}
+
+// signedURL 生成expires后过期的带签名下载地址
+func (h *qiniuUploader) signedURL(key string, expires time.Duration) string {
+	deadline := time.Now().Add(expires).Unix()
+	return storage.MakePrivateURLv2(h.mac, "https://"+h.domain, key, deadline)
+}
+
+// ErrorDetails 从err中提取七牛云返回的错误码、错误信息、请求ID与HTTP状态码，err不是七牛云服务端错误时返回false
+// 七牛云多数接口只返回HTTP状态码，此时错误码为状态码的字符串形式，如 612
+func ErrorDetails(err error) (*config.ProviderError, bool) {
+	var pe *config.ProviderError
+	if errors.As(err, &pe) {
+		return pe, true
+	}
+	var e *storage.ErrorInfo
+	if !errors.As(err, &e) {
+		return nil, false
+	}
+	code := e.ErrorCode
+	if code == "" {
+		code = strconv.Itoa(e.Code)
+	}
+	return &config.ProviderError{Provider: "qiniu", Code: code, Message: e.Err, RequestID: e.Reqid, StatusCode: e.Code, Err: err}, true
+}
+
+// withRequestID 将七牛云服务端错误(请求ID取自响应头 X-Reqid)转换为 *config.ProviderError，使错误信息带上 request_id，
+// 原始错误仍可通过 errors.As 取得；其他错误原样返回
+func withRequestID(err error) error {
+	if d, ok := ErrorDetails(err); ok {
+		return d
+	}
+	return err
+}
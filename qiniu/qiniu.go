@@ -10,19 +10,32 @@ package qiniu
 
 import (
 	"bytes"
+	"cmp"
 	"context"
-	"encoding/base64"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
 	"mime/multipart"
-	"path/filepath"
+	"net/http"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/client"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/b64"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/httplog"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/keylock"
+	"github.com/zjguoxin/gosuploader/internal/magic"
+	"github.com/zjguoxin/gosuploader/internal/mime"
+	"github.com/zjguoxin/gosuploader/internal/useragent"
 )
 
 type qiniuUploader struct {
@@ -30,50 +43,183 @@ type qiniuUploader struct {
 	cfg    storage.Config
 	bucket string
 	domain string
+
+	urlStripPrefix string // 生成访问URL时从key开头去除的目录前缀
+
+	keyStrategy     string                // 唯一文件名生成策略
+	sequence        *config.Sequence      // sequential策略的计数器
+	keyCharPolicy   *config.KeyCharPolicy // 存储key的字符策略
+	keySeparator    string                // 生成key时替换/的分隔符
+	routingRules    *config.RoutingRules  // 按文件类型选择生成key的前缀目录
+	maxBase64Length int64                 // Base64上传解码后的最大字节数
+	contentTypes    mime.Resolver         // 内容类型的确定规则
+	maxRetries      int                   // 上传遇到限流或临时错误时的最大重试次数
+	keys            keylock.Locker        // WithKey上传时的按key锁，仅在本实例内生效，不是分布式锁
+
+	httpClient *http.Client   // 下载、统计等直接发起的请求使用的客户端
+	sdkClient  *client.Client // 传给SDK的客户端，为nil时使用SDK默认客户端(测试中直接构造时)
+}
+
+// limits 七牛云的上传限制：表单上传最大1GB，分片上传v2分片1MB~1GB，最多10000片
+var limits = config.ProviderLimits{
+	MaxSinglePutSize: 1 << 30,
+	MinPartSize:      1 << 20,
+	MaxPartSize:      1 << 30,
+	MaxParts:         10000,
+}
+
+// ProviderLimits 返回七牛云的上传限制
+func (h *qiniuUploader) ProviderLimits() config.ProviderLimits {
+	return limits
 }
 
 func New(cfg config.QiniuConfig) (*qiniuUploader, error) {
 	if cfg.AccessKey == "" || cfg.SecretKey == "" || cfg.Bucket == "" {
 		return nil, errors.New("qiniu config is incomplete")
 	}
+	if cfg.Domain == "" && !cfg.KeyAsURL {
+		return nil, errors.New("未配置访问域名(Domain)，只需要返回存储key时请设置 KeyAsURL")
+	}
+	if !keygen.ValidStrategy(cfg.KeyStrategy) {
+		return nil, fmt.Errorf("不支持的key生成策略: %s", cfg.KeyStrategy)
+	}
+	if !keygen.ValidSeparator(cfg.KeySeparator) {
+		return nil, fmt.Errorf("无效的key分隔符: %q", cfg.KeySeparator)
+	}
+	if cfg.KeyStrategy == config.KeyStrategySequential && !cfg.Sequence.Persistent() {
+		return nil, errors.New("sequential策略需要配置带 Store 的 Sequence，否则重启后编号从1开始并覆盖已有对象")
+	}
+	if err := cfg.RoutingRules.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.MaxRetries < 0 {
+		return nil, errors.New("重试次数不能为负数")
+	}
 
 	mac := qbox.NewMac(cfg.AccessKey, cfg.SecretKey)
-	Region, _ := storage.GetZone(cfg.AccessKey, cfg.Bucket)
+	Region, err := zone(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// SDK请求与直接发起的请求使用同一个客户端；SDK的User-Agent是全局变量，由Transport按上传器设置
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSSkipVerify {
+		log.Println(config.TLSSkipVerifyWarning)
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	var rt http.RoundTripper = transport
+	if cfg.DebugHTTP {
+		rt = httplog.Wrap(transport, cfg.Logger)
+	}
+	rt = useragent.Wrap(rt, cmp.Or(cfg.UserAgent, config.DefaultUserAgent))
+	httpClient := &http.Client{Transport: rt}
+	sdkClient := &client.Client{Client: httpClient}
 
 	return &qiniuUploader{
 		mac:    mac,
 		cfg:    storage.Config{Region: Region, Zone: Region, UseHTTPS: true, UseCdnDomains: false},
 		bucket: cfg.Bucket,
 		domain: cfg.Domain,
+
+		urlStripPrefix: cfg.URLStripPrefix,
+
+		keyStrategy:     cfg.KeyStrategy,
+		sequence:        cfg.Sequence,
+		keyCharPolicy:   cfg.KeyCharPolicy,
+		keySeparator:    cfg.KeySeparator,
+		routingRules:    cfg.RoutingRules,
+		maxBase64Length: cfg.MaxBase64Length,
+		contentTypes:    mime.Resolver{Overrides: cfg.ContentTypeOverrides, DetectMIME: cfg.DetectMIME},
+		maxRetries:      cfg.MaxRetries,
+
+		httpClient: httpClient,
+		sdkClient:  sdkClient,
 	}, nil
 }
 
-// getUpToken 获取上传凭证
-func (h *qiniuUploader) getUpToken() string {
+// zones ZoneID对应的预置上传区域
+var zones = map[string]storage.Zone{
+	"z0":  storage.Zone_z0,
+	"z1":  storage.Zone_z1,
+	"z2":  storage.Zone_z2,
+	"na0": storage.Zone_na0,
+	"as0": storage.Zone_as0,
+}
+
+// zone 确定上传区域，指定了ZoneID时使用预置区域，否则通过API自动查询
+func zone(cfg config.QiniuConfig) (*storage.Zone, error) {
+	if cfg.ZoneID == "" {
+		// 查询失败时保持原有行为，由SDK在上传时再解析
+		z, _ := storage.GetZone(cfg.AccessKey, cfg.Bucket)
+		return z, nil
+	}
+	z, ok := zones[cfg.ZoneID]
+	if !ok {
+		return nil, fmt.Errorf("不支持的ZoneID: %s", cfg.ZoneID)
+	}
+	return &z, nil
+}
+
+// fileTypes 通用存储类型对应的七牛云文件存储类型
+var fileTypes = map[string]int{
+	config.StorageClassStandard:         0,
+	config.StorageClassInfrequentAccess: 1,
+	config.StorageClassArchive:          2,
+}
+
+// getUpToken 获取上传凭证，storageClass为空时使用标准存储
+func (h *qiniuUploader) getUpToken(storageClass string) string {
 	// 上传策略
 	putPolicy := storage.PutPolicy{
-		Scope: h.bucket,
+		Scope:    h.bucket,
+		FileType: fileTypes[storageClass],
 	}
 	// 设置凭证有效期
 	putPolicy.Expires = 3600 // 1小时
 	return putPolicy.UploadToken(h.mac)
 }
 
-// generateUniqueKey 生成唯一的文件key
-func (h *qiniuUploader) generateUniqueKey(originalName string) string {
-	ext := filepath.Ext(originalName)
-	timestamp := time.Now().UnixNano()
-	randomStr := uuid.New().String()[:8]
-	return fmt.Sprintf("%d_%s%s", timestamp, randomStr, ext)
+// objectKey 确定文件key，优先使用上传选项指定的key
+// 指定的key经过规范化校验，不合法时返回 config.ErrInvalidKey
+// content-addressed策略下读取r按内容摘要生成key，返回从头读取同一内容的Reader
+func (h *qiniuUploader) objectKey(originalName string, r io.Reader, o *config.UploadOptions) (string, io.Reader, error) {
+	if o.Key != "" {
+		key, err := keygen.PolicyKey(o.Key, h.keyCharPolicy)
+		return key, r, err
+	}
+	if h.keyStrategy == config.KeyStrategyContentAddressed {
+		return keygen.ContentKey(o.FilenameOr(originalName), r)
+	}
+	key, err := h.generateUniqueKey(o.FilenameOr(originalName), o.ContentType)
+	return h.keyCharPolicy.Apply(key), r, err
+}
+
+// generateUniqueKey 生成唯一的文件key，contentType为空时按扩展名推断，用于匹配路由规则
+func (h *qiniuUploader) generateUniqueKey(originalName, contentType string) (string, error) {
+	// 七牛云默认不保留原文件名，仅在UUID与顺序编号策略下与其他后端一致
+	strategy := keygen.StrategyTimestampRandom
+	if h.keyStrategy == config.KeyStrategyUUID || h.keyStrategy == config.KeyStrategySequential {
+		strategy = h.keyStrategy
+	}
+	return keygen.GenerateKey(originalName, keygen.KeygenOptions{
+		Prefix:    h.routingRules.Prefix(originalName, cmp.Or(contentType, mime.TypeByFilename(originalName))),
+		Strategy:  strategy,
+		Sequence:  h.sequence,
+		Separator: h.keySeparator,
+	})
 }
 
-// getFileURL 获取文件访问URL
+// getFileURL 获取文件访问URL，去除 URLStripPrefix；未配置Domain(KeyAsURL)时返回完整的存储key
 func (h *qiniuUploader) getFileURL(key string) string {
-	return fmt.Sprintf("https://%s/%s", h.domain, key)
+	if h.domain == "" {
+		return key
+	}
+	return fmt.Sprintf("https://%s/%s", h.domain, keygen.StripPrefix(key, h.urlStripPrefix))
 }
 
 // UploadBase64 上传Base64编码的文件
-func (h *qiniuUploader) UploadBase64(fileName string, base64Code string) (string, error) {
+func (h *qiniuUploader) UploadBase64(fileName string, base64Code string, opts ...config.UploadOption) (string, error) {
 	if fileName == "" {
 		return "", errors.New("文件名不能为空")
 	}
@@ -81,57 +227,146 @@ func (h *qiniuUploader) UploadBase64(fileName string, base64Code string) (string
 		return "", errors.New("base64编码不能为空")
 	}
 
-	// 生成唯一文件名
-	key := h.generateUniqueKey(fileName)
-
 	// 解码Base64数据
-	data, err := base64.StdEncoding.DecodeString(base64Code)
+	data, err := b64.Decode(base64Code, h.maxBase64Length)
 	if err != nil {
-		return "", fmt.Errorf("base64解码失败: %v", err)
+		return "", fmt.Errorf("base64解码失败: %w", err)
 	}
 
-	// 获取上传凭证
-	upToken := h.getUpToken()
-
-	// 创建表单上传对象
-	formUploader := storage.NewFormUploader(&h.cfg)
-	ret := storage.PutRet{}
-
-	// 上传文件
-	err = formUploader.Put(context.Background(), &ret, upToken, key, bytes.NewReader(data), int64(len(data)), nil)
-	if err != nil {
-		return "", fmt.Errorf("七牛云上传失败: %v", err)
-	}
-
-	return h.getFileURL(ret.Key), nil
+	return h.UploadBinary(fileName, data, opts...)
 }
 
 // UploadBinary 上传二进制数据
-func (h *qiniuUploader) UploadBinary(fileName string, content []byte) (string, error) {
+func (h *qiniuUploader) UploadBinary(fileName string, content []byte, opts ...config.UploadOption) (string, error) {
 	if fileName == "" {
 		return "", errors.New("文件名不能为空")
 	}
 	if len(content) == 0 {
 		return "", errors.New("文件内容不能为空")
 	}
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
 
 	// 生成唯一文件名
-	key := h.generateUniqueKey(fileName)
+	key, body, err := h.objectKey(fileName, bytes.NewReader(content), o)
+	if err != nil {
+		return "", err
+	}
+
+	ret, err := h.put(key, body, int64(len(content)), o)
+	if err != nil {
+		return "", fmt.Errorf("七牛云上传失败: %w", err)
+	}
+
+	return h.getFileURL(ret.Key), nil
+}
+
+// Ping 检查存储空间是否可访问
+func (h *qiniuUploader) Ping(ctx context.Context) error {
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	if _, _, err := bucketManager.ListFilesWithContext(ctx, h.bucket, storage.ListInputOptionsLimit(1)); err != nil {
+		return fmt.Errorf("七牛云存储空间不可访问: %w", err)
+	}
+	return nil
+}
+
+// put 表单上传数据到七牛云
+func (h *qiniuUploader) put(key string, r io.Reader, size int64, o *config.UploadOptions) (storage.PutRet, error) {
+	if err := limits.CheckSinglePut(size); err != nil {
+		return storage.PutRet{}, err
+	}
+	if o.Retention != nil {
+		return storage.PutRet{}, fmt.Errorf("七牛云不支持对象锁定保留: %w", config.ErrNotSupported)
+	}
+	if o.CustomerKey != nil {
+		return storage.PutRet{}, fmt.Errorf("七牛云不支持客户提供密钥的服务端加密: %w", config.ErrNotSupported)
+	}
+	if len(o.Tags) > 0 {
+		return storage.PutRet{}, fmt.Errorf("七牛云不支持对象标签: %w", config.ErrNotSupported)
+	}
+	if o.Key != "" {
+		defer h.keys.Lock(key)()
+	}
+	// 内容寻址的key由内容决定，文件已存在时内容相同，跳过写入
+	if o.Key == "" && h.keyStrategy == config.KeyStrategyContentAddressed {
+		if exists, err := h.Exists(key); err != nil || exists {
+			return storage.PutRet{Key: key}, err
+		}
+	}
+
+	ctx, cancel := o.Context(size)
+	defer cancel()
+
+	// 限流等可重试的错误在内容可重新读取时重试，超时时间覆盖全部尝试
+	maxRetries := h.maxRetries
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		maxRetries = 0
+	}
+	var ret storage.PutRet
+	attempt := 0
+	err := withRetry(ctx, maxRetries, func() error {
+		if attempt++; attempt > 1 {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		var err error
+		ret, err = h.putObject(ctx, key, r, size, o)
+		return err
+	})
+	return ret, withRequestID(err)
+}
+
+// putObject 执行一次表单上传
+func (h *qiniuUploader) putObject(ctx context.Context, key string, r io.Reader, size int64, o *config.UploadOptions) (storage.PutRet, error) {
+	if o.StrictTypeValidation {
+		var err error
+		if r, err = magic.Validate(r, o.FilenameOr(key), config.MagicByteRules); err != nil {
+			return storage.PutRet{}, err
+		}
+	}
+	var head []byte
+	if h.contentTypes.DetectMIME && o.ContentType == "" {
+		var err error
+		if head, r, err = mime.Peek(r); err != nil {
+			return storage.PutRet{}, err
+		}
+	}
+	contentType := h.contentTypes.ResolveContentType(o.FilenameOr(key), head, o.ContentType)
+	r, done := audit.Wrap(r, size, o)
 
 	// 获取上传凭证
-	upToken := h.getUpToken()
+	upToken := h.getUpToken(o.StorageClass)
 
 	// 创建表单上传对象
-	formUploader := storage.NewFormUploader(&h.cfg)
+	formUploader := storage.NewFormUploaderEx(&h.cfg, h.sdkClient)
 	ret := storage.PutRet{}
 
 	// 上传文件
-	err := formUploader.Put(context.Background(), &ret, upToken, key, bytes.NewReader(content), int64(len(content)), nil)
-	if err != nil {
-		return "", fmt.Errorf("七牛云上传失败: %v", err)
+	// 无法确定内容类型时由七牛云自动识别
+	var extra *storage.PutExtra
+	if contentType != "" || o.RecordUploadedAt {
+		extra = &storage.PutExtra{MimeType: contentType}
+	}
+	if o.RecordUploadedAt {
+		extra.Params = map[string]string{"x-qn-meta-" + config.UploadedAtMeta: config.FormatUploadedAt(time.Now())}
 	}
 
-	return h.getFileURL(ret.Key), nil
+	if err := formUploader.Put(ctx, &ret, upToken, key, r, size, extra); err != nil {
+		return ret, err
+	}
+	// 表单上传的响应只有hash，没有可供本地比对的校验值
+	if o.VerifyAfterUpload {
+		if err := config.VerifySize(func() (int64, error) { return h.Size(ret.Key) }, size); err != nil {
+			return ret, err
+		}
+	}
+
+	done(ret.Key)
+	return ret, nil
 }
 
 // Delete 删除七牛云文件
@@ -141,22 +376,29 @@ func (h *qiniuUploader) Delete(filePath string) error {
 	}
 
 	// 创建BucketManager
-	bucketManager := storage.NewBucketManager(h.mac, &h.cfg)
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
 
-	// 删除文件
-	err := bucketManager.Delete(h.bucket, filePath)
+	// 删除文件，按上传时的字符策略转换key
+	err := bucketManager.Delete(h.bucket, h.keyCharPolicy.Apply(filePath))
+	if isNotFound(err) {
+		return fmt.Errorf("删除七牛云文件失败: %w: %w", config.ErrNotFound, withRequestID(err))
+	}
 	if err != nil {
-		return fmt.Errorf("删除七牛云文件失败: %v", err)
+		return fmt.Errorf("删除七牛云文件失败: %w", withRequestID(err))
 	}
 
 	return nil
 }
 
 // UploadFile 上传multipart文件
-func (h *qiniuUploader) UploadFile(fileHeader *multipart.FileHeader) (string, error) {
+func (h *qiniuUploader) UploadFile(fileHeader *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
 	if fileHeader == nil {
 		return "", errors.New("文件头不能为空")
 	}
+	// 读入内存前按文件头中的大小提前拒绝
+	if err := limits.CheckSinglePut(fileHeader.Size); err != nil {
+		return "", err
+	}
 
 	// 打开文件
 	file, err := fileHeader.Open()
@@ -172,5 +414,127 @@ func (h *qiniuUploader) UploadFile(fileHeader *multipart.FileHeader) (string, er
 	}
 
 	// 使用二进制上传方法
-	return h.UploadBinary(fileHeader.Filename, fileBytes)
+	return h.UploadBinary(fileHeader.Filename, fileBytes, opts...)
+}
+
+// UploadFSFile 上传 fs.File 的内容，适用于 os.DirFS、embed.FS 等非HTTP场景
+// key为空时按文件名生成存储key，ctx取消或超时时中止上传；调用方负责关闭f
+func (h *qiniuUploader) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	file, err := fsfile.Open(f, h.contentTypes, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	o.ContentType = file.ContentType
+
+	objectKey, body, err := h.objectKey(file.Name, file.Reader, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	result := config.UploadResult{Size: file.Size, ContentType: file.ContentType, RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	ret, err := h.put(objectKey, body, file.Size, o)
+	if err != nil {
+		return config.UploadResult{}, fmt.Errorf("七牛云上传失败: %w", err)
+	}
+	result.Path = h.getFileURL(ret.Key)
+	return result, nil
+}
+
+// FetchResult 抓取远程资源的结果
+type FetchResult struct {
+	Key      string
+	Hash     string
+	MimeType string
+	Fsize    int64
+	URL      string // 文件访问URL
+	Error    error  // BatchFetchFromURLs 中该任务的错误，成功时为nil
+}
+
+// FetchTask 批量抓取的任务，Key为空时由七牛云自动分配文件key
+type FetchTask struct {
+	SrcURL string
+	Key    string
+}
+
+// batchFetchConcurrency 批量抓取时同时进行的抓取数
+const batchFetchConcurrency = 8
+
+// FetchRemoteURL 由七牛云服务端直接抓取远程资源到空间，无需经过应用服务器中转
+// key为空时由七牛云自动分配文件key
+func (h *qiniuUploader) FetchRemoteURL(ctx context.Context, remoteURL string, key string) (FetchResult, error) {
+	if remoteURL == "" {
+		return FetchResult{}, errors.New("远程资源地址不能为空")
+	}
+	if err := ctx.Err(); err != nil {
+		return FetchResult{}, err
+	}
+
+	return h.fetch(storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient), remoteURL, key)
+}
+
+// BatchFetchFromURLs 由七牛云服务端批量抓取远程资源，结果与tasks一一对应
+// 单个任务失败记录在对应结果的Error中，不影响其他任务；ctx取消后未开始的任务以ctx的错误结束，并返回该错误
+// 七牛云的批量操作接口不支持抓取，因此逐个调用抓取接口，同时进行 batchFetchConcurrency 个
+func (h *qiniuUploader) BatchFetchFromURLs(ctx context.Context, tasks []FetchTask) ([]FetchResult, error) {
+	bucketManager := storage.NewBucketManagerEx(h.mac, &h.cfg, h.sdkClient)
+	results := make([]FetchResult, len(tasks))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < batchFetchConcurrency && i < len(tasks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				task := tasks[i]
+				if err := ctx.Err(); err != nil {
+					results[i] = FetchResult{Key: task.Key, Error: err}
+					continue
+				}
+				if task.SrcURL == "" {
+					results[i] = FetchResult{Key: task.Key, Error: errors.New("远程资源地址不能为空")}
+					continue
+				}
+				res, err := h.fetch(bucketManager, task.SrcURL, task.Key)
+				if err != nil {
+					res = FetchResult{Key: task.Key, Error: err}
+				}
+				results[i] = res
+			}
+		}()
+	}
+	for i := range tasks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// fetch 抓取单个远程资源，key为空时由七牛云自动分配文件key
+func (h *qiniuUploader) fetch(bucketManager *storage.BucketManager, remoteURL, key string) (FetchResult, error) {
+	var (
+		ret storage.FetchRet
+		err error
+	)
+	if key == "" {
+		ret, err = bucketManager.FetchWithoutKey(remoteURL, h.bucket)
+	} else {
+		ret, err = bucketManager.Fetch(remoteURL, h.bucket, key)
+	}
+	if err != nil {
+		return FetchResult{}, fmt.Errorf("抓取远程资源失败: %w", err)
+	}
+
+	return FetchResult{
+		Key:      ret.Key,
+		Hash:     ret.Hash,
+		MimeType: ret.MimeType,
+		Fsize:    ret.Fsize,
+		URL:      h.getFileURL(ret.Key),
+	}, nil
 }
@@ -0,0 +1,481 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 23:52:36
+ * Description: 七牛云上传器测试，只覆盖不需要访问七牛云接口的功能
+ */
+package qiniu
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// 测试带签名下载地址在本地生成，签名可用SecretKey验证
+func TestGetDownloadURL(t *testing.T) {
+	up, err := New(config.QiniuConfig{
+		AccessKey: "ak",
+		SecretKey: "sk",
+		Bucket:    "bucket",
+		Domain:    "cdn.example.com",
+		ZoneID:    "z0",
+	})
+	assert.NoError(t, err)
+
+	signed, err := up.GetDownloadURL("/docs/a b.pdf", 10*time.Minute)
+	assert.NoError(t, err)
+	u, err := url.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "cdn.example.com", u.Host)
+	assert.Equal(t, "/docs/a b.pdf", u.Path)
+
+	deadline, err := strconv.ParseInt(u.Query().Get("e"), 10, 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(10*time.Minute).Unix(), deadline, 2)
+
+	// token为 AccessKey:签名，签名覆盖token之前的完整地址
+	unsigned, token, _ := strings.Cut(signed, "&token=")
+	assert.Equal(t, up.mac.Sign([]byte(unsigned)), token)
+	assert.True(t, strings.HasPrefix(token, "ak:"))
+
+	_, err = up.GetDownloadURL("../a.pdf", time.Minute)
+	assert.ErrorIs(t, err, config.ErrInvalidKey)
+	_, err = up.GetDownloadURL("a.pdf", 0)
+	assert.Error(t, err)
+}
+
+// 测试按天汇总存储空间用量统计
+func TestGetBucketStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "QBox ak:"))
+		q := r.URL.Query()
+		assert.Equal(t, "20261001000000", q.Get("begin"))
+		switch r.URL.Path {
+		case "/v6/space":
+			assert.Equal(t, "bucket", q.Get("bucket"))
+			io.WriteString(w, `{"times":[1,2],"datas":[100,300]}`)
+		case "/v6/count":
+			io.WriteString(w, `{"times":[1,2],"datas":[5,7]}`)
+		case "/v6/blob_io":
+			assert.Equal(t, "bucket", q.Get("$bucket"))
+			io.WriteString(w, `[{"time":"1","values":{"flow":10}},{"time":"2","values":{"flow":32}}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(host string) { statHost = host }(statHost)
+	statHost = server.URL
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0"})
+	assert.NoError(t, err)
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	stats, err := up.GetBucketStats(context.Background(), start, start.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, QiniuBucketStats{StorageBytes: 300, ObjectCount: 7, OutboundBytes: 42}, stats)
+
+	_, err = up.GetBucketStats(context.Background(), start, start.AddDate(0, 0, -1))
+	assert.Error(t, err)
+}
+
+// 测试空间用量取最近一天的统计值，请求随ctx取消
+func TestBucketUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "QBox ak:"))
+		assert.Equal(t, "bucket", r.URL.Query().Get("bucket"))
+		switch r.URL.Path {
+		case "/v6/space":
+			io.WriteString(w, `{"times":[1,2],"datas":[100,300]}`)
+		case "/v6/count":
+			io.WriteString(w, `{"times":[1,2],"datas":[5,7]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(host string) { statHost = host }(statHost)
+	statHost = server.URL
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0"})
+	assert.NoError(t, err)
+	used, count, err := up.BucketUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), used)
+	assert.Equal(t, int64(7), count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = up.bucketUsage(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// 测试限流错误的重试
+func TestUploadRetry(t *testing.T) {
+	defer func(rate, transient time.Duration) {
+		rateLimitRetryDelay, transientRetryDelay = rate, transient
+	}(rateLimitRetryDelay, transientRetryDelay)
+	rateLimitRetryDelay, transientRetryDelay = time.Millisecond, time.Millisecond
+
+	// SDK的表单上传对每次调用已有内部重试，失败次数需要超过其重试次数
+	failures := 5
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("X-Reqid", "reqid")
+		if failures > 0 {
+			failures--
+			w.WriteHeader(573)
+			io.WriteString(w, `{"error":"slow down"}`)
+			return
+		}
+		io.WriteString(w, `{"key":"a.txt","hash":"h"}`)
+	}))
+	defer server.Close()
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0", MaxRetries: 2})
+	assert.NoError(t, err)
+	up.cfg = storage.Config{Region: &storage.Region{SrcUpHosts: []string{strings.TrimPrefix(server.URL, "http://")}}}
+
+	url, err := up.UploadBinary("a.txt", []byte("data"), config.WithKey("a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/a.txt", url)
+
+	// 不重试时返回服务端的错误
+	failures = 100
+	up.maxRetries = 0
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithKey("a.txt"))
+	var e *storage.ErrorInfo
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, 573, e.Code)
+
+	// 不可重试的错误
+	_, ok := retryDelay(&storage.ErrorInfo{Code: 614})
+	assert.False(t, ok)
+	delay, ok := retryDelay(&storage.ErrorInfo{Code: 579})
+	assert.True(t, ok)
+	assert.Equal(t, rateLimitRetryDelay, delay)
+
+	for i := 0; i < 100; i++ {
+		d := jitter(100 * time.Millisecond)
+		assert.True(t, d >= 100*time.Millisecond && d <= 150*time.Millisecond)
+	}
+}
+
+// 测试跳过证书校验后可访问自签名证书的绑定域名
+func TestTLSSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: domain, ZoneID: "z0"})
+	assert.NoError(t, err)
+	_, err = up.Head("a.txt", 5)
+	assert.Error(t, err, "默认校验证书")
+
+	up, err = New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: domain, ZoneID: "z0", TLSSkipVerify: true})
+	assert.NoError(t, err)
+	data, err := up.Head("a.txt", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+// 测试批量抓取远程资源，结果与任务顺序一致，单个失败不影响其他任务
+func TestBatchFetchFromURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Reqid", "reqid")
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(r.URL.Path, "/")
+		src, _ := base64.URLEncoding.DecodeString(parts[2])
+		entry, _ := base64.URLEncoding.DecodeString(parts[4])
+		if strings.Contains(string(src), "missing") {
+			w.WriteHeader(404)
+			io.WriteString(w, `{"error":"httpGet url failed"}`)
+			return
+		}
+		_, key, _ := strings.Cut(string(entry), ":")
+		fmt.Fprintf(w, `{"hash":"h-%s","key":"%s","fsize":5,"mimeType":"text/plain"}`, key, key)
+	}))
+	defer server.Close()
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0"})
+	assert.NoError(t, err)
+	host := strings.TrimPrefix(server.URL, "http://")
+	up.cfg = storage.Config{IoHost: server.URL, Region: &storage.Region{IovipHost: host, IoSrcHost: host}}
+
+	tasks := []FetchTask{
+		{SrcURL: "https://example.com/a.txt", Key: "a.txt"},
+		{SrcURL: "https://example.com/missing.txt", Key: "m.txt"},
+		{SrcURL: "", Key: "empty.txt"},
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("n%02d.txt", i)
+		tasks = append(tasks, FetchTask{SrcURL: "https://example.com/" + key, Key: key})
+	}
+	results, err := up.BatchFetchFromURLs(context.Background(), tasks)
+	assert.NoError(t, err)
+	assert.Len(t, results, len(tasks))
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, FetchResult{Key: "a.txt", Hash: "h-a.txt", MimeType: "text/plain", Fsize: 5, URL: "https://cdn.example.com/a.txt"}, results[0])
+	assert.Error(t, results[1].Error)
+	assert.Equal(t, "m.txt", results[1].Key)
+	assert.Error(t, results[2].Error)
+	for i, task := range tasks[3:] {
+		assert.Equal(t, task.Key, results[i+3].Key, "结果按任务顺序排列")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = up.BatchFetchFromURLs(ctx, tasks[:2])
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, results[1].Error, context.Canceled)
+}
+
+// 测试按marker分页列举并批量删除前缀下的文件
+func TestDeletePrefix(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Reqid", "reqid")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/list":
+			assert.Equal(t, "tmp/", r.URL.Query().Get("prefix"))
+			if r.URL.Query().Get("marker") == "" {
+				io.WriteString(w, `{"marker":"m1","items":[{"key":"tmp/a"},{"key":"tmp/b"}]}`)
+				return
+			}
+			io.WriteString(w, `{"marker":"","items":[{"key":"tmp/c"}]}`)
+		case "/batch":
+			r.ParseForm()
+			ops := r.PostForm["op"]
+			batches = append(batches, ops)
+			w.WriteHeader(298)
+			io.WriteString(w, `[{"code":200},{"code":612,"data":{"error":"no such file or directory"}},{"code":403,"data":{"error":"forbidden"}}]`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0"})
+	assert.NoError(t, err)
+	host := strings.TrimPrefix(server.URL, "http://")
+	up.cfg = storage.Config{RsfHost: server.URL, Region: &storage.Region{RsHost: host, RsfHost: host}}
+
+	keys, err := up.List("tmp/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tmp/a", "tmp/b", "tmp/c"}, keys)
+
+	deleted, err := up.DeletePrefix(context.Background(), "tmp/")
+	assert.Equal(t, 1, deleted)
+	assert.ErrorContains(t, err, "tmp/c: forbidden")
+	if assert.Len(t, batches, 1) {
+		assert.Equal(t, []string{storage.URIDelete("bucket", "tmp/a"), storage.URIDelete("bucket", "tmp/b"), storage.URIDelete("bucket", "tmp/c")}, batches[0])
+	}
+
+	_, err = up.DeletePrefix(context.Background(), "")
+	assert.ErrorIs(t, err, config.ErrInvalidPrefix)
+}
+
+// 测试DebugHTTP记录请求的方法、URL与状态码，隐去签名参数且不记录内容
+func TestDebugHTTP(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secret-content")
+	}))
+	defer server.Close()
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: domain, ZoneID: "z0",
+		TLSSkipVerify: true, DebugHTTP: true, Logger: logger})
+	assert.NoError(t, err)
+	_, err = up.Head("a.txt", 5)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "method=GET")
+	assert.Contains(t, out, "/a.txt")
+	assert.Contains(t, out, "status=200")
+	assert.Contains(t, out, "latency=")
+	assert.Contains(t, out, "token=REDACTED")
+	assert.NotContains(t, out, "secret-content")
+}
+
+// 测试直接发起的请求使用配置的User-Agent，未配置时使用默认值
+func TestUserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+	}))
+	defer server.Close()
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	for _, ua := range []string{"my-app/1.0", ""} {
+		up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: domain, ZoneID: "z0",
+			TLSSkipVerify: true, UserAgent: ua})
+		assert.NoError(t, err)
+		_, err = up.Head("a.txt", 5)
+		assert.NoError(t, err)
+		if ua == "" {
+			ua = config.DefaultUserAgent
+		}
+		assert.Equal(t, ua, got)
+	}
+	assert.True(t, strings.HasPrefix(config.DefaultUserAgent, "gosuploader/"))
+}
+
+// 测试范围下载，length为0时不发起请求
+func TestDownloadRange(t *testing.T) {
+	var ranges []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Content-Range", "bytes 2-4/10")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, "234")
+	}))
+	defer server.Close()
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", ZoneID: "z0",
+		Domain: strings.TrimPrefix(server.URL, "https://"), TLSSkipVerify: true})
+	assert.NoError(t, err)
+	body, err := up.DownloadRange(context.Background(), "a.txt", 2, 3)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "234", string(data))
+
+	body, err = up.DownloadRange(context.Background(), "a.txt", 5, 0)
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(body)
+	assert.Empty(t, data)
+	assert.Equal(t, []string{"bytes=2-4"}, ranges)
+}
+
+// 测试未配置Domain时默认拒绝，设置 KeyAsURL 后返回存储key而非无效的URL，依赖访问域名的下载方法返回错误
+func TestEmptyDomain(t *testing.T) {
+	_, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", ZoneID: "z0"})
+	assert.Error(t, err)
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", ZoneID: "z0", KeyAsURL: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/a.pdf", up.getFileURL("docs/a.pdf"))
+	_, err = up.GetDownloadURL("docs/a.pdf", time.Minute)
+	assert.Error(t, err)
+	// 通过访问域名下载的方法同样返回错误，而不是请求 https:///docs/a.pdf
+	_, err = up.Head("docs/a.pdf", 16)
+	assert.ErrorContains(t, err, "未配置访问域名")
+	_, err = up.DownloadRange(context.Background(), "docs/a.pdf", 0, -1)
+	assert.ErrorContains(t, err, "未配置访问域名")
+	_, _, err = up.DownloadIfNoneMatch(context.Background(), "docs/a.pdf", "")
+	assert.ErrorContains(t, err, "未配置访问域名")
+
+	up, err = New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0", KeyAsURL: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/docs/a.pdf", up.getFileURL("docs/a.pdf"))
+}
+
+// 测试查询图片信息：请求地址带imageInfo参数与签名，私有空间同样可用
+func TestGetImageInfo(t *testing.T) {
+	var up *qiniuUploader
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 签名覆盖token之前的完整地址(含imageInfo参数与过期时间)
+		signed := "https://" + r.Host + r.URL.RequestURI()
+		unsigned, token, _ := strings.Cut(signed, "&token=")
+		if !strings.HasPrefix(r.URL.RawQuery, "imageInfo&e=") || token != up.mac.Sign([]byte(unsigned)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/img/a.png":
+			io.WriteString(w, `{"width":640,"height":480,"format":"png","colorModel":"nrgba"}`)
+		case "/doc.txt":
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":"unsupported format"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var err error
+	up, err = New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", ZoneID: "z0",
+		Domain: strings.TrimPrefix(server.URL, "https://"), TLSSkipVerify: true})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	info, err := up.GetImageInfo(ctx, "/img/a.png")
+	assert.NoError(t, err)
+	assert.Equal(t, QiniuImageInfo{Width: 640, Height: 480, Format: "png", ColorModel: "nrgba"}, info)
+
+	_, err = up.GetImageInfo(ctx, "missing.png")
+	assert.ErrorIs(t, err, config.ErrNotFound)
+	_, err = up.GetImageInfo(ctx, "doc.txt")
+	assert.ErrorContains(t, err, "unsupported format")
+	_, err = up.GetImageInfo(ctx, "../a.png")
+	assert.ErrorIs(t, err, config.ErrInvalidKey)
+
+	noDomain, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", ZoneID: "z0", KeyAsURL: true})
+	assert.NoError(t, err)
+	_, err = noDomain.GetImageInfo(ctx, "img/a.png")
+	assert.ErrorContains(t, err, "未配置访问域名")
+}
+
+// 测试抓取远程资源：指定key时抓取到该key，未指定时使用七牛云分配的key
+func TestFetchRemoteURL(t *testing.T) {
+	var entries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Reqid", "reqid")
+		w.Header().Set("Content-Type", "application/json")
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Qiniu ak:"))
+		parts := strings.Split(r.URL.Path, "/")
+		src, _ := base64.URLEncoding.DecodeString(parts[2])
+		entry, _ := base64.URLEncoding.DecodeString(parts[4])
+		entries = append(entries, string(entry))
+		assert.Equal(t, "https://example.com/a.txt", string(src))
+		_, key, ok := strings.Cut(string(entry), ":")
+		if !ok {
+			key = "FkAutoKey"
+		}
+		fmt.Fprintf(w, `{"hash":"h","key":"%s","fsize":5,"mimeType":"text/plain"}`, key)
+	}))
+	defer server.Close()
+
+	up, err := New(config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "bucket", Domain: "cdn.example.com", ZoneID: "z0"})
+	assert.NoError(t, err)
+	host := strings.TrimPrefix(server.URL, "http://")
+	up.cfg = storage.Config{IoHost: server.URL, Region: &storage.Region{IovipHost: host, IoSrcHost: host}}
+	ctx := context.Background()
+
+	res, err := up.FetchRemoteURL(ctx, "https://example.com/a.txt", "docs/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, FetchResult{Key: "docs/a.txt", Hash: "h", MimeType: "text/plain", Fsize: 5, URL: "https://cdn.example.com/docs/a.txt"}, res)
+
+	res, err = up.FetchRemoteURL(ctx, "https://example.com/a.txt", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "FkAutoKey", res.Key)
+	assert.Equal(t, []string{"bucket:docs/a.txt", "bucket"}, entries)
+
+	_, err = up.FetchRemoteURL(ctx, "", "a.txt")
+	assert.Error(t, err)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = up.FetchRemoteURL(canceled, "https://example.com/a.txt", "a.txt")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,70 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 04:52:07
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 04:52:07
+ * Description: 七牛云上传的限流重试
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// 重试的初始等待时间，之后每次翻倍
+var (
+	rateLimitRetryDelay = 500 * time.Millisecond // 573(请求过于频繁)与579(回调失败)
+	transientRetryDelay = 100 * time.Millisecond // 其他5xx与网络错误
+)
+
+// retryDelay 判断错误是否可以重试，返回对应的初始等待时间
+func retryDelay(err error) (time.Duration, bool) {
+	var e *storage.ErrorInfo
+	if errors.As(err, &e) {
+		switch {
+		case e.Code == 573 || e.Code == 579:
+			return rateLimitRetryDelay, true
+		case e.Code >= 500 && e.Code < 600: // 6xx为七牛云的业务错误，如614文件已存在
+			return transientRetryDelay, true
+		}
+		return 0, false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return transientRetryDelay, true
+	}
+	return 0, false
+}
+
+// jitter 在[d, 1.5d]内随机选取等待时间，避免多个客户端同时重试
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// withRetry 执行fn，遇到可重试的错误时按指数退避加抖动重试，最多重试maxRetries次
+// ctx结束时不再重试，返回最后一次的错误
+func withRetry(ctx context.Context, maxRetries int, fn func() error) error {
+	err := fn()
+	for attempt := 0; attempt < maxRetries && err != nil; attempt++ {
+		delay, ok := retryDelay(err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(jitter(delay << attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 10:11:47
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:47:05
+ * Description: 七牛云空间统计
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package qiniu
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// statResult 统计接口返回结构
+type statResult struct {
+	Times []int64 `json:"times"`
+	Datas []int64 `json:"datas"`
+}
+
+// BucketUsage 获取空间的存储量(字节)与文件数量，取最近一天的统计值
+func (h *qiniuUploader) BucketUsage() (int64, int64, error) {
+	return h.bucketUsage(context.Background())
+}
+
+// bucketUsage 经 getStat 以上传器的HTTP客户端查询统计接口，请求随ctx取消
+func (h *qiniuUploader) bucketUsage(ctx context.Context) (int64, int64, error) {
+	end := time.Now()
+	query := url.Values{
+		"bucket": {h.bucket},
+		"begin":  {end.Add(-24 * time.Hour).Format("20060102150405")},
+		"end":    {end.Format("20060102150405")},
+		"g":      {"day"},
+	}
+
+	var space, count statResult
+	if err := h.getStat(ctx, "/v6/space", query, &space); err != nil {
+		return 0, 0, err
+	}
+	if err := h.getStat(ctx, "/v6/count", query, &count); err != nil {
+		return 0, 0, err
+	}
+	return lastValue(space), lastValue(count), nil
+}
+
+// lastValue 返回统计序列中最新的值
+func lastValue(ret statResult) int64 {
+	if len(ret.Datas) == 0 {
+		return 0
+	}
+	return ret.Datas[len(ret.Datas)-1]
+}
@@ -0,0 +1,78 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 08:12:37
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 08:41:12
+ * Description: 按新的key规则批量迁移已有对象
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Remap 列出所有对象，按keyMapper计算新key后移动，用于更换key生成策略后迁移已有对象
+// keyMapper返回空字符串或与原key相同时跳过该对象。多个对象映射到同一新key时这些对象都不移动，
+// 新key已存在时也不移动，两种情况都以 ErrObjectExists 报告，不会覆盖已有对象。
+// 单个对象失败不会中断，返回成功移动的数量及汇总的错误。已迁移的对象不再位于原key，
+// 失败后以同一keyMapper重新调用即可从中断处继续，keyMapper需要对新key返回自身或空字符串。
+// 上传器未实现 Lister、Mover 与 ObjectInspector 时返回 ErrNotSupported
+func Remap(u Uploader, keyMapper func(oldKey string) (newKey string)) (migrated int, err error) {
+	lister, ok := u.(Lister)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	mover, ok := u.(Mover)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	inspector, ok := u.(ObjectInspector)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+
+	// 先列出全部key再移动，避免新key再次被列举与映射
+	keys, err := lister.List("")
+	if err != nil {
+		return 0, err
+	}
+
+	// 先计算全部映射，找出多个对象映射到同一新key的冲突
+	newKeys := make([]string, len(keys))
+	sources := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if newKey := keyMapper(key); newKey != "" && newKey != key {
+			newKeys[i] = newKey
+			sources[newKey]++
+		}
+	}
+
+	var errs []error
+	for i, key := range keys {
+		newKey := newKeys[i]
+		if newKey == "" {
+			continue
+		}
+		if n := sources[newKey]; n > 1 {
+			errs = append(errs, fmt.Errorf("%s: %w: %d objects map to %s", key, ErrObjectExists, n, newKey))
+			continue
+		}
+		exists, err := inspector.Exists(newKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		if exists {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", key, ErrObjectExists, newKey))
+			continue
+		}
+		if err := mover.Move(key, newKey); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		migrated++
+	}
+	return migrated, errors.Join(errs...)
+}
@@ -0,0 +1,167 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 21:24:36
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 21:24:36
+ * Description: 在多个同构存储后端之间轮询分摊写入
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime/multipart"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// RoundRobin 将每次上传依次分配给下一个后端，用于在多个存储桶或账号间分摊写入压力
+//
+// 上传成功后记录返回路径与后端的对应关系，Delete与Copy按该记录路由到存储该对象的后端；
+// 记录只保存在内存中，对于重启前上传或未经本实例上传的路径，会依次尝试所有后端，
+// Delete在任一后端删除成功即返回成功
+type RoundRobin struct {
+	backends []Uploader
+	next     atomic.Uint64
+	owners   sync.Map // 上传返回的路径 -> 后端下标
+}
+
+// NewRoundRobin 以多个上传器创建轮询上传器，至少需要一个上传器
+func NewRoundRobin(backends ...Uploader) (*RoundRobin, error) {
+	if len(backends) == 0 {
+		return nil, ErrInvalidConfig
+	}
+	for _, b := range backends {
+		if b == nil {
+			return nil, ErrInvalidConfig
+		}
+	}
+	return &RoundRobin{backends: backends}, nil
+}
+
+// pick 原子地选出下一个后端
+func (r *RoundRobin) pick() int {
+	return int((r.next.Add(1) - 1) % uint64(len(r.backends)))
+}
+
+// record 记录路径所在的后端
+func (r *RoundRobin) record(path string, i int, err error) (string, error) {
+	if err == nil {
+		r.owners.Store(path, i)
+	}
+	return path, err
+}
+
+// UploadFile 上传multipart文件到下一个后端
+func (r *RoundRobin) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
+	i := r.pick()
+	path, err := r.backends[i].UploadFile(file, opts...)
+	return r.record(path, i, err)
+}
+
+// UploadBinary 上传二进制数据到下一个后端
+func (r *RoundRobin) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
+	i := r.pick()
+	path, err := r.backends[i].UploadBinary(filename, content, opts...)
+	return r.record(path, i, err)
+}
+
+// UploadBase64 上传Base64数据到下一个后端
+func (r *RoundRobin) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
+	i := r.pick()
+	path, err := r.backends[i].UploadBase64(filename, base64Str, opts...)
+	return r.record(path, i, err)
+}
+
+// UploadFSFile 上传 fs.File 到下一个后端
+func (r *RoundRobin) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (UploadResult, error) {
+	i := r.pick()
+	result, err := r.backends[i].UploadFSFile(ctx, f, key, opts...)
+	r.record(result.Path, i, err)
+	return result, err
+}
+
+// Delete 删除文件，有路由记录时只在对应后端删除，否则依次尝试所有后端
+func (r *RoundRobin) Delete(path string) error {
+	if v, ok := r.owners.Load(path); ok {
+		if err := r.backends[v.(int)].Delete(path); err != nil {
+			return err
+		}
+		r.owners.Delete(path)
+		return nil
+	}
+
+	var errs []error
+	for _, b := range r.backends {
+		err := b.Delete(path)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Copy 在存储源对象的后端内复制，没有路由记录时依次尝试，跳过返回 ErrNotFound 的后端
+func (r *RoundRobin) Copy(ctx context.Context, srcKey, dstKey string) error {
+	if v, ok := r.owners.Load(srcKey); ok {
+		i := v.(int)
+		_, err := r.record(dstKey, i, r.backends[i].Copy(ctx, srcKey, dstKey))
+		return err
+	}
+
+	for i, b := range r.backends {
+		err := b.Copy(ctx, srcKey, dstKey)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		_, err = r.record(dstKey, i, err)
+		return err
+	}
+	return ErrNotFound
+}
+
+// Count 返回各后端前缀下对象数量之和，任一后端失败时返回错误
+func (r *RoundRobin) Count(ctx context.Context, prefix string) (int64, error) {
+	var total int64
+	for i, b := range r.backends {
+		n, err := b.Count(ctx, prefix)
+		if err != nil {
+			return 0, fmt.Errorf("backend %d: %w", i, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// HealthCheckAll 并发检查每个后端，返回的错误与 NewRoundRobin 传入的后端一一对应，正常的后端为nil
+// 各检查共用ctx，调用方通过ctx设置整体超时；未实现 Pinger 的后端为 ErrNotSupported
+func (r *RoundRobin) HealthCheckAll(ctx context.Context) []error {
+	errs := make([]error, len(r.backends))
+	var wg sync.WaitGroup
+	for i, b := range r.backends {
+		wg.Add(1)
+		go func(i int, b Uploader) {
+			defer wg.Done()
+			errs[i] = Ping(ctx, b)
+		}(i, b)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Ping 检查全部后端，任一后端异常时返回汇总的错误，可直接用于 health.HealthHandler
+func (r *RoundRobin) Ping(ctx context.Context) error {
+	var errs []error
+	for i, err := range r.HealthCheckAll(ctx) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("backend %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,151 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 22:48:19
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 22:48:19
+ * Description: 将存储中的对象直接输出到HTTP响应，用于下载代理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ServeObject 将对象流式写入HTTP响应，适用于任意实现了 ObjectInspector 与 RangeReader 的上传器
+//
+// 按对象信息设置Content-Type、Content-Length、Last-Modified与ETag，支持单个区间的Range请求、
+// If-None-Match条件请求与HEAD请求；多区间请求按完整内容返回。
+// 对象不存在时返回 ErrNotFound 且不写入响应，由调用方决定返回404；
+// 上传器未实现所需接口时返回 ErrNotSupported
+func ServeObject(w http.ResponseWriter, r *http.Request, u Uploader, key string) error {
+	inspector, ok := u.(ObjectInspector)
+	if !ok {
+		return ErrNotSupported
+	}
+	reader, ok := u.(RangeReader)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	info, err := inspector.GetObjectInfo(key)
+	if err != nil {
+		return err
+	}
+
+	// 本地存储没有ETag，以大小与修改时间生成弱校验值
+	etag := `"` + info.ETag + `"`
+	if info.ETag == "" {
+		etag = fmt.Sprintf(`W/"%x-%x"`, info.Size, info.LastModified.Unix())
+	}
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Accept-Ranges", "bytes")
+	if !info.LastModified.IsZero() {
+		header.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	}
+
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	status := http.StatusOK
+	offset, length := int64(0), info.Size
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end, ok, err := parseRange(rng, info.Size)
+		if err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return nil
+		}
+		if ok {
+			status = http.StatusPartialContent
+			offset, length = start, end-start+1
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, info.Size))
+		}
+	}
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	body, err := reader.DownloadRange(r.Context(), key, offset, length)
+	if err != nil {
+		// 响应尚未写出，清除已设置的头，便于调用方输出错误
+		for name := range header {
+			header.Del(name)
+		}
+		return err
+	}
+	defer body.Close()
+
+	w.WriteHeader(status)
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// etagMatch 判断If-None-Match是否命中，按弱比较处理
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange 解析单个字节区间，返回闭区间[start, end]
+// 多区间或无法识别的格式返回ok为false，按完整内容处理；区间无法满足时返回错误
+func parseRange(rng string, size int64) (start, end int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(rng, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	errUnsatisfiable := fmt.Errorf("range %q not satisfiable", rng)
+	if first == "" {
+		// 后缀区间：最后n个字节
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, false, errUnsatisfiable
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false, errUnsatisfiable
+	}
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, errUnsatisfiable
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	return start, end, true, nil
+}
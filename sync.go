@@ -0,0 +1,245 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 15:02:47
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 15:02:47
+ * Description: 本地目录同步到存储后端
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// SyncOptions 目录同步选项
+type SyncOptions struct {
+	// Workers 并发上传数，默认4
+	Workers int
+	// DeleteOrphans 删除目标前缀下源目录中不存在的对象，要求上传器实现 Lister
+	DeleteOrphans bool
+	// DryRun 只统计不执行上传与删除
+	DryRun bool
+	// Filter 过滤文件，参数为相对srcDir的/分隔路径，返回false时跳过
+	// 被跳过的路径也不会作为孤立对象删除
+	Filter func(path string) bool
+	// Progress 每处理完一个文件或对象后以当前统计回调
+	Progress func(SyncResult)
+}
+
+// SyncResult 目录同步结果统计
+type SyncResult struct {
+	Added     int // 新增上传
+	Updated   int // 变更后重新上传
+	Deleted   int // 删除的孤立对象
+	Unchanged int // 未变化而跳过
+	Failed    int // 处理失败
+}
+
+// defaultSyncWorkers 默认并发上传数
+const defaultSyncWorkers = 4
+
+// syncFile 待同步的源文件
+type syncFile struct {
+	rel  string // 相对srcDir的/分隔路径
+	path string // 完整路径
+	info fs.FileInfo
+}
+
+// Sync 将本地目录srcDir同步到上传器的dstPrefix前缀下
+// 对象key为dstPrefix与文件相对路径的拼接，目标对象不存在时上传(Added)，
+// 大小不同或本地修改时间晚于对象修改时间时重新上传(Updated)，否则跳过(Unchanged)
+// 上传器需实现 ObjectInspector，否则返回 ErrNotSupported
+// 文件内容流式上传，不读入内存。单个文件失败不会中断同步，计入Failed并汇总在返回的错误中；
+// ctx取消时中止进行中的上传并停止处理剩余文件
+func Sync(ctx context.Context, srcDir string, u Uploader, dstPrefix string, opts SyncOptions) (SyncResult, error) {
+	inspector, ok := u.(ObjectInspector)
+	if !ok {
+		return SyncResult{}, ErrNotSupported
+	}
+	var lister Lister
+	if opts.DeleteOrphans {
+		if lister, ok = u.(Lister); !ok {
+			return SyncResult{}, ErrNotSupported
+		}
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultSyncWorkers
+	}
+
+	files, err := walkSyncDir(srcDir, opts.Filter)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result SyncResult
+		errs   []error
+	)
+	// record 在锁内更新统计并回调进度
+	record := func(update func(*SyncResult), err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		update(&result)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(result)
+		}
+	}
+
+	jobs := make(chan syncFile)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				update, err := syncOne(ctx, u, inspector, syncKey(dstPrefix, f.rel), f, opts.DryRun)
+				record(update, err)
+			}
+		}()
+	}
+
+dispatch:
+	for _, f := range files {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- f:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if opts.DeleteOrphans {
+		if err := deleteOrphans(ctx, u, lister, dstPrefix, files, opts, record); err != nil {
+			return result, err
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// syncOne 同步单个文件，返回统计更新函数
+func syncOne(ctx context.Context, u Uploader, inspector ObjectInspector, key string, f syncFile, dryRun bool) (func(*SyncResult), error) {
+	failed := func(r *SyncResult) { r.Failed++ }
+
+	added := false
+	info, err := inspector.GetObjectInfo(key)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		added = true
+	case err != nil:
+		return failed, fmt.Errorf("%s: %w", f.rel, err)
+	case info.Size == f.info.Size() && !f.info.ModTime().After(info.LastModified):
+		return func(r *SyncResult) { r.Unchanged++ }, nil
+	}
+
+	if !dryRun {
+		// 流式上传文件内容，不将整个文件读入内存
+		file, err := os.Open(f.path)
+		if err != nil {
+			return failed, fmt.Errorf("%s: %w", f.rel, err)
+		}
+		_, err = u.UploadFSFile(ctx, file, key, config.WithFilename(path.Base(f.rel)))
+		file.Close()
+		if err != nil {
+			return failed, fmt.Errorf("%s: %w", f.rel, err)
+		}
+	}
+
+	if added {
+		return func(r *SyncResult) { r.Added++ }, nil
+	}
+	return func(r *SyncResult) { r.Updated++ }, nil
+}
+
+// deleteOrphans 删除目标前缀下源目录中不存在的对象
+func deleteOrphans(ctx context.Context, u Uploader, lister Lister, dstPrefix string, files []syncFile,
+	opts SyncOptions, record func(func(*SyncResult), error)) error {
+	listPrefix := syncKey(dstPrefix, "")
+	keys, err := lister.List(listPrefix)
+	if err != nil {
+		return err
+	}
+
+	sources := make(map[string]bool, len(files))
+	for _, f := range files {
+		sources[f.rel] = true
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(key, listPrefix)
+		if sources[rel] || (opts.Filter != nil && !opts.Filter(rel)) {
+			continue
+		}
+		if !opts.DryRun {
+			if err := u.Delete(key); err != nil {
+				record(func(r *SyncResult) { r.Failed++ }, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+		}
+		record(func(r *SyncResult) { r.Deleted++ }, nil)
+	}
+	return nil
+}
+
+// walkSyncDir 遍历源目录，返回通过过滤的普通文件
+func walkSyncDir(srcDir string, filter func(string) bool) ([]syncFile, error) {
+	var files []syncFile
+	err := filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if filter != nil && !filter(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, syncFile{rel: rel, path: p, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk source directory: %w", err)
+	}
+	return files, nil
+}
+
+// syncKey 拼接目标前缀与相对路径
+func syncKey(prefix, rel string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return rel
+	}
+	return prefix + "/" + rel
+}
@@ -0,0 +1,190 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:04:16
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:04:16
+ * Description: COS批量处理，由服务端对清单中的大量对象执行同一操作
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// defaultBatchPollInterval 轮询批量处理任务状态的默认间隔
+const defaultBatchPollInterval = 10 * time.Second
+
+// batchManifestPrefix 批量处理清单文件的目录，任务结束后清单被删除
+const batchManifestPrefix = ".batch-manifests/"
+
+// COSBatchOp 批量处理操作，实现有 COSCopyOp 与 COSDeleteOp
+type COSBatchOp interface {
+	operation(u *TencentUploader) (*cos.BatchJobOperation, error)
+}
+
+// COSCopyOp 将清单中的对象复制到目标存储桶，目标key为 DstPrefix 加源key
+type COSCopyOp struct {
+	DstBucket string // 目标存储桶(<名称>-<APPID>)，须与当前存储桶在同一地域，为空时复制到当前存储桶
+	DstPrefix string // 目标key前缀，为空时与源key相同
+}
+
+func (op COSCopyOp) operation(u *TencentUploader) (*cos.BatchJobOperation, error) {
+	bucket := op.DstBucket
+	if bucket == "" {
+		bucket = u.config.BucketName
+	}
+	target, err := bucketArn(bucket, u.config.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &cos.BatchJobOperation{PutObjectCopy: &cos.BatchJobOperationCopy{
+		TargetResource:  target,
+		TargetKeyPrefix: op.DstPrefix,
+	}}, nil
+}
+
+// COSDeleteOp 删除清单中的对象
+// COS批量处理目前只支持复制与恢复归档，不支持删除，返回 config.ErrNotSupported；批量删除请使用生命周期规则
+type COSDeleteOp struct{}
+
+func (COSDeleteOp) operation(*TencentUploader) (*cos.BatchJobOperation, error) {
+	return nil, fmt.Errorf("COS batch operations do not support deletion, use lifecycle rules instead: %w", config.ErrNotSupported)
+}
+
+// COSBatchResult 批量处理任务的结果
+type COSBatchResult struct {
+	JobID          string
+	SucceededCount int64
+	FailedCount    int64
+}
+
+// BatchCosOperation 对manifest中的对象key执行批量处理，阻塞直到任务结束，按 BatchPollInterval 轮询任务状态
+// 清单以CSV上传到当前存储桶的 .batch-manifests/ 目录，任务由服务端执行，适合百万级对象，结束后删除清单；
+// 需要配置 BatchRoleArn，存储桶名称须带APPID后缀。ctx取消或查询状态失败时停止等待并返回已创建的任务ID，
+// 任务仍会在服务端继续执行；返回前总会删除清单，服务端尚未读取清单时任务将失败，可通过任务ID在控制台确认
+func (u *TencentUploader) BatchCosOperation(ctx context.Context, manifest []string, op COSBatchOp) (COSBatchResult, error) {
+	if len(manifest) == 0 {
+		return COSBatchResult{}, errors.New("batch manifest cannot be empty")
+	}
+	if u.config.BatchRoleArn == "" {
+		return COSBatchResult{}, errors.New("COS batch operations require BatchRoleArn")
+	}
+	appID, err := appIDOf(u.config.BucketName)
+	if err != nil {
+		return COSBatchResult{}, err
+	}
+	operation, err := op.operation(u)
+	if err != nil {
+		return COSBatchResult{}, err
+	}
+	sourceArn, _ := bucketArn(u.config.BucketName, u.config.Region)
+
+	// 清单每行为 存储桶,URL编码的key
+	var csv strings.Builder
+	for _, key := range manifest {
+		fmt.Fprintf(&csv, "%s,%s\n", u.config.BucketName, strings.ReplaceAll(url.QueryEscape(key), "+", "%20"))
+	}
+	token := uuid.New().String()
+	manifestKey := batchManifestPrefix + token + ".csv"
+	resp, err := u.client.Object.Put(ctx, manifestKey, strings.NewReader(csv.String()), nil)
+	if err != nil {
+		return COSBatchResult{}, fmt.Errorf("failed to upload COS batch manifest: %w", withRequestID(err))
+	}
+	// 清单只供本次任务使用，任何路径返回前都删除，避免在存储桶中累积
+	defer u.client.Object.Delete(context.Background(), manifestKey)
+
+	headers := &cos.BatchRequestHeaders{XCosAppid: appID}
+	job, _, err := u.client.Batch.CreateJob(ctx, &cos.BatchCreateJobOptions{
+		ClientRequestToken:   token,
+		ConfirmationRequired: "false",
+		Manifest: &cos.BatchJobManifest{
+			Location: &cos.BatchJobManifestLocation{
+				ETag:      strings.Trim(resp.Header.Get("ETag"), `"`),
+				ObjectArn: sourceArn + "/" + manifestKey,
+			},
+			Spec: &cos.BatchJobManifestSpec{Fields: []string{"Bucket", "Key"}, Format: "COSBatchOperations_CSV_V1"},
+		},
+		Operation: operation,
+		Priority:  1,
+		Report:    &cos.BatchJobReport{Bucket: sourceArn, Enabled: "false", Format: "Report_CSV_V1", ReportScope: "AllTasks"},
+		RoleArn:   u.config.BatchRoleArn,
+	}, headers)
+	if err != nil {
+		return COSBatchResult{}, fmt.Errorf("failed to create COS batch job: %w", withRequestID(err))
+	}
+
+	result := COSBatchResult{JobID: job.JobId}
+	interval := u.config.BatchPollInterval
+	if interval <= 0 {
+		interval = defaultBatchPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		desc, _, err := u.client.Batch.DescribeJob(ctx, job.JobId, headers)
+		if err != nil {
+			return result, fmt.Errorf("failed to describe COS batch job %s: %w", job.JobId, withRequestID(err))
+		}
+		if desc.Job != nil {
+			if s := desc.Job.ProgressSummary; s != nil {
+				result.SucceededCount, result.FailedCount = int64(s.NumberOfTasksSucceeded), int64(s.NumberOfTasksFailed)
+			}
+			switch desc.Job.Status {
+			case "Complete":
+				return result, nil
+			case "Failed", "Cancelled":
+				reason := desc.Job.StatusUpdateReason
+				if f := desc.Job.FailureReasons; f != nil && f.FailureReason != "" {
+					reason = f.FailureCode + ": " + f.FailureReason
+				}
+				return result, fmt.Errorf("COS batch job %s %s: %s", job.JobId, strings.ToLower(desc.Job.Status), reason)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// appIDOf 从存储桶名称(<名称>-<APPID>)中取得APPID
+func appIDOf(bucket string) (int, error) {
+	i := strings.LastIndex(bucket, "-")
+	appID, err := strconv.Atoi(bucket[i+1:])
+	if i < 0 || err != nil {
+		return 0, fmt.Errorf("COS bucket name %q does not end with -<APPID>", bucket)
+	}
+	return appID, nil
+}
+
+// bucketArn 存储桶的资源描述: qcs::cos:<地域>:uid/<APPID>:<存储桶>
+func bucketArn(bucket, region string) (string, error) {
+	appID, err := appIDOf(bucket)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("qcs::cos:%s:uid/%d:%s", region, appID, bucket), nil
+}
+
+// batchURL 批量处理接口的地址，存储桶名称不带APPID时返回nil，此时无法使用批量处理
+func batchURL(bucket, region string) *url.URL {
+	appID, err := appIDOf(bucket)
+	if err != nil {
+		return nil
+	}
+	u, _ := url.Parse(fmt.Sprintf("https://%d.cos-control.%s.myqcloud.com", appID, region))
+	return u
+}
@@ -0,0 +1,65 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 02:31:48
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 02:31:48
+ * Description: 腾讯云临时凭证的获取与到期前刷新
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// credentialTransport 每次请求前取得当前凭证并签名，凭证临近过期时先刷新
+// 同一请求的SecretId、SecretKey与Token取自同一组凭证，刷新由互斥锁串行化
+type credentialTransport struct {
+	fetch     config.CredentialsProvider
+	transport http.RoundTripper
+
+	mu     sync.Mutex
+	cached *config.Credentials
+}
+
+// current 返回当前凭证，未获取或临近过期时调用fetch刷新
+func (t *credentialTransport) current() (config.Credentials, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.cached != nil && !t.cached.NeedsRefresh(now) {
+		return *t.cached, nil
+	}
+	cred, err := t.fetch()
+	if err != nil {
+		// 刷新失败但旧凭证尚未过期时继续使用旧凭证
+		if t.cached != nil && !t.cached.Expired(now) {
+			return *t.cached, nil
+		}
+		return config.Credentials{}, fmt.Errorf("failed to fetch COS credentials: %w", err)
+	}
+	t.cached = &cred
+	return cred, nil
+}
+
+// RoundTrip 使用当前凭证签名并发送请求
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cred, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	auth := &cos.AuthorizationTransport{
+		SecretID:     cred.AccessKeyID,
+		SecretKey:    cred.AccessKeySecret,
+		SessionToken: cred.SecurityToken,
+		Transport:    t.transport,
+	}
+	return auth.RoundTrip(req)
+}
@@ -0,0 +1,64 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 23:15:08
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 23:15:08
+ * Description: COS存储桶生命周期规则管理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// LifecycleManager 可管理存储桶生命周期规则(自动过期、存储类型转换)的上传器
+// 规则作用于整个存储桶，不属于 Uploader 接口，需要通过类型断言使用
+type LifecycleManager interface {
+	// SetLifecycleRules 以rules整体替换存储桶的生命周期规则
+	SetLifecycleRules(ctx context.Context, rules []cos.BucketLifecycleRule) error
+	// GetLifecycleRules 返回存储桶的生命周期规则，未配置时返回空切片
+	GetLifecycleRules(ctx context.Context) ([]cos.BucketLifecycleRule, error)
+	// DeleteLifecycleRules 删除存储桶的全部生命周期规则
+	DeleteLifecycleRules(ctx context.Context) error
+}
+
+var _ LifecycleManager = (*TencentUploader)(nil)
+
+// SetLifecycleRules 以rules整体替换存储桶的生命周期规则，COS不接受空规则，rules为空时等同于删除全部规则
+func (u *TencentUploader) SetLifecycleRules(ctx context.Context, rules []cos.BucketLifecycleRule) error {
+	if len(rules) == 0 {
+		return u.DeleteLifecycleRules(ctx)
+	}
+	if _, err := u.client.Bucket.PutLifecycle(ctx, &cos.BucketPutLifecycleOptions{Rules: rules}); err != nil {
+		return fmt.Errorf("failed to put COS lifecycle: %w", err)
+	}
+	return nil
+}
+
+// GetLifecycleRules 返回存储桶的生命周期规则，未配置时返回空切片
+func (u *TencentUploader) GetLifecycleRules(ctx context.Context) ([]cos.BucketLifecycleRule, error) {
+	result, _, err := u.client.Bucket.GetLifecycle(ctx)
+	if err != nil {
+		// 未配置生命周期时COS返回404 NoSuchLifecycleConfiguration
+		if cos.IsNotFoundError(err) {
+			return []cos.BucketLifecycleRule{}, nil
+		}
+		return nil, fmt.Errorf("failed to get COS lifecycle: %w", err)
+	}
+	if result.Rules == nil {
+		return []cos.BucketLifecycleRule{}, nil
+	}
+	return result.Rules, nil
+}
+
+// DeleteLifecycleRules 删除存储桶的全部生命周期规则
+func (u *TencentUploader) DeleteLifecycleRules(ctx context.Context) error {
+	if _, err := u.client.Bucket.DeleteLifecycle(ctx); err != nil {
+		return fmt.Errorf("failed to delete COS lifecycle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 11:38:12
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 11:38:12
+ * Description: 腾讯云COS分片上传，实现 multipart.Backend
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/multipart"
+)
+
+var _ multipart.Backend = (*TencentUploader)(nil)
+
+// InitiateMultipart 初始化分片上传
+func (u *TencentUploader) InitiateMultipart(ctx context.Context, key string) (string, error) {
+	result, _, err := u.client.Object.InitiateMultipartUpload(ctx, key, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart 上传单个分片
+func (u *TencentUploader) UploadPart(ctx context.Context, key, uploadID string, number int, r io.Reader, size int64) (multipart.Part, error) {
+	resp, err := u.client.Object.UploadPart(ctx, key, uploadID, number, r, &cos.ObjectUploadPartOptions{
+		ContentLength: size,
+	})
+	if err != nil {
+		return multipart.Part{}, err
+	}
+	return multipart.Part{Number: number, ETag: resp.Header.Get("ETag"), Size: size}, nil
+}
+
+// CompleteMultipart 合并分片
+func (u *TencentUploader) CompleteMultipart(ctx context.Context, key, uploadID string, parts []multipart.Part) error {
+	opt := &cos.CompleteMultipartUploadOptions{}
+	for _, p := range parts {
+		opt.Parts = append(opt.Parts, cos.Object{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, _, err := u.client.Object.CompleteMultipartUpload(ctx, key, uploadID, opt)
+	return err
+}
+
+// AbortMultipart 中止分片上传并清理已上传的分片
+func (u *TencentUploader) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	_, err := u.client.Object.AbortMultipartUpload(ctx, key, uploadID)
+	return err
+}
+
+// ListIncompleteMultipartUploads 列举前缀下未完成的分片上传
+func (u *TencentUploader) ListIncompleteMultipartUploads(ctx context.Context, prefix string) ([]multipart.IncompleteUpload, error) {
+	var uploads []multipart.IncompleteUpload
+	opt := &cos.ListMultipartUploadsOptions{Prefix: prefix}
+	for {
+		result, _, err := u.client.Bucket.ListMultipartUploads(ctx, opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list COS multipart uploads: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			item := multipart.IncompleteUpload{Key: upload.Key, UploadID: upload.UploadID}
+			item.InitiatedAt, _ = time.Parse(time.RFC3339, upload.Initiated)
+			if err := u.countParts(ctx, &item); err != nil {
+				return nil, err
+			}
+			uploads = append(uploads, item)
+		}
+		if !result.IsTruncated {
+			return uploads, nil
+		}
+		opt.KeyMarker, opt.UploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+// countParts 统计分片上传已上传的分片数与字节数
+func (u *TencentUploader) countParts(ctx context.Context, item *multipart.IncompleteUpload) error {
+	opt := &cos.ObjectListPartsOptions{}
+	for {
+		result, _, err := u.client.Object.ListParts(ctx, item.Key, item.UploadID, opt)
+		if err != nil {
+			return fmt.Errorf("failed to list COS uploaded parts: %w", err)
+		}
+		for _, part := range result.Parts {
+			item.PartCount++
+			item.BytesUploaded += part.Size
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		opt.PartNumberMarker = result.NextPartNumberMarker
+	}
+}
+
+// UploadStream 从r流式上传大小未知的内容到key，以分片上传写入，返回的 UploadResult.Path 为文件访问URL
+// key为空时按 WithFilename 指定的文件名生成，两者都为空时返回 ErrInvalidKey
+// ctx取消、超时或读取失败(如 UploadWriter.Abort)时中止分片上传，不会生成对象，也不会残留已上传的分片；
+// 大小限制、扫描、审计与 WithResult 照常生效，请求头、元数据与存储类型等选项不会应用到分片上传
+func (u *TencentUploader) UploadStream(ctx context.Context, key string, r io.Reader, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	if o.Key != "" || o.Filename == "" {
+		key, err = keygen.PolicyKey(o.Key, u.config.KeyCharPolicy)
+	} else {
+		// 未指定key时按文件名生成，content-addressed策略需要预先读取全部内容，返回错误
+		key, err = u.generateObjectKey(o.Filename, o.ContentType)
+		key = u.config.KeyCharPolicy.Apply(key)
+	}
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+
+	result := config.UploadResult{RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	r, done := audit.Wrap(r, -1, o)
+	ctx, cancel := o.Context(-1)
+	defer cancel()
+	if err := multipart.NewOrchestrator(u, multipart.Options{}).Upload(ctx, key, r, -1); err != nil {
+		return config.UploadResult{}, fmt.Errorf("failed to upload COS object %s: %w", key, withRequestID(err))
+	}
+	done(key)
+	result.Path = u.getFileURL(key)
+	return result, nil
+}
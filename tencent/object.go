@@ -0,0 +1,196 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 14:18:50
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 14:18:50
+ * Description: 腾讯云COS对象管理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+)
+
+// Copy 服务端复制对象
+func (u *TencentUploader) Copy(ctx context.Context, srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := u.client.Object.Copy(ctx, dstKey, u.sourceURL(srcKey), nil); err != nil {
+		if cos.IsNotFoundError(err) {
+			return config.ErrNotFound
+		}
+		return fmt.Errorf("failed to copy COS object: %w", err)
+	}
+	return nil
+}
+
+// Move 移动对象，通过服务端复制后删除源对象实现
+func (u *TencentUploader) Move(srcKey, dstKey string) error {
+	srcKey, dstKey, err := keygen.NormalizeKeyPair(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Copy(context.Background(), srcKey, dstKey); err != nil {
+		return err
+	}
+	if _, err := u.client.Object.Delete(context.Background(), srcKey); err != nil {
+		return fmt.Errorf("failed to delete COS object: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断对象是否存在
+func (u *TencentUploader) Exists(objectKey string) (bool, error) {
+	exists, err := u.client.Object.IsExist(context.Background(), u.config.KeyCharPolicy.Apply(objectKey))
+	if err != nil {
+		return false, fmt.Errorf("failed to check COS object: %w", err)
+	}
+	return exists, nil
+}
+
+// List 列出前缀下的所有对象key，前缀按原样匹配
+// 配置了 KeySeparator 时生成的key需以分隔后的形式查询，如 2026_10_，WithKey 指定的含/的key仍按/查询
+func (u *TencentUploader) List(prefix string) ([]string, error) {
+	var keys []string
+	err := u.walk(context.Background(), prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Count 统计前缀下的对象数量，COS没有计数接口，逐页列举计数，耗时与对象数成正比
+func (u *TencentUploader) Count(ctx context.Context, prefix string) (int64, error) {
+	var count int64
+	err := u.walk(ctx, prefix, func(string) { count++ })
+	return count, err
+}
+
+// walk 逐页列举前缀下的对象
+func (u *TencentUploader) walk(ctx context.Context, prefix string, fn func(key string)) error {
+	opt := &cos.BucketGetOptions{Prefix: prefix, MaxKeys: 1000}
+	for {
+		result, _, err := u.client.Bucket.Get(ctx, opt)
+		if err != nil {
+			return fmt.Errorf("failed to list COS objects: %w", err)
+		}
+		for _, object := range result.Contents {
+			fn(object.Key)
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		opt.Marker = result.NextMarker
+	}
+}
+
+// sourceURL 构造服务端复制使用的源对象地址
+func (u *TencentUploader) sourceURL(key string) string {
+	return fmt.Sprintf("%s/%s", u.client.BaseURL.BucketURL.Host, key)
+}
+
+// GetTags 读取对象标签
+func (u *TencentUploader) GetTags(objectKey string) (map[string]string, error) {
+	result, _, err := u.client.Object.GetTagging(context.Background(), objectKey)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get COS object tagging: %w", err)
+	}
+
+	tags := make(map[string]string, len(result.TagSet))
+	for _, tag := range result.TagSet {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
+
+// Head 读取对象的前n个字节，对象小于n时返回全部内容
+func (u *TencentUploader) Head(objectKey string, n int64) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("head length must be positive")
+	}
+
+	opt := &cos.ObjectGetOptions{Range: fmt.Sprintf("bytes=0-%d", n-1)}
+	resp, err := u.client.Object.Get(context.Background(), objectKey, opt)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get COS object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read COS object: %w", err)
+	}
+	return data, nil
+}
+
+// DownloadRange 从offset开始读取length字节，length小于0时读取到对象末尾，length为0或offset超出对象末尾时返回空内容
+// 调用方负责关闭返回的ReadCloser
+func (u *TencentUploader) DownloadRange(ctx context.Context, objectKey string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("range offset must not be negative")
+	}
+	// bytes=N-(N-1)是无效范围，服务端会忽略它返回完整对象，因此不发起请求
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	opt := &cos.ObjectGetOptions{Range: fmt.Sprintf("bytes=%d-", offset)}
+	if length >= 0 {
+		opt.Range = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	resp, err := u.client.Object.Get(ctx, objectKey, opt)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, config.ErrNotFound
+		}
+		if e, ok := cos.IsCOSError(err); ok && e.Response != nil && e.Response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		return nil, fmt.Errorf("failed to get COS object: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// DownloadIfNoneMatch 下载完整对象并返回其ETag，etag与对象当前ETag相同时返回 ErrNotModified
+// 条件判断由COS完成，未修改时不传输内容
+func (u *TencentUploader) DownloadIfNoneMatch(ctx context.Context, objectKey, etag string) (io.ReadCloser, string, error) {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	opt := &cos.ObjectGetOptions{}
+	if etag != "" {
+		opt.XOptionHeader = &http.Header{}
+		opt.XOptionHeader.Set("If-None-Match", `"`+etag+`"`)
+	}
+	resp, err := u.client.Object.Get(ctx, objectKey, opt)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, "", config.ErrNotFound
+		}
+		if e, ok := cos.IsCOSError(err); ok && e.Response != nil && e.Response.StatusCode == http.StatusNotModified {
+			return nil, etag, config.ErrNotModified
+		}
+		return nil, "", fmt.Errorf("failed to get COS object: %w", err)
+	}
+	return resp.Body, strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
@@ -0,0 +1,45 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 00:21:40
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 00:21:40
+ * Description: COS客户提供密钥的服务端加密(SSE-C)
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// sseCustomer 返回SSE-C请求头的取值：算法、Base64编码的密钥及其MD5
+func sseCustomer(key []byte) (algorithm, encodedKey, keyMD5 string) {
+	sum := md5.Sum(key)
+	return "AES256", base64.StdEncoding.EncodeToString(key), base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// DownloadWithCustomerKey 读取以 config.WithCustomerKey 上传的对象，customerKey必须与上传时相同
+// 调用方负责关闭返回的ReadCloser；对象不存在时返回 config.ErrNotFound
+func (u *TencentUploader) DownloadWithCustomerKey(ctx context.Context, objectKey string, customerKey []byte) (io.ReadCloser, error) {
+	if len(customerKey) != config.CustomerKeySize {
+		return nil, fmt.Errorf("%w: customer key must be %d bytes, got %d", config.ErrInvalidEncryptionKey, config.CustomerKeySize, len(customerKey))
+	}
+
+	opt := &cos.ObjectGetOptions{}
+	opt.XCosSSECustomerAglo, opt.XCosSSECustomerKey, opt.XCosSSECustomerKeyMD5 = sseCustomer(customerKey)
+	resp, err := u.client.Object.Get(ctx, objectKey, opt)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, config.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get COS object: %w", err)
+	}
+	return resp.Body, nil
+}
@@ -10,33 +10,80 @@ package tencent
 
 import (
 	"bytes"
+	"cmp"
 	"context"
-	"encoding/base64"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/tencentyun/cos-go-sdk-v5"
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/audit"
+	"github.com/zjguoxin/gosuploader/internal/b64"
+	"github.com/zjguoxin/gosuploader/internal/fsfile"
+	"github.com/zjguoxin/gosuploader/internal/httplog"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
+	"github.com/zjguoxin/gosuploader/internal/keylock"
+	"github.com/zjguoxin/gosuploader/internal/magic"
+	"github.com/zjguoxin/gosuploader/internal/mime"
 )
 
 // TencentUploader 腾讯云COS上传处理器
 type TencentUploader struct {
 	client *cos.Client
 	config config.TencentConfig
+	keys   keylock.Locker // WithKey上传时的按key锁，仅在本实例内生效，不是分布式锁
+
+	credentials *credentialTransport // 配置了CredentialsProvider时的凭证缓存
+}
+
+// limits COS的上传限制：简单上传最大5GB，分块1MB~5GB，最多10000块
+var limits = config.ProviderLimits{
+	MaxSinglePutSize: 5 << 30,
+	MinPartSize:      1 << 20,
+	MaxPartSize:      5 << 30,
+	MaxParts:         10000,
+}
+
+// ProviderLimits 返回COS的上传限制
+func (u *TencentUploader) ProviderLimits() config.ProviderLimits {
+	return limits
 }
 
+// 未配置时使用的默认超时
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultDialTimeout    = 10 * time.Second
+)
+
 // New 创建腾讯云COS上传处理器
 func New(cfg config.TencentConfig) (*TencentUploader, error) {
 	// 验证必要配置
-	if cfg.SecretID == "" || cfg.SecretKey == "" || cfg.BucketName == "" || cfg.Region == "" {
+	hasKey := cfg.SecretID != "" && cfg.SecretKey != ""
+	if (!hasKey && cfg.CredentialsProvider == nil) || cfg.BucketName == "" || cfg.Region == "" {
 		return nil, errors.New("tencent COS configuration is incomplete")
 	}
+	if !keygen.ValidStrategy(cfg.KeyStrategy) {
+		return nil, fmt.Errorf("unsupported key strategy: %s", cfg.KeyStrategy)
+	}
+	if !keygen.ValidSeparator(cfg.KeySeparator) {
+		return nil, fmt.Errorf("invalid key separator: %q", cfg.KeySeparator)
+	}
+	if cfg.KeyStrategy == config.KeyStrategySequential && !cfg.Sequence.Persistent() {
+		return nil, errors.New("sequential key strategy requires a Sequence with a Store, otherwise keys restart at 1 and overwrite existing objects")
+	}
+	if err := cfg.RoutingRules.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 构建存储桶URL
 	bucketURL := fmt.Sprintf("https://%s.cos.%s.myqcloud.com", cfg.BucketName, cfg.Region)
@@ -49,34 +96,71 @@ func New(cfg config.TencentConfig) (*TencentUploader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse COS URL: %w", err)
 	}
+	return newUploader(cfg, &cos.BaseURL{BucketURL: u, BatchURL: batchURL(cfg.BucketName, cfg.Region)})
+}
+
+// newUploader 以指定的服务地址创建上传处理器并验证连接，cfg须已通过 New 中的校验
+func newUploader(cfg config.TencentConfig, baseURL *cos.BaseURL) (*TencentUploader, error) {
+	// 创建COS客户端，设置超时避免服务端无响应时协程一直阻塞
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	if cfg.TLSSkipVerify {
+		log.Println(config.TLSSkipVerifyWarning)
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	var base http.RoundTripper = transport
+	if cfg.DebugHTTP {
+		base = httplog.Wrap(transport, cfg.Logger)
+	}
+
+	// 配置了凭证提供函数时每次请求前取得当前凭证，临近过期时自动刷新
+	var auth http.RoundTripper = &cos.AuthorizationTransport{
+		SecretID:  cfg.SecretID,
+		SecretKey: cfg.SecretKey,
+		Transport: base,
+	}
+	var provider *credentialTransport
+	if cfg.CredentialsProvider != nil {
+		provider = &credentialTransport{fetch: cfg.CredentialsProvider, transport: base}
+		auth = provider
+	}
 
-	// 创建COS客户端
-	baseURL := &cos.BaseURL{BucketURL: u}
 	client := cos.NewClient(baseURL, &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:  cfg.SecretID,
-			SecretKey: cfg.SecretKey,
-		},
+		Transport: auth,
+		Timeout:   cfg.RequestTimeout,
 	})
+	client.UserAgent = cmp.Or(cfg.UserAgent, config.DefaultUserAgent)
 
 	// 验证连接
-	_, err = client.Bucket.Head(context.Background())
-	if err != nil {
+	if _, err := client.Bucket.Head(context.Background()); err != nil {
 		return nil, fmt.Errorf("failed to connect to COS bucket: %w", err)
 	}
 
 	return &TencentUploader{
 		client: client,
 		config: cfg,
+
+		credentials: provider,
 	}, nil
 }
 
 // UploadFile 上传multipart表单文件
-func (u *TencentUploader) UploadFile(file *multipart.FileHeader) (string, error) {
+func (u *TencentUploader) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
 	if file == nil {
 		return "", errors.New("file header cannot be nil")
 	}
 
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+
 	// 打开上传文件
 	src, err := file.Open()
 	if err != nil {
@@ -85,29 +169,64 @@ func (u *TencentUploader) UploadFile(file *multipart.FileHeader) (string, error)
 	defer src.Close()
 
 	// 生成存储对象键
-	objectKey := u.generateObjectKey(file.Filename)
+	objectKey, body, err := u.objectKey(file.Filename, src, o)
+	if err != nil {
+		return "", err
+	}
 
 	// 上传文件到COS
-	_, err = u.client.Object.Put(context.Background(), objectKey, src, nil)
-	if err != nil {
+	if err = u.put(objectKey, body, file.Size, o); err != nil {
 		return "", fmt.Errorf("failed to upload file to COS: %w", err)
 	}
 
 	return u.getFileURL(objectKey), nil
 }
 
+// UploadFSFile 上传 fs.File 的内容，适用于 os.DirFS、embed.FS 等非HTTP场景
+// key为空时按文件名生成存储key，ctx取消或超时时中止上传；调用方负责关闭f
+func (u *TencentUploader) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (config.UploadResult, error) {
+	o, err := fsfile.Options(ctx, key, opts)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	file, err := fsfile.Open(f, mime.Resolver{Overrides: u.config.ContentTypeOverrides}, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	o.ContentType = file.ContentType
+
+	objectKey, body, err := u.objectKey(file.Name, file.Reader, o)
+	if err != nil {
+		return config.UploadResult{}, err
+	}
+	result := config.UploadResult{Size: file.Size, ContentType: file.ContentType, RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	if err = u.put(objectKey, body, file.Size, o); err != nil {
+		return config.UploadResult{}, fmt.Errorf("failed to upload file to COS: %w", err)
+	}
+	result.Path = u.getFileURL(objectKey)
+	return result, nil
+}
+
 // UploadBinary 上传二进制数据
-func (u *TencentUploader) UploadBinary(filename string, content []byte) (string, error) {
+func (u *TencentUploader) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
 	if len(content) == 0 {
 		return "", errors.New("content cannot be empty")
 	}
 
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+
 	// 生成存储对象键
-	objectKey := u.generateObjectKey(filename)
+	objectKey, body, err := u.objectKey(filename, bytes.NewReader(content), o)
+	if err != nil {
+		return "", err
+	}
 
 	// 上传文件到COS
-	_, err := u.client.Object.Put(context.Background(), objectKey, bytes.NewReader(content), nil)
-	if err != nil {
+	if err = u.put(objectKey, body, int64(len(content)), o); err != nil {
 		return "", fmt.Errorf("failed to upload binary to COS: %w", err)
 	}
 
@@ -115,18 +234,18 @@ func (u *TencentUploader) UploadBinary(filename string, content []byte) (string,
 }
 
 // UploadBase64 上传Base64编码的文件
-func (u *TencentUploader) UploadBase64(filename string, base64Str string) (string, error) {
+func (u *TencentUploader) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
 	if base64Str == "" {
 		return "", errors.New("base64 content cannot be empty")
 	}
 
 	// 解码Base64数据
-	data, err := base64.StdEncoding.DecodeString(base64Str)
+	data, err := b64.Decode(base64Str, u.config.MaxBase64Length)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	return u.UploadBinary(filename, data)
+	return u.UploadBinary(filename, data, opts...)
 }
 
 // Delete 删除COS文件
@@ -135,45 +254,264 @@ func (u *TencentUploader) Delete(objectKey string) error {
 		return errors.New("object key cannot be empty")
 	}
 
-	_, err := u.client.Object.Delete(context.Background(), objectKey)
+	_, err := u.client.Object.Delete(context.Background(), u.config.KeyCharPolicy.Apply(objectKey))
 	if err != nil {
-		return fmt.Errorf("failed to delete COS object: %w", err)
+		return fmt.Errorf("failed to delete COS object: %w", withRequestID(err))
 	}
 
 	return nil
 }
 
-// generateObjectKey 生成存储对象键
-func (u *TencentUploader) generateObjectKey(originalName string) string {
-	// 获取文件扩展名
-	ext := filepath.Ext(originalName)
-	baseName := strings.TrimSuffix(filepath.Base(originalName), ext)
+// DeleteIfExists 删除COS文件，COS删除不存在的对象同样成功，无法区分对象是否存在，
+// 因此不做存在性检查，成功时总是返回true
+func (u *TencentUploader) DeleteIfExists(ctx context.Context, objectKey string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if err := u.Delete(objectKey); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// objectKey 确定存储对象键，优先使用上传选项指定的key
+// 指定的key经过规范化校验，不合法时返回 config.ErrInvalidKey
+// content-addressed策略下读取r按内容摘要生成key，返回从头读取同一内容的Reader
+func (u *TencentUploader) objectKey(originalName string, r io.Reader, o *config.UploadOptions) (string, io.Reader, error) {
+	if o.Key != "" {
+		key, err := keygen.PolicyKey(o.Key, u.config.KeyCharPolicy)
+		return key, r, err
+	}
+	if u.config.KeyStrategy == config.KeyStrategyContentAddressed {
+		return keygen.ContentKey(o.FilenameOr(originalName), r)
+	}
+	key, err := u.generateObjectKey(o.FilenameOr(originalName), o.ContentType)
+	return u.config.KeyCharPolicy.Apply(key), r, err
+}
 
-	// 生成日期路径和唯一文件名
-	datePath := time.Now().Format("2006/01/02")
-	uniqueName := fmt.Sprintf("%s_%d%s", baseName, time.Now().UnixNano(), ext)
+// generateObjectKey 生成存储对象键，contentType为空时按扩展名推断，用于匹配路由规则
+func (u *TencentUploader) generateObjectKey(originalName, contentType string) (string, error) {
+	// 生成[路由前缀/]日期路径和唯一文件名
+	return keygen.GenerateKey(originalName, keygen.KeygenOptions{
+		DateFormat: "2006/01/02",
+		Prefix:     u.config.RoutingRules.Prefix(originalName, cmp.Or(contentType, mime.TypeByFilename(originalName))),
+		Strategy:   u.config.KeyStrategy,
+		Sequence:   u.config.Sequence,
+		Separator:  u.config.KeySeparator,
+	})
+}
 
-	return filepath.Join(datePath, uniqueName)
+// BucketUsage COS未提供低成本的用量查询接口，返回 config.ErrNotSupported
+func (u *TencentUploader) BucketUsage() (int64, int64, error) {
+	return 0, 0, config.ErrNotSupported
+}
+
+// Ping 检查存储桶是否可访问
+func (u *TencentUploader) Ping(ctx context.Context) error {
+	if _, err := u.client.Bucket.Head(ctx); err != nil {
+		return fmt.Errorf("failed to ping COS bucket: %w", err)
+	}
+	return nil
+}
+
+// put 上传数据到COS，size为内容长度
+func (u *TencentUploader) put(objectKey string, r io.Reader, size int64, o *config.UploadOptions) error {
+	if err := limits.CheckSinglePut(size); err != nil {
+		return err
+	}
+	// COS的对象锁定只能在存储桶级别配置默认保留期，无法按对象指定
+	if o.Retention != nil {
+		return fmt.Errorf("COS does not support per-object retention, configure bucket object lock instead: %w", config.ErrNotSupported)
+	}
+	if o.StrictTypeValidation {
+		var err error
+		if r, err = magic.Validate(r, o.FilenameOr(objectKey), config.MagicByteRules); err != nil {
+			return err
+		}
+	}
+	if o.Key != "" {
+		defer u.keys.Lock(objectKey)()
+	}
+	// 内容寻址的key由内容决定，对象已存在时内容相同，跳过写入
+	if o.Key == "" && u.config.KeyStrategy == config.KeyStrategyContentAddressed {
+		if exists, err := u.Exists(objectKey); err != nil || exists {
+			return err
+		}
+	}
+	r, ct, err := u.resolveContentType(objectKey, r, o)
+	if err != nil {
+		return err
+	}
+	r, done := audit.Wrap(r, size, o)
+
+	ctx, cancel := o.Context(size)
+	defer cancel()
+
+	options := u.putOptions(ct, o)
+	options.ContentLength = size
+	if _, err := u.client.Object.Put(ctx, objectKey, r, options); err != nil {
+		return withRequestID(err)
+	}
+	// 开启CRC时SDK已用响应中的CRC64校验了内容，无需再查询
+	if o.VerifyAfterUpload && !u.client.Conf.EnableCRC {
+		if err := config.VerifySize(func() (int64, error) { return u.Size(objectKey) }, size); err != nil {
+			return err
+		}
+	}
+
+	done(objectKey)
+	return nil
+}
+
+// putOptions 将上传选项转换为COS请求选项，contentType为空时由SDK按key推断
+func (u *TencentUploader) putOptions(contentType string, o *config.UploadOptions) *cos.ObjectPutOptions {
+	header := &cos.ObjectPutHeaderOptions{
+		CacheControl:     u.cacheControl(contentType, o),
+		ContentType:      contentType,
+		XCosStorageClass: storageClasses[o.StorageClass],
+	}
+	extra := o.Headers.Clone()
+	if tags := config.ObjectTags(u.config.ProvenanceTags, o); len(tags) > 0 {
+		values := make(url.Values, len(tags))
+		for k, v := range tags {
+			values.Set(k, v)
+		}
+		if extra == nil {
+			extra = make(http.Header)
+		}
+		extra.Set("x-cos-tagging", values.Encode())
+	}
+	meta := make(http.Header)
+	for name, value := range o.ExtraHeaders {
+		if strings.HasPrefix(strings.ToLower(name), metaPrefix) {
+			meta.Set(name, value)
+		} else {
+			if extra == nil {
+				extra = make(http.Header)
+			}
+			extra.Set(name, value)
+		}
+	}
+	if o.RecordUploadedAt {
+		meta.Set(metaPrefix+config.UploadedAtMeta, config.FormatUploadedAt(time.Now()))
+	}
+	if len(extra) > 0 {
+		header.XOptionHeader = &extra
+	}
+	if len(meta) > 0 {
+		header.XCosMetaXXX = &meta
+	}
+	if o.CustomerKey != nil {
+		header.XCosSSECustomerAglo, header.XCosSSECustomerKey, header.XCosSSECustomerKeyMD5 = sseCustomer(o.CustomerKey)
+	}
+	return &cos.ObjectPutOptions{ObjectPutHeaderOptions: header}
+}
+
+// storageClasses 通用存储类型对应的COS存储类型
+var storageClasses = map[string]string{
+	config.StorageClassStandard:         "STANDARD",
+	config.StorageClassInfrequentAccess: "STANDARD_IA",
+	config.StorageClassArchive:          "ARCHIVE",
+}
+
+// storageClassOf 将COS返回的存储类型转换为通用存储类型，标准存储的对象不返回该头
+func storageClassOf(class string) string {
+	if class == "" {
+		return config.StorageClassStandard
+	}
+	for generic, c := range storageClasses {
+		if c == class {
+			return generic
+		}
+	}
+	return class
+}
+
+// metaPrefix COS自定义元数据头前缀
+const metaPrefix = "x-cos-meta-"
+
+// GetObjectInfo 获取对象信息，对象不存在时返回 config.ErrNotFound
+func (u *TencentUploader) GetObjectInfo(objectKey string) (config.ObjectInfo, error) {
+	resp, err := u.client.Object.Head(context.Background(), objectKey, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return config.ObjectInfo{}, config.ErrNotFound
+		}
+		return config.ObjectInfo{}, fmt.Errorf("failed to head COS object: %w", err)
+	}
+
+	info := config.ObjectInfo{
+		Key:           objectKey,
+		Size:          resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ETag:          strings.Trim(resp.Header.Get("ETag"), `"`),
+		CustomHeaders: make(map[string]string),
+	}
+	info.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	info.StorageClass = storageClassOf(resp.Header.Get("x-cos-storage-class"))
+	for name := range resp.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, metaPrefix) {
+			info.CustomHeaders[lower] = resp.Header.Get(name)
+		}
+	}
+	info.UploadedAt = config.ParseUploadedAt(info.CustomHeaders[metaPrefix+config.UploadedAtMeta], info.LastModified)
+	return info, nil
+}
+
+// Size 返回对象大小，对象不存在时返回 config.ErrNotFound
+func (u *TencentUploader) Size(objectKey string) (int64, error) {
+	resp, err := u.client.Object.Head(context.Background(), objectKey, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return 0, config.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to head COS object: %w", err)
+	}
+	return resp.ContentLength, nil
+}
+
+// cacheControl 确定上传时的Cache-Control，显式选项优先，其次按内容类型规则匹配
+func (u *TencentUploader) cacheControl(contentType string, o *config.UploadOptions) string {
+	if o.CacheControl != "" {
+		return o.CacheControl
+	}
+	value, _ := mime.Lookup(u.config.ContentTypeCacheRules, contentType)
+	return value
 }
 
 // getFileURL 获取文件访问URL
+// 使用自定义域名时去除 URLStripPrefix
 func (u *TencentUploader) getFileURL(objectKey string) string {
 	if u.config.Domain != "" {
-		return fmt.Sprintf("https://%s/%s", u.config.Domain, objectKey)
+		return fmt.Sprintf("https://%s/%s", u.config.Domain, keygen.StripPrefix(objectKey, u.config.URLStripPrefix))
 	}
 	return fmt.Sprintf("https://%s.cos.%s.myqcloud.com/%s", u.config.BucketName, u.config.Region, objectKey)
 }
 
 // GetPresignedURL 获取预签名URL
+// 使用临时凭证时URL携带安全令牌，有效期不会超过凭证本身的有效期
 func (u *TencentUploader) GetPresignedURL(objectKey string, expired time.Duration) (string, error) {
+	secretID, secretKey := u.config.SecretID, u.config.SecretKey
+	var opt interface{} // 必须是无类型的nil，SDK会对非nil的选项做类型断言
+	if u.credentials != nil {
+		cred, err := u.credentials.current()
+		if err != nil {
+			return "", err
+		}
+		secretID, secretKey = cred.AccessKeyID, cred.AccessKeySecret
+		if cred.SecurityToken != "" {
+			opt = &cos.PresignedURLOptions{Query: &url.Values{"x-cos-security-token": []string{cred.SecurityToken}}}
+		}
+	}
+
 	presignedURL, err := u.client.Object.GetPresignedURL(
 		context.Background(),
 		http.MethodGet,
 		objectKey,
-		u.config.SecretID,
-		u.config.SecretKey,
+		secretID,
+		secretKey,
 		expired,
-		nil,
+		opt,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
@@ -191,3 +529,47 @@ func (u *TencentUploader) SetACL(objectKey string, acl string) error {
 	})
 	return err
 }
+
+// resolveContentType 确定上传的内容类型，WithFilename 指定的文件名优先于key
+// 开启DetectMIME时读取内容开头用于嗅探，返回的reader仍包含完整内容
+func (u *TencentUploader) resolveContentType(objectKey string, r io.Reader, o *config.UploadOptions) (io.Reader, string, error) {
+	resolver := mime.Resolver{Overrides: u.config.ContentTypeOverrides, DetectMIME: u.config.DetectMIME}
+	var head []byte
+	if resolver.DetectMIME && o.ContentType == "" {
+		var err error
+		if head, r, err = mime.Peek(r); err != nil {
+			return nil, "", err
+		}
+	}
+	return r, resolver.ResolveContentType(o.FilenameOr(objectKey), head, o.ContentType), nil
+}
+
+// ErrorDetails 从err中提取COS返回的错误码、错误信息、请求ID与HTTP状态码，err不是COS服务端错误时返回false
+// HEAD等没有响应体的请求从响应头 x-cos-request-id 读取请求ID
+func ErrorDetails(err error) (*config.ProviderError, bool) {
+	var pe *config.ProviderError
+	if errors.As(err, &pe) {
+		return pe, true
+	}
+	var e *cos.ErrorResponse
+	if !errors.As(err, &e) {
+		return nil, false
+	}
+	d := &config.ProviderError{Provider: "tencent", Code: e.Code, Message: e.Message, RequestID: e.RequestID, Err: err}
+	if e.Response != nil {
+		d.StatusCode = e.Response.StatusCode
+		if d.RequestID == "" {
+			d.RequestID = e.Response.Header.Get("x-cos-request-id")
+		}
+	}
+	return d, true
+}
+
+// withRequestID 将COS服务端错误转换为 *config.ProviderError，使错误信息带上 request_id，
+// 原始错误仍可通过 errors.As 取得；其他错误原样返回
+func withRequestID(err error) error {
+	if d, ok := ErrorDetails(err); ok {
+		return d
+	}
+	return err
+}
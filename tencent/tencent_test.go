@@ -0,0 +1,297 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 08:44:30
+ * Description: 腾讯云COS上传器测试，使用本地HTTP服务模拟COS
+ */
+package tencent
+
+import (
+	"context"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// newTestUploader 创建指向本地模拟服务的上传器，存储桶与批量处理接口都由handler处理
+func newTestUploader(t *testing.T, handler http.HandlerFunc, cfg config.TencentConfig) *TencentUploader {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg.SecretID, cfg.SecretKey = "test-id", "test-secret"
+	cfg.BucketName, cfg.Region = "test-1250000000", "ap-guangzhou"
+	u, _ := url.Parse(server.URL)
+	up, err := newUploader(cfg, &cos.BaseURL{BucketURL: u, BatchURL: u})
+	assert.NoError(t, err)
+	return up
+}
+
+// putCRC64 设置简单上传响应的CRC64校验值，SDK上传后会与本地计算的值比对
+func putCRC64(w http.ResponseWriter, body []byte) {
+	w.Header().Set("x-cos-hash-crc64ecma", strconv.FormatUint(crc64.Checksum(body, crc64.MakeTable(crc64.ECMA)), 10))
+}
+
+// batchServer 模拟批量处理：记录清单的上传与删除，按statuses依次返回任务状态
+type batchServer struct {
+	mu       sync.Mutex
+	manifest string // 上传的清单内容
+	created  string // 创建任务的请求体
+	deleted  []string
+	statuses []string
+	describe int
+}
+
+func (s *batchServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead:
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/"+batchManifestPrefix):
+		s.manifest = string(body)
+		putCRC64(w, body)
+		w.Header().Set("ETag", `"manifest-etag"`)
+	case r.Method == http.MethodDelete:
+		s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/jobs":
+		s.created = string(body)
+		fmt.Fprint(w, `<CreateJobResult><JobId>job-1</JobId></CreateJobResult>`)
+	case r.Method == http.MethodGet && r.URL.Path == "/jobs/job-1":
+		if len(s.statuses) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>describe failed</Message></Error>`)
+			return
+		}
+		status := s.statuses[min(s.describe, len(s.statuses)-1)]
+		s.describe++
+		fmt.Fprintf(w, `<DescribeJobResult><Job><JobId>job-1</JobId><Status>%s</Status>`+
+			`<FailureReasons><JobFailure><FailureCode>ManifestInvalid</FailureCode><FailureReason>bad manifest</FailureReason></JobFailure></FailureReasons>`+
+			`<ProgressSummary><NumberOfTasksSucceeded>2</NumberOfTasksSucceeded><NumberOfTasksFailed>1</NumberOfTasksFailed><TotalNumberOfTasks>3</TotalNumberOfTasks></ProgressSummary>`+
+			`</Job></DescribeJobResult>`, status)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// 测试批量处理：上传清单、创建任务、轮询到结束，各种结束方式下都删除清单
+func TestBatchCosOperation(t *testing.T) {
+	cfg := config.TencentConfig{BatchRoleArn: "qcs::cam::uin/100000000001:roleName/COSBatch", BatchPollInterval: 10 * time.Millisecond}
+
+	t.Run("complete", func(t *testing.T) {
+		s := &batchServer{statuses: []string{"Preparing", "Active", "Complete"}}
+		up := newTestUploader(t, s.handle, cfg)
+
+		result, err := up.BatchCosOperation(context.Background(), []string{"a b.txt", "dir/c.txt"}, COSCopyOp{DstPrefix: "backup/"})
+		assert.NoError(t, err)
+		assert.Equal(t, COSBatchResult{JobID: "job-1", SucceededCount: 2, FailedCount: 1}, result)
+		assert.Equal(t, 3, s.describe)
+
+		assert.Equal(t, "test-1250000000,a%20b.txt\ntest-1250000000,dir%2Fc.txt\n", s.manifest)
+		assert.Contains(t, s.created, "<ETag>manifest-etag</ETag>")
+		assert.Contains(t, s.created, "<ObjectArn>qcs::cos:ap-guangzhou:uid/1250000000:test-1250000000/"+batchManifestPrefix)
+		assert.Contains(t, s.created, "<TargetResource>qcs::cos:ap-guangzhou:uid/1250000000:test-1250000000</TargetResource>")
+		assert.Contains(t, s.created, "<TargetKeyPrefix>backup/</TargetKeyPrefix>")
+		assert.Contains(t, s.created, "<RoleArn>"+cfg.BatchRoleArn+"</RoleArn>")
+		assert.Len(t, s.deleted, 1)
+		assert.True(t, strings.HasPrefix(s.deleted[0], batchManifestPrefix), s.deleted[0])
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		s := &batchServer{statuses: []string{"Active", "Failed"}}
+		up := newTestUploader(t, s.handle, cfg)
+
+		result, err := up.BatchCosOperation(context.Background(), []string{"a.txt"}, COSCopyOp{})
+		assert.ErrorContains(t, err, "ManifestInvalid: bad manifest")
+		assert.Equal(t, "job-1", result.JobID)
+		assert.Len(t, s.deleted, 1)
+	})
+
+	t.Run("describe error", func(t *testing.T) {
+		s := &batchServer{}
+		up := newTestUploader(t, s.handle, cfg)
+
+		result, err := up.BatchCosOperation(context.Background(), []string{"a.txt"}, COSCopyOp{})
+		assert.ErrorContains(t, err, "failed to describe COS batch job job-1")
+		assert.Equal(t, "job-1", result.JobID)
+		assert.Len(t, s.deleted, 1)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		s := &batchServer{statuses: []string{"Active"}}
+		up := newTestUploader(t, s.handle, cfg)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		result, err := up.BatchCosOperation(ctx, []string{"a.txt"}, COSCopyOp{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, "job-1", result.JobID)
+		assert.Len(t, s.deleted, 1)
+	})
+
+	t.Run("delete op", func(t *testing.T) {
+		s := &batchServer{}
+		up := newTestUploader(t, s.handle, cfg)
+
+		_, err := up.BatchCosOperation(context.Background(), []string{"a.txt"}, COSDeleteOp{})
+		assert.ErrorIs(t, err, config.ErrNotSupported)
+		assert.Empty(t, s.manifest)
+		assert.Empty(t, s.deleted)
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		up := newTestUploader(t, (&batchServer{}).handle, config.TencentConfig{})
+		_, err := up.BatchCosOperation(context.Background(), []string{"a.txt"}, COSCopyOp{})
+		assert.ErrorContains(t, err, "BatchRoleArn")
+	})
+}
+
+// 测试未配置超时时使用默认值，响应慢于请求超时时返回错误而不是一直阻塞
+func TestTimeouts(t *testing.T) {
+	up := newTestUploader(t, func(w http.ResponseWriter, r *http.Request) {}, config.TencentConfig{})
+	assert.Equal(t, 30*time.Second, up.config.RequestTimeout)
+	assert.Equal(t, 10*time.Second, up.config.DialTimeout)
+
+	up = newTestUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			time.Sleep(500 * time.Millisecond)
+		}
+	}, config.TencentConfig{RequestTimeout: 100 * time.Millisecond, DialTimeout: time.Second})
+	assert.Equal(t, time.Second, up.config.DialTimeout)
+	start := time.Now()
+	_, err := up.UploadBinary("a.txt", []byte("data"))
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+// 测试生命周期规则的设置、读取与删除，未配置时返回空切片，设置空规则等同于删除
+func TestLifecycleRules(t *testing.T) {
+	var stored []byte
+	var methods []string
+	up := newTestUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["lifecycle"]; !ok {
+			return
+		}
+		methods = append(methods, r.Method)
+		switch r.Method {
+		case http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `<Error><Code>NoSuchLifecycleConfiguration</Code><Message>not found</Message></Error>`)
+				return
+			}
+			w.Write(stored)
+		case http.MethodDelete:
+			stored = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, config.TencentConfig{})
+	ctx := context.Background()
+
+	rules, err := up.GetLifecycleRules(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, rules)
+	assert.Empty(t, rules)
+
+	want := []cos.BucketLifecycleRule{{
+		ID:         "expire-tmp",
+		Status:     "Enabled",
+		Filter:     &cos.BucketLifecycleFilter{Prefix: "tmp/"},
+		Expiration: &cos.BucketLifecycleExpiration{Days: 7},
+		Transition: []cos.BucketLifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}},
+	}}
+	assert.NoError(t, up.SetLifecycleRules(ctx, want))
+	assert.Contains(t, string(stored), "<Prefix>tmp/</Prefix>")
+	rules, err = up.GetLifecycleRules(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, want, rules)
+
+	// 空规则改为删除
+	assert.NoError(t, up.SetLifecycleRules(ctx, nil))
+	assert.Nil(t, stored)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPut, http.MethodGet, http.MethodDelete}, methods)
+}
+
+// 测试版本控制状态的切换与按版本删除，从未开启时返回 Unversioned
+func TestVersioning(t *testing.T) {
+	status := ""
+	var deleted []string
+	up := newTestUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, versioning := r.URL.Query()["versioning"]
+		switch {
+		case versioning && r.Method == http.MethodPut:
+			_, rest, _ := strings.Cut(string(body), "<Status>")
+			status, _, _ = strings.Cut(rest, "</Status>")
+		case versioning && r.Method == http.MethodGet:
+			if status == "" {
+				fmt.Fprint(w, `<VersioningConfiguration/>`)
+				return
+			}
+			fmt.Fprintf(w, `<VersioningConfiguration><Status>%s</Status></VersioningConfiguration>`, status)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path+"@"+r.URL.Query().Get("VersionId"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, config.TencentConfig{})
+	ctx := context.Background()
+
+	got, err := up.GetVersioningStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, VersioningUnversioned, got)
+
+	assert.NoError(t, up.EnableVersioning(ctx))
+	got, err = up.GetVersioningStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, VersioningEnabled, got)
+
+	assert.NoError(t, up.SuspendVersioning(ctx))
+	got, err = up.GetVersioningStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, VersioningSuspended, got)
+
+	assert.NoError(t, up.DeleteVersion(ctx, "docs/a.txt", "v1"))
+	assert.Equal(t, []string{"/docs/a.txt@v1"}, deleted)
+	assert.Error(t, up.DeleteVersion(ctx, "", "v1"))
+	assert.Error(t, up.DeleteVersion(ctx, "docs/a.txt", ""))
+	assert.Len(t, deleted, 1)
+}
+
+// 测试范围下载，length为0时不发起请求
+func TestDownloadRange(t *testing.T) {
+	var ranges []string
+	up := newTestUploader(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Content-Range", "bytes 2-4/10")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, "234")
+	}, config.TencentConfig{})
+
+	body, err := up.DownloadRange(context.Background(), "a.txt", 2, 3)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "234", string(data))
+
+	body, err = up.DownloadRange(context.Background(), "a.txt", 5, 0)
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(body)
+	assert.Empty(t, data)
+	assert.Equal(t, []string{"bytes=2-4"}, ranges)
+}
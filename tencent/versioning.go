@@ -0,0 +1,69 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 02:58:31
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 02:58:31
+ * Description: COS存储桶版本控制与按版本删除
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package tencent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 存储桶版本控制状态
+const (
+	VersioningEnabled     = "Enabled"     // 已开启，覆盖与删除会保留历史版本
+	VersioningSuspended   = "Suspended"   // 已暂停，新写入的对象不再产生历史版本，已有版本保留
+	VersioningUnversioned = "Unversioned" // 从未开启过
+)
+
+// EnableVersioning 开启存储桶版本控制，开启后无法回到 Unversioned，只能暂停
+func (u *TencentUploader) EnableVersioning(ctx context.Context) error {
+	return u.putVersioning(ctx, VersioningEnabled)
+}
+
+// SuspendVersioning 暂停存储桶版本控制，已有的历史版本不会被删除
+func (u *TencentUploader) SuspendVersioning(ctx context.Context) error {
+	return u.putVersioning(ctx, VersioningSuspended)
+}
+
+func (u *TencentUploader) putVersioning(ctx context.Context, status string) error {
+	if _, err := u.client.Bucket.PutVersioning(ctx, &cos.BucketPutVersionOptions{Status: status}); err != nil {
+		return fmt.Errorf("failed to put COS versioning: %w", err)
+	}
+	return nil
+}
+
+// GetVersioningStatus 返回存储桶版本控制状态：VersioningEnabled、VersioningSuspended 或 VersioningUnversioned
+func (u *TencentUploader) GetVersioningStatus(ctx context.Context) (string, error) {
+	result, _, err := u.client.Bucket.GetVersioning(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get COS versioning: %w", err)
+	}
+	// 从未开启过版本控制时COS返回空的Status
+	if result.Status == "" {
+		return VersioningUnversioned, nil
+	}
+	return result.Status, nil
+}
+
+// DeleteVersion 永久删除对象的指定版本
+// 与 Delete 不同，不会产生删除标记，删除的是最新版本时上一个版本成为当前版本
+func (u *TencentUploader) DeleteVersion(ctx context.Context, key, versionID string) error {
+	if key == "" {
+		return errors.New("object key cannot be empty")
+	}
+	if versionID == "" {
+		return errors.New("version id cannot be empty")
+	}
+	if _, err := u.client.Object.Delete(ctx, key, &cos.ObjectDeleteOptions{VersionId: versionID}); err != nil {
+		return fmt.Errorf("failed to delete COS object version: %w", err)
+	}
+	return nil
+}
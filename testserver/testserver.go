@@ -0,0 +1,264 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:20:37
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:20:37
+ * Description: 本地对象存储测试服务，用于在没有云凭证的CI中经过完整的HTTP路径测试上传
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package testserver
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	uploader "github.com/zjguoxin/gosuploader"
+	"github.com/zjguoxin/gosuploader/aliyun"
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// Bucket 测试服务中的存储空间名称
+const Bucket = "test-bucket"
+
+// crcTable OSS返回的 x-oss-hash-crc64ecma 使用的CRC64表
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// object 保存在测试服务中的对象
+type object struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// TestServer 在本地监听的对象存储服务，实现OSS(S3风格)接口的最小子集:
+// PUT(含服务端复制)、GET、HEAD、DELETE 与 ListObjectsV2，对象保存在内存中，不校验签名
+// 不支持分片上传，超过分片阈值的上传会失败
+type TestServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+// NewTestServer 启动测试服务并返回连接到该服务的上传器，使用完毕后调用 Close 关闭服务
+// 上传器为阿里云OSS后端(路径风格访问)，返回的文件URL可直接通过测试服务下载
+func NewTestServer() (*TestServer, uploader.Uploader) {
+	s := &TestServer{objects: make(map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	up, err := aliyun.New(config.AliyunConfig{
+		Endpoint:        s.URL,
+		AccessKeyID:     "test-id",
+		AccessKeySecret: "test-secret",
+		BucketName:      Bucket,
+		ForcePathStyle:  true,
+	})
+	if err != nil {
+		s.Close()
+		panic(fmt.Sprintf("testserver: failed to create uploader: %v", err))
+	}
+	return s, up
+}
+
+// Files 返回当前保存的所有对象，key为对象key，返回的是副本
+func (s *TestServer) Files() map[string][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := make(map[string][]byte, len(s.objects))
+	for key, obj := range s.objects {
+		files[key] = append([]byte(nil), obj.data...)
+	}
+	return files
+}
+
+// serveHTTP 按请求路径 /<bucket>/<key> 分发请求
+func (s *TestServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+	if _, ok := r.URL.Query()["uploads"]; ok || r.URL.Query().Has("uploadId") {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "Multipart upload is not supported by the test server.")
+		return
+	}
+	if key == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+			return
+		}
+		s.list(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Oss-Copy-Source"); src != "" {
+			s.copy(w, src, key)
+			return
+		}
+		s.put(w, r, key)
+	case http.MethodGet, http.MethodHead:
+		s.get(w, r, key)
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
+
+// put 保存请求体为对象
+func (s *TestServer) put(w http.ResponseWriter, r *http.Request, key string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	obj := object{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+
+	s.mu.Lock()
+	s.objects[key] = obj
+	s.mu.Unlock()
+
+	setObjectHeaders(w, obj)
+	w.WriteHeader(http.StatusOK)
+}
+
+// copy 服务端复制，src为 /<bucket>/<key>，key部分经过URL编码
+func (s *TestServer) copy(w http.ResponseWriter, src, key string) {
+	src, err := url.PathUnescape(src)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "Invalid copy source.")
+		return
+	}
+	srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+
+	s.mu.Lock()
+	obj, ok := s.objects[srcKey]
+	if ok && srcBucket == Bucket {
+		obj.modTime = time.Now().UTC()
+		s.objects[key] = obj
+	}
+	s.mu.Unlock()
+	if !ok || srcBucket != Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		LastModified string   `xml:"LastModified"`
+		ETag         string   `xml:"ETag"`
+	}{LastModified: obj.modTime.Format(time.RFC3339), ETag: etag(obj.data)})
+}
+
+// get 返回对象内容，HEAD请求只返回响应头
+func (s *TestServer) get(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	obj, ok := s.objects[key]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	setObjectHeaders(w, obj)
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(obj.data)
+	}
+}
+
+// list 按prefix列出对象(ListObjectsV2)，一次返回全部结果，不支持delimiter
+func (s *TestServer) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+	var contents []content
+
+	s.mu.Lock()
+	for key, obj := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			contents = append(contents, content{
+				Key:          key,
+				LastModified: obj.modTime.Format(time.RFC3339),
+				ETag:         etag(obj.data),
+				Size:         len(obj.data),
+				StorageClass: "Standard",
+			})
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(contents, func(i, j int) bool { return contents[i].Key < contents[j].Key })
+
+	writeXML(w, struct {
+		XMLName     xml.Name  `xml:"ListBucketResult"`
+		Name        string    `xml:"Name"`
+		Prefix      string    `xml:"Prefix"`
+		MaxKeys     int       `xml:"MaxKeys"`
+		KeyCount    int       `xml:"KeyCount"`
+		IsTruncated bool      `xml:"IsTruncated"`
+		Contents    []content `xml:"Contents"`
+	}{Name: Bucket, Prefix: prefix, MaxKeys: len(contents), KeyCount: len(contents), Contents: contents})
+}
+
+// setObjectHeaders 设置对象的ETag、类型、修改时间与CRC64响应头
+func setObjectHeaders(w http.ResponseWriter, obj object) {
+	h := w.Header()
+	h.Set("ETag", etag(obj.data))
+	h.Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+	h.Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(obj.data, crcTable), 10))
+	if obj.contentType != "" {
+		h.Set("Content-Type", obj.contentType)
+	}
+}
+
+// etag 返回与OSS普通上传一致的ETag(内容MD5的大写十六进制，带引号)
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + strings.ToUpper(hex.EncodeToString(sum[:])) + `"`
+}
+
+// writeXML 以200状态码返回XML响应体
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+// writeError 返回OSS格式的错误响应
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		RequestID string   `xml:"RequestId"`
+	}{Code: code, Message: message, RequestID: "testserver"})
+}
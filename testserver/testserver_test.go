@@ -0,0 +1,58 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:26:10
+ * Description: 测试服务测试
+ */
+package testserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zjguoxin/gosuploader/aliyun"
+)
+
+// 测试经过测试服务的上传、下载、复制、列举与删除
+func TestTestServer(t *testing.T) {
+	server, up := NewTestServer()
+	defer server.Close()
+
+	url, err := up.UploadBinary("hello.txt", []byte("hello world"))
+	assert.NoError(t, err)
+	files := server.Files()
+	assert.Len(t, files, 1)
+	var key string
+	for k, data := range files {
+		key = k
+		assert.Equal(t, "hello world", string(data))
+	}
+
+	// 返回的URL可以直接从测试服务下载
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello world", string(body))
+
+	assert.NoError(t, up.Copy(context.Background(), key, "copy/hello.txt"))
+	assert.Equal(t, "hello world", string(server.Files()["copy/hello.txt"]))
+
+	ali := up.(*aliyun.AliUploader)
+	keys, err := ali.List("copy/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"copy/hello.txt"}, keys)
+
+	exists, err := ali.Exists(key)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, up.Delete(key))
+	exists, err = ali.Exists(key)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Len(t, server.Files(), 1)
+}
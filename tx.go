@@ -0,0 +1,129 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 05:58:06
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 05:58:06
+ * Description: 跟踪上传的对象，后续步骤失败时统一删除
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime/multipart"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// Transaction 记录经由它上传或复制的对象，Rollback删除这些对象，Commit保留并清空记录
+//
+// 用于"上传后还有其他步骤"的处理函数：上传成功但后续步骤失败时调用Rollback，全部成功时调用Commit。
+// 只记录本次实际写入的存储key(取自审计记录)，去重命中已有对象等未写入的上传不会被删除。
+// Commit或Rollback之后可继续使用，开始新的一轮记录
+type Transaction struct {
+	Uploader
+
+	mu   sync.Mutex
+	keys []string
+}
+
+// Tx 以上传器创建事务
+func Tx(u Uploader) *Transaction {
+	return &Transaction{Uploader: u}
+}
+
+// UploadFile 上传multipart文件并记录
+func (t *Transaction) UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error) {
+	return t.Uploader.UploadFile(file, t.track(opts)...)
+}
+
+// UploadBinary 上传二进制数据并记录
+func (t *Transaction) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
+	return t.Uploader.UploadBinary(filename, content, t.track(opts)...)
+}
+
+// UploadBase64 上传Base64数据并记录
+func (t *Transaction) UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error) {
+	return t.Uploader.UploadBase64(filename, base64Str, t.track(opts)...)
+}
+
+// UploadFSFile 上传 fs.File 并记录
+func (t *Transaction) UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (UploadResult, error) {
+	return t.Uploader.UploadFSFile(ctx, f, key, t.track(opts)...)
+}
+
+// Copy 复制对象并记录目标key
+func (t *Transaction) Copy(ctx context.Context, srcKey, dstKey string) error {
+	if err := t.Uploader.Copy(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	t.add(dstKey)
+	return nil
+}
+
+// Keys 返回当前记录的存储key，按写入顺序排列
+func (t *Transaction) Keys() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.keys...)
+}
+
+// Commit 保留已上传的对象并清空记录
+func (t *Transaction) Commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keys = nil
+}
+
+// Rollback 按写入的相反顺序删除记录的全部对象
+// 删除是尽力而为的：某个对象删除失败时继续删除其余对象，错误汇总返回，删除失败的key保留在记录中以便重试
+func (t *Transaction) Rollback() error {
+	t.mu.Lock()
+	keys := t.keys
+	t.keys = nil
+	t.mu.Unlock()
+
+	var (
+		errs   []error
+		remain []string
+	)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := t.Uploader.Delete(keys[i]); err != nil {
+			errs = append(errs, fmt.Errorf("rollback %s: %w", keys[i], err))
+			remain = append([]string{keys[i]}, remain...)
+		}
+	}
+	if len(remain) > 0 {
+		t.mu.Lock()
+		t.keys = append(remain, t.keys...)
+		t.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// track 追加记录存储key的审计回调，保留调用方已设置的回调
+func (t *Transaction) track(opts []config.UploadOption) []config.UploadOption {
+	o, err := config.NewUploadOptions(opts...)
+	if err != nil {
+		// 选项无效时上传会返回同样的错误
+		return opts
+	}
+	sink := config.WithAuditSink(func(r config.AuditRecord) {
+		t.add(r.Key)
+		if o.AuditSink != nil {
+			o.AuditSink(r)
+		}
+	})
+	return append(opts[:len(opts):len(opts)], sink)
+}
+
+// add 记录一个存储key
+func (t *Transaction) add(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keys = append(t.keys, key)
+}
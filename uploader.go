@@ -9,21 +9,62 @@
 package uploader
 
 import (
+	"context"
 	"errors"
+	"io/fs"
 	"mime/multipart"
 
 	"github.com/zjguoxin/gosuploader/aliyun"
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/keygen"
 	"github.com/zjguoxin/gosuploader/local"
 	"github.com/zjguoxin/gosuploader/qiniu"
 	"github.com/zjguoxin/gosuploader/tencent"
 )
 
 var (
-	ErrInvalidConfig   = errors.New("invalid config for uploader")
-	ErrUnsupportedType = errors.New("unsupported uploader type")
+	ErrInvalidConfig        = errors.New("invalid config for uploader")
+	ErrUnsupportedType      = errors.New("unsupported uploader type")
+	ErrInvalidHeader        = config.ErrInvalidHeader
+	ErrNotSupported         = config.ErrNotSupported
+	ErrNotFound             = config.ErrNotFound
+	ErrInvalidPrefix        = config.ErrInvalidPrefix
+	ErrInvalidFilename      = config.ErrInvalidFilename
+	ErrFileTypeMismatch     = config.ErrFileTypeMismatch
+	ErrInvalidKey           = config.ErrInvalidKey
+	ErrFileTooLarge         = config.ErrFileTooLarge
+	ErrInvalidRetention     = config.ErrInvalidRetention
+	ErrObjectLocked         = config.ErrObjectLocked
+	ErrConfigWarning        = config.ErrConfigWarning
+	ErrInvalidTag           = config.ErrInvalidTag
+	ErrTooManyCollisions    = config.ErrTooManyCollisions
+	ErrFileTypeForbidden    = config.ErrFileTypeForbidden
+	ErrInvalidSignature     = config.ErrInvalidSignature
+	ErrSignatureExpired     = config.ErrSignatureExpired
+	ErrChecksumMismatch     = config.ErrChecksumMismatch
+	ErrInvalidEncryptionKey = config.ErrInvalidEncryptionKey
+	ErrIsDirectory          = config.ErrIsDirectory
+	ErrVerificationFailed   = config.ErrVerificationFailed
+	ErrContentRejected      = config.ErrContentRejected
+	ErrUploadAborted        = config.ErrUploadAborted
+	ErrNotModified          = config.ErrNotModified
+	ErrObjectExists         = config.ErrObjectExists
 )
 
+// ProviderError 云存储服务返回的错误详情
+type ProviderError = config.ProviderError
+
+// ErrorDetails 从上传器返回的错误中提取服务商的错误码、错误信息、请求ID与HTTP状态码
+// 支持七牛云、阿里云OSS与腾讯云COS的服务端错误，err不包含这些错误(如本地存储或网络错误)时返回false
+func ErrorDetails(err error) (*ProviderError, bool) {
+	for _, details := range []func(error) (*ProviderError, bool){aliyun.ErrorDetails, tencent.ErrorDetails, qiniu.ErrorDetails} {
+		if d, ok := details(err); ok {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
 type UploadType string
 
 const (
@@ -34,11 +75,21 @@ const (
 )
 
 // Uploader 统一上传接口
+// 上传方法均可附加 config.UploadOption 选项，例如 config.WithHeader
 type Uploader interface {
-	UploadFile(file *multipart.FileHeader) (string, error)
-	UploadBinary(filename string, content []byte) (string, error)
-	UploadBase64(filename string, base64Str string) (string, error)
+	UploadFile(file *multipart.FileHeader, opts ...config.UploadOption) (string, error)
+	UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error)
+	UploadBase64(filename string, base64Str string, opts ...config.UploadOption) (string, error)
+	// UploadFSFile 上传 fs.File(如 os.DirFS、embed.FS 中的文件)，用于没有 multipart.FileHeader 的非HTTP场景
+	// 通过Stat获取大小与文件名并嗅探内容类型，key为空时按文件名生成；f为目录时返回 ErrIsDirectory
+	UploadFSFile(ctx context.Context, f fs.File, key string, opts ...config.UploadOption) (UploadResult, error)
 	Delete(filepath string) error
+	// Copy 在同一存储后端内复制对象，云存储使用服务端复制，不经过本地中转
+	// srcKey与dstKey均会规范化，为空或非法时返回 ErrInvalidKey，源对象不存在时返回 ErrNotFound
+	Copy(ctx context.Context, srcKey, dstKey string) error
+	// Count 返回前缀下的对象数量，用于分页展示总数
+	// 除阿里云OSS在前缀为空时使用存储空间统计外，都需要逐页列举或遍历目录，耗时与对象数成正比
+	Count(ctx context.Context, prefix string) (int64, error)
 }
 
 // NewUploader 创建上传器
@@ -47,35 +98,56 @@ type Uploader interface {
 //   - cfg: 是对应的配置结构体
 //
 // 返回:
-//   - Uploader 实例
+//   - Uploader 实例，配置了 DeduplicationStore 时为 *Deduplicator
 //   - error 如果创建失败，返回错误信息
 func NewUploader(t UploadType, cfg interface{}) (Uploader, error) {
 	switch t {
 	case Local:
 		localCfg, ok := cfg.(config.LocalConfig)
-		if !ok {
+		if !ok || !keygen.ValidStrategy(localCfg.KeyStrategy) || !keygen.ValidSeparator(localCfg.KeySeparator) ||
+			localCfg.RoutingRules.Validate() != nil {
 			return nil, ErrInvalidConfig
 		}
-		return local.New(localCfg), nil
+		return deduplicate(local.New(localCfg), localCfg.DeduplicationStore), nil
 	case Qiniu:
 		qiniuCfg, ok := cfg.(config.QiniuConfig)
 		if !ok {
 			return nil, ErrInvalidConfig
 		}
-		return qiniu.New(qiniuCfg)
+		up, err := qiniu.New(qiniuCfg)
+		if err != nil {
+			return nil, err
+		}
+		return deduplicate(up, qiniuCfg.DeduplicationStore), nil
 	case Aliyun:
 		aliCfg, ok := cfg.(config.AliyunConfig)
 		if !ok {
 			return nil, ErrInvalidConfig
 		}
-		return aliyun.New(aliCfg)
+		up, err := aliyun.New(aliCfg)
+		if err != nil {
+			return nil, err
+		}
+		return deduplicate(up, aliCfg.DeduplicationStore), nil
 	case Tencent:
 		txCfg, ok := cfg.(config.TencentConfig)
 		if !ok {
 			return nil, ErrInvalidConfig
 		}
-		return tencent.New(txCfg)
+		up, err := tencent.New(txCfg)
+		if err != nil {
+			return nil, err
+		}
+		return deduplicate(up, txCfg.DeduplicationStore), nil
 	default:
 		return nil, ErrUnsupportedType
 	}
 }
+
+// deduplicate 配置了去重索引时以 Deduplicator 包装上传器
+func deduplicate(u Uploader, store DeduplicationStore) Uploader {
+	if store == nil {
+		return u
+	}
+	return NewDeduplicator(u, store)
+}
@@ -7,29 +7,45 @@ package uploader_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	uploader "github.com/zjguoxin/gosuploader"
 	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/local"
 )
 
 // 测试辅助函数：创建一个模拟的multipart.FileHeader
 func createTestFile(t *testing.T, filename string) *multipart.FileHeader {
+	return createTestFileWithContent(t, filename, []byte("test file content"))
+}
+
+// 测试辅助函数：创建指定内容的multipart.FileHeader
+func createTestFileWithContent(t *testing.T, filename string, content []byte) *multipart.FileHeader {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
 	part, err := writer.CreateFormFile("file", filename)
 	assert.NoError(t, err)
 
-	_, err = io.WriteString(part, "test file content")
+	_, err = part.Write(content)
 	assert.NoError(t, err)
 
 	err = writer.Close()
@@ -83,6 +99,120 @@ func TestLocalUploader(t *testing.T) {
 		assert.FileExists(t, filepath.Join(testDir, path))
 	})
 
+	// 测试存储用量统计
+	t.Run("BucketUsage", func(t *testing.T) {
+		used, count, err := uploader.BucketUsage(up)
+		assert.NoError(t, err)
+		assert.Greater(t, used, int64(0))
+		assert.Greater(t, count, int64(0))
+	})
+
+	// 测试上传审计
+	t.Run("AuditSink", func(t *testing.T) {
+		var records []config.AuditRecord
+		sink := func(r config.AuditRecord) { records = append(records, r) }
+
+		path, err := up.UploadBinary("audit.txt", []byte("test data"),
+			config.WithAuditSink(sink), config.WithActor("alice"), config.WithRequestID("req-42"))
+		assert.NoError(t, err)
+		if assert.Len(t, records, 1) {
+			assert.Equal(t, path, records[0].Key)
+			assert.Equal(t, int64(9), records[0].Size)
+			assert.Equal(t, "alice", records[0].Actor)
+			assert.Equal(t, "req-42", records[0].RequestID)
+			// sha256("test data")
+			assert.Equal(t, "916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9", records[0].SHA256)
+		}
+	})
+
+	// 测试覆盖文件名
+	t.Run("WithFilename", func(t *testing.T) {
+		path, err := up.UploadFile(createTestFile(t, "blob"), config.WithFilename("../uploads/report.pdf"))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(filepath.Base(path), "report_"), path)
+		assert.Equal(t, ".pdf", filepath.Ext(path))
+
+		_, err = up.UploadBinary("a.txt", []byte("x"), config.WithFilename("dir/.."))
+		assert.ErrorIs(t, err, uploader.ErrInvalidFilename)
+	})
+
+	// 测试文件头魔数校验
+	t.Run("StrictTypeValidation", func(t *testing.T) {
+		png := []byte("\x89PNG\r\n\x1a\n")
+
+		_, err := up.UploadBinary("photo.jpg", png, config.WithStrictTypeValidation())
+		assert.ErrorIs(t, err, uploader.ErrFileTypeMismatch)
+		assert.Contains(t, err.Error(), "extension .jpg does not match detected type image/png")
+
+		path, err := up.UploadBinary("photo.png", png, config.WithStrictTypeValidation())
+		assert.NoError(t, err)
+		saved, err := os.ReadFile(filepath.Join(testDir, path))
+		assert.NoError(t, err)
+		assert.Equal(t, png, saved)
+	})
+
+	// 测试读取文件开头
+	t.Run("Head", func(t *testing.T) {
+		reader, ok := up.(uploader.HeadReader)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		path, err := up.UploadBinary("head.txt", []byte("0123456789"))
+		assert.NoError(t, err)
+		key := filepath.ToSlash(path)
+
+		head, err := reader.Head(key, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("0123"), head)
+
+		head, err = reader.Head(key, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("0123456789"), head)
+
+		_, err = reader.Head("missing.txt", 4)
+		assert.ErrorIs(t, err, uploader.ErrNotFound)
+	})
+
+	// 测试复制文件
+	t.Run("Copy", func(t *testing.T) {
+		path, err := up.UploadBinary("copy.txt", []byte("copy me"))
+		assert.NoError(t, err)
+
+		err = up.Copy(context.Background(), "/"+filepath.ToSlash(path), "copies//copy.txt")
+		assert.NoError(t, err)
+		saved, err := os.ReadFile(filepath.Join(testDir, "copies", "copy.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "copy me", string(saved))
+		assert.FileExists(t, filepath.Join(testDir, path))
+
+		err = up.Copy(context.Background(), "missing.txt", "copies/missing.txt")
+		assert.ErrorIs(t, err, uploader.ErrNotFound)
+
+		err = up.Copy(context.Background(), "", "copies/empty.txt")
+		assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+		err = up.Copy(context.Background(), path, "../outside.txt")
+		assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+	})
+
+	// 测试批量移动前缀
+	t.Run("MovePrefix", func(t *testing.T) {
+		path, err := up.UploadBinary("tomove.txt", []byte("move me"), config.WithHeader("x-test", "1"))
+		assert.NoError(t, err)
+		oldPrefix := filepath.ToSlash(filepath.Dir(path)) + "/"
+
+		moved, err := uploader.MovePrefix(up, oldPrefix, "archive/")
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, moved, 1)
+		assert.FileExists(t, filepath.Join(testDir, "archive", filepath.Base(path)))
+		assert.NoFileExists(t, filepath.Join(testDir, path))
+
+		_, err = uploader.MovePrefix(up, "", "archive/")
+		assert.ErrorIs(t, err, uploader.ErrInvalidPrefix)
+		_, err = uploader.MovePrefix(listerOnly{Uploader: up, lister: up.(uploader.Lister)}, oldPrefix, "archive/")
+		assert.ErrorIs(t, err, uploader.ErrNotSupported)
+	})
+
 	// 测试删除文件
 	t.Run("Delete", func(t *testing.T) {
 		path, err := up.UploadBinary("todelete.txt", []byte("to be deleted"))
@@ -296,17 +426,343 @@ func TestTencentUploader(t *testing.T) {
 	})
 }
 
+// 测试单目录文件数上限滚动
+func TestLocalMaxFilesPerDir(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := config.LocalConfig{BasePath: baseDir, MaxFilesPerDir: 2}
+	up, err := uploader.NewUploader(uploader.Local, cfg)
+	assert.NoError(t, err)
+
+	var paths []string
+	for i := 0; i < 5; i++ {
+		path, err := up.UploadBinary("a.txt", []byte("data"))
+		assert.NoError(t, err)
+		paths = append(paths, path)
+	}
+
+	dateDir := filepath.FromSlash(time.Now().Format("2006/01/02"))
+	for i, want := range []string{"00", "00", "01", "01", "02"} {
+		assert.Equal(t, filepath.Join(dateDir, want), filepath.Dir(paths[i]))
+	}
+
+	// 新实例从磁盘恢复滚动状态，继续填充未满的子目录
+	up, err = uploader.NewUploader(uploader.Local, cfg)
+	assert.NoError(t, err)
+	path, err := up.UploadBinary("a.txt", []byte("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dateDir, "02"), filepath.Dir(path))
+
+	// 删除与查询使用完整key，不受滚动影响
+	exists, err := up.(uploader.ObjectInspector).Exists(filepath.ToSlash(path))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, up.Delete(path))
+}
+
+// 测试日期目录缓存在目录被外部删除后仍能正常上传
+func TestLocalDateDirCache(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+
+	dateDir := filepath.Join(baseDir, filepath.FromSlash(time.Now().Format("2006/01/02")))
+	assert.NoError(t, up.Warm())
+	assert.DirExists(t, dateDir)
+
+	_, err := up.UploadBinary("a.txt", []byte("data"))
+	assert.NoError(t, err)
+
+	// 外部删除目录后，缓存失效并重新创建
+	assert.NoError(t, os.RemoveAll(filepath.Join(baseDir, time.Now().Format("2006"))))
+	path, err := up.UploadBinary("a.txt", []byte("data"))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(baseDir, path))
+
+	// 并发上传共用同一缓存
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := up.UploadBinary("b.txt", []byte("data"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// 测试同一key的并发上传串行执行，内容不会交错
+func TestConcurrentUploadSameKey(t *testing.T) {
+	baseDir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: baseDir})
+	assert.NoError(t, err)
+
+	// 长度各不相同的内容，交错写入会产生与任一内容都不相同的结果
+	// 使用表单文件上传，内容会分块写入，扩大交错的窗口
+	contents := make([][]byte, 8)
+	files := make([]*multipart.FileHeader, len(contents))
+	for i := range contents {
+		contents[i] = bytes.Repeat([]byte{byte('a' + i)}, (i+1)*256*1024)
+		files[i] = createTestFileWithContent(t, "same.bin", contents[i])
+	}
+
+	for round := 0; round < 20; round++ {
+		var wg sync.WaitGroup
+		for _, file := range files {
+			wg.Add(1)
+			go func(file *multipart.FileHeader) {
+				defer wg.Done()
+				_, err := up.UploadFile(file, config.WithKey("fixed/same.bin"))
+				assert.NoError(t, err)
+			}(file)
+		}
+		wg.Wait()
+
+		saved, err := os.ReadFile(filepath.Join(baseDir, "fixed", "same.bin"))
+		assert.NoError(t, err)
+		matched := false
+		for _, content := range contents {
+			if bytes.Equal(saved, content) {
+				matched = true
+			}
+		}
+		if !assert.True(t, matched, "最终文件应完整等于某一次上传的内容") {
+			return
+		}
+	}
+}
+
+// 测试目录同步
+func TestSync(t *testing.T) {
+	srcDir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "css"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "index.html"), []byte("<html></html>"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "css", "site.css"), []byte("body{}"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "skip.tmp"), []byte("tmp"), 0644))
+
+	opts := uploader.SyncOptions{
+		Workers:       2,
+		DeleteOrphans: true,
+		Filter:        func(path string) bool { return !strings.HasSuffix(path, ".tmp") },
+	}
+	ctx := context.Background()
+
+	// 首次同步全部新增
+	result, err := uploader.Sync(ctx, srcDir, up, "site", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uploader.SyncResult{Added: 2}, result)
+
+	exists, err := up.(uploader.ObjectInspector).Exists("site/css/site.css")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// 再次同步无变化
+	result, err = uploader.Sync(ctx, srcDir, up, "site", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uploader.SyncResult{Unchanged: 2}, result)
+
+	// 修改与删除源文件
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "index.html"), []byte("<html>v2</html>"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(srcDir, "css", "site.css")))
+
+	// DryRun只统计不执行
+	dry := opts
+	dry.DryRun = true
+	result, err = uploader.Sync(ctx, srcDir, up, "site", dry)
+	assert.NoError(t, err)
+	assert.Equal(t, uploader.SyncResult{Updated: 1, Deleted: 1}, result)
+
+	var progress []uploader.SyncResult
+	opts.Progress = func(r uploader.SyncResult) { progress = append(progress, r) }
+	result, err = uploader.Sync(ctx, srcDir, up, "site", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uploader.SyncResult{Updated: 1, Deleted: 1}, result)
+	assert.Len(t, progress, 2)
+
+	exists, err = up.(uploader.ObjectInspector).Exists("site/css/site.css")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
 // 测试无效配置
 func TestInvalidConfig(t *testing.T) {
 	// 测试本地存储无效配置
 	_, err := uploader.NewUploader(uploader.Local, "invalid config")
 	assert.EqualError(t, err, uploader.ErrInvalidConfig.Error())
 
+	// 测试Base64解码大小限制
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir(), MaxBase64Length: 4})
+	assert.NoError(t, err)
+	_, err = up.UploadBase64("a.txt", "dGVzdCBkYXRh") // "test data"
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+	_, err = up.UploadBase64("a.txt", "dGVzdA==") // "test"
+	assert.NoError(t, err)
+
+	// 测试不支持的key生成策略
+	_, err = uploader.NewUploader(uploader.Local, config.LocalConfig{KeyStrategy: "random"})
+	assert.EqualError(t, err, uploader.ErrInvalidConfig.Error())
+
+	// 测试不支持的七牛云ZoneID，不会发起网络请求
+	_, err = uploader.NewUploader(uploader.Qiniu, config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "b", Domain: "cdn.example.com", ZoneID: "z9"})
+	assert.Error(t, err)
+	_, err = uploader.NewUploader(uploader.Qiniu, config.QiniuConfig{AccessKey: "ak", SecretKey: "sk", Bucket: "b", Domain: "cdn.example.com", ZoneID: "z1"})
+	assert.NoError(t, err)
+
 	// 测试不支持的存储类型
 	_, err = uploader.NewUploader("unsupported", nil)
 	assert.EqualError(t, err, uploader.ErrUnsupportedType.Error())
 }
 
+// 测试非法的自定义请求头
+func TestInvalidHeaderOption(t *testing.T) {
+	testDir := "./test_uploads_header"
+	defer os.RemoveAll(testDir)
+
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: testDir})
+	assert.NoError(t, err)
+
+	_, err = up.UploadBinary("header.txt", []byte("data"), config.WithHeader("bad header", "v"))
+	assert.ErrorIs(t, err, uploader.ErrInvalidHeader)
+
+	_, err = up.UploadBinary("header.txt", []byte("data"), config.WithHeader("x-oss-forbid-overwrite", "true\r\n"))
+	assert.ErrorIs(t, err, uploader.ErrInvalidHeader)
+
+	_, err = up.UploadBinary("header.txt", []byte("data"), config.UploadOptions{
+		ExtraHeaders: map[string]string{"x-oss-meta-bad name": "v"},
+	})
+	assert.ErrorIs(t, err, uploader.ErrInvalidHeader)
+
+	_, err = up.UploadBinary("header.txt", []byte("data"), config.WithHeader("x-oss-forbid-overwrite", "true"))
+	assert.NoError(t, err)
+}
+
+func TestRetentionOption(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+
+	// 非法的保留模式与已过去的截止时间
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithRetention("forever", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, err, uploader.ErrInvalidRetention)
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithRetention(config.RetentionCompliance, time.Now().Add(-time.Hour)))
+	assert.ErrorIs(t, err, uploader.ErrInvalidRetention)
+
+	// 本地存储不支持对象锁定，不能静默忽略
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithRetention("governance", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, err, uploader.ErrNotSupported)
+}
+
+// 测试来源标签与用户标签的写入、读取与隔离
+func TestProvenanceTags(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{
+		BasePath:       t.TempDir(),
+		ProvenanceTags: map[string]string{"service": "order-api", "version": "1.2.0"},
+	})
+	assert.NoError(t, err)
+
+	path, err := up.UploadBinary("a.txt", []byte("data"), config.WithTags(map[string]string{"team": "billing"}))
+	assert.NoError(t, err)
+	key := filepath.ToSlash(path)
+
+	tags, err := up.(uploader.TagReader).GetTags(key)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"team":               "billing",
+		"provenance-service": "order-api",
+		"provenance-version": "1.2.0",
+	}, tags)
+
+	// 用户标签不能使用来源标签前缀
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithTags(map[string]string{"provenance-service": "fake"}))
+	assert.ErrorIs(t, err, uploader.ErrInvalidTag)
+
+	// 旁路文件不会出现在列举结果中，移动后标签随文件移动
+	keys, err := up.(uploader.Lister).List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{key}, keys)
+	assert.NoError(t, up.(uploader.Mover).Move(key, "moved/a.txt"))
+	tags, err = up.(uploader.TagReader).GetTags("moved/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "order-api", tags["provenance-service"])
+
+	_, err = up.(uploader.TagReader).GetTags(key)
+	assert.ErrorIs(t, err, uploader.ErrNotFound)
+}
+
+// 测试轮询上传在后端间交替分配，并按记录路由删除
+func TestRoundRobin(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	var backends []uploader.Uploader
+	for _, dir := range dirs {
+		up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir})
+		assert.NoError(t, err)
+		backends = append(backends, up)
+	}
+
+	_, err := uploader.NewRoundRobin()
+	assert.ErrorIs(t, err, uploader.ErrInvalidConfig)
+
+	rr, err := uploader.NewRoundRobin(backends...)
+	assert.NoError(t, err)
+
+	var paths []string
+	for i := 0; i < 4; i++ {
+		path, err := rr.UploadBinary("a.txt", []byte("data"))
+		assert.NoError(t, err)
+		paths = append(paths, path)
+	}
+	for i, path := range paths {
+		assert.FileExists(t, filepath.Join(dirs[i%2], path))
+	}
+
+	// 按记录删除
+	assert.NoError(t, rr.Delete(paths[1]))
+	assert.NoFileExists(t, filepath.Join(dirs[1], paths[1]))
+
+	// 没有路由记录的路径依次尝试所有后端
+	rr2, err := uploader.NewRoundRobin(backends...)
+	assert.NoError(t, err)
+	assert.NoError(t, rr2.Delete(paths[3]))
+	assert.NoFileExists(t, filepath.Join(dirs[1], paths[3]))
+	assert.Error(t, rr2.Delete("missing.txt"))
+
+	// 数量为各后端之和
+	count, err := rr.Count(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// 健康检查按后端顺序返回结果
+	assert.Equal(t, []error{nil, nil}, rr.HealthCheckAll(context.Background()))
+	assert.NoError(t, uploader.Ping(context.Background(), rr))
+	rr3, err := uploader.NewRoundRobin(backends[0], failingUploader{Uploader: backends[1]})
+	assert.NoError(t, err)
+	errs := rr3.HealthCheckAll(context.Background())
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], uploader.ErrNotSupported)
+	assert.ErrorIs(t, rr3.Ping(context.Background()), uploader.ErrNotSupported)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, rr.HealthCheckAll(ctx)[0], context.Canceled)
+}
+
+// 测试本地存储的健康检查不在存储根目录留下探测文件
+func TestLocalPing(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+	assert.NoError(t, up.Ping(context.Background()))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, e := range entries {
+		assert.Equal(t, ".tags", e.Name())
+	}
+	keys, err := up.List("")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
 // extractQiniuKey 从URL中提取七牛云文件key
 func extractKey(url string) string {
 	// 简单实现：去除http://和https://开头部分
@@ -320,3 +776,1234 @@ func extractKey(url string) string {
 	}
 	return url
 }
+
+// 测试按ETag条件下载，未修改时返回 ErrNotModified，文件重写后重新下载
+func TestDownloadIfNoneMatch(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+	_, err = up.UploadBinary("a.txt", []byte("v1"), config.WithKey("poll/a.txt"))
+	assert.NoError(t, err)
+	d := up.(uploader.ConditionalDownloader)
+	ctx := context.Background()
+
+	body, etag, err := d.DownloadIfNoneMatch(ctx, "poll/a.txt", "")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "v1", string(data))
+	assert.NotEmpty(t, etag)
+
+	body, same, err := d.DownloadIfNoneMatch(ctx, "poll/a.txt", `"`+etag+`"`)
+	assert.ErrorIs(t, err, uploader.ErrNotModified)
+	assert.Nil(t, body)
+	assert.Equal(t, etag, same)
+
+	_, err = up.UploadBinary("a.txt", []byte("v2 changed"), config.WithKey("poll/a.txt"))
+	assert.NoError(t, err)
+	body, changed, err := d.DownloadIfNoneMatch(ctx, "poll/a.txt", etag)
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "v2 changed", string(data))
+	assert.NotEqual(t, etag, changed)
+
+	_, _, err = d.DownloadIfNoneMatch(ctx, "poll/missing.txt", etag)
+	assert.ErrorIs(t, err, uploader.ErrNotFound)
+}
+
+// 测试通过ServeObject下载对象，包括Range与If-None-Match
+func TestServeObject(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+	_, err = up.UploadBinary("a.txt", []byte("hello world"), config.WithKey("docs/a.txt"))
+	assert.NoError(t, err)
+
+	serve := func(header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/docs/a.txt", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		assert.NoError(t, uploader.ServeObject(rec, req, up, "docs/a.txt"))
+		return rec
+	}
+
+	rec := serve(nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello world", rec.Body.String())
+	assert.Equal(t, "11", rec.Header().Get("Content-Length"))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.NotEmpty(t, rec.Header().Get("Last-Modified"))
+	etag := rec.Header().Get("ETag")
+
+	rec = serve(map[string]string{"Range": "bytes=6-"})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "world", rec.Body.String())
+	assert.Equal(t, "bytes 6-10/11", rec.Header().Get("Content-Range"))
+
+	rec = serve(map[string]string{"Range": "bytes=-5"})
+	assert.Equal(t, "world", rec.Body.String())
+
+	rec = serve(map[string]string{"Range": "bytes=20-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	rec = serve(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// 对象不存在时由调用方决定响应
+	err = uploader.ServeObject(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), up, "missing.txt")
+	assert.ErrorIs(t, err, uploader.ErrNotFound)
+}
+
+// 测试上传前的大小、扩展名、内容类型与魔数校验
+func TestValidatedUpload(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	opts := uploader.ValidationOpts{
+		MaxSizeBytes:      1024,
+		AllowedMIMETypes:  []string{"image/*"},
+		AllowedExtensions: []string{"png", ".JPG"},
+		StrictMagicBytes:  true,
+	}
+	ctx := context.Background()
+
+	result, err := uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "a.png", png), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", result.ContentType)
+	assert.Equal(t, int64(len(png)), result.Size)
+	assert.Empty(t, result.RequestID)
+	md5Sum, shaSum := md5.Sum(png), sha256.Sum256(png)
+	assert.Equal(t, hex.EncodeToString(md5Sum[:]), result.MD5)
+	assert.Equal(t, hex.EncodeToString(shaSum[:]), result.SHA256)
+
+	result, err = uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "a.png", png), opts, config.WithRequestID("req-42"))
+	assert.NoError(t, err)
+	assert.Equal(t, "req-42", result.RequestID)
+	assert.NotEmpty(t, result.Path)
+
+	_, err = uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "big.png", make([]byte, 2048)), opts)
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+
+	_, err = uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "a.gif", png), opts)
+	assert.ErrorIs(t, err, uploader.ErrFileTypeForbidden)
+
+	// 扩展名允许，但内容不是图片
+	_, err = uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "a.jpg", []byte("plain text")), uploader.ValidationOpts{
+		AllowedMIMETypes: []string{"image/*"},
+	})
+	assert.ErrorIs(t, err, uploader.ErrFileTypeForbidden)
+
+	// 魔数与扩展名不一致
+	_, err = uploader.ValidatedUpload(ctx, up, createTestFileWithContent(t, "a.jpg", png), opts)
+	assert.ErrorIs(t, err, uploader.ErrFileTypeMismatch)
+}
+
+// 测试本地存储签名URL的生成与校验
+func TestLocalSignedURL(t *testing.T) {
+	up := local.New(config.LocalConfig{
+		BasePath:      t.TempDir(),
+		SigningSecret: "secret",
+		BaseURL:       "https://files.example.com/uploads/",
+	})
+
+	signed, err := up.GenerateLocalSignedURL("/docs/报告 1.pdf", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "https://files.example.com/uploads/docs/"), signed)
+
+	key, err := up.VerifyLocalSignedURL(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/报告 1.pdf", key)
+
+	// 反向代理收到的请求URI
+	key, err = up.VerifyLocalSignedURL(strings.TrimPrefix(signed, "https://files.example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/报告 1.pdf", key)
+
+	// 篡改key或过期时间
+	_, err = up.VerifyLocalSignedURL(strings.Replace(signed, "docs", "secret", 1))
+	assert.ErrorIs(t, err, uploader.ErrInvalidSignature)
+	_, err = up.VerifyLocalSignedURL(strings.Replace(signed, "expires=", "expires=9", 1))
+	assert.ErrorIs(t, err, uploader.ErrInvalidSignature)
+
+	signed, err = up.GenerateLocalSignedURL("a.txt", time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(1100 * time.Millisecond)
+	_, err = up.VerifyLocalSignedURL(signed)
+	assert.ErrorIs(t, err, uploader.ErrSignatureExpired)
+
+	// 未配置密钥
+	_, err = local.New(config.LocalConfig{BasePath: t.TempDir()}).GenerateLocalSignedURL("a.txt", time.Minute)
+	assert.ErrorIs(t, err, uploader.ErrNotSupported)
+}
+
+// 测试查询对象大小
+func TestSize(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+	path, err := up.UploadBinary("a.txt", []byte("hello"), config.WithKey("a.txt"))
+	assert.NoError(t, err)
+
+	size, err := uploader.Size(up, path)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+
+	_, err = uploader.Size(up, "missing.txt")
+	assert.ErrorIs(t, err, uploader.ErrNotFound)
+}
+
+// inspectorOnly 只暴露 ObjectInspector 的上传器，指定的key检查失败
+type inspectorOnly struct {
+	uploader.Uploader
+	inspector uploader.ObjectInspector
+	failKey   string
+}
+
+func (u inspectorOnly) GetObjectInfo(key string) (config.ObjectInfo, error) {
+	return u.inspector.GetObjectInfo(key)
+}
+
+func (u inspectorOnly) Exists(key string) (bool, error) {
+	if key == u.failKey {
+		return false, os.ErrPermission
+	}
+	return u.inspector.Exists(key)
+}
+
+// 测试批量判断对象是否存在
+func TestExistsMany(t *testing.T) {
+	up := local.New(config.LocalConfig{BasePath: t.TempDir()})
+	for _, key := range []string{"a.txt", "b.txt"} {
+		_, err := up.UploadBinary(key, []byte("data"), config.WithKey(key))
+		assert.NoError(t, err)
+	}
+	keys := []string{"a.txt", "b.txt", "c.txt", "../d.txt"}
+
+	result, err := uploader.ExistsMany(up, keys)
+	assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+	assert.Equal(t, map[string]bool{"a.txt": true, "b.txt": true, "c.txt": false}, result)
+
+	// 未实现 BatchExister 时并发调用 Exists
+	result, err = uploader.ExistsMany(inspectorOnly{Uploader: up, inspector: up, failKey: "b.txt"}, keys[:3])
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.Equal(t, map[string]bool{"a.txt": true, "c.txt": false}, result)
+}
+
+// 测试按内容去重上传与LRU淘汰
+func TestDeduplicator(t *testing.T) {
+	dir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir})
+	assert.NoError(t, err)
+	d := uploader.NewDeduplicator(up, uploader.NewMemoryDeduplicationStore(1))
+
+	first, err := d.UploadBinary("a.txt", []byte("same"))
+	assert.NoError(t, err)
+	second, err := d.UploadFile(createTestFileWithContent(t, "b.txt", []byte("same")))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// 指定key的上传不参与去重
+	keyed, err := d.UploadBinary("a.txt", []byte("same"), config.WithKey("fixed/a.txt"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, keyed)
+
+	// 容量为1，新内容淘汰旧记录后旧内容会重新上传
+	_, err = d.UploadBinary("c.txt", []byte("other"))
+	assert.NoError(t, err)
+	third, err := d.UploadBinary("a.txt", []byte("same"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, third)
+
+	// 配置中的去重索引由 NewUploader 包装
+	up, err = uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir, DeduplicationStore: uploader.NewMemoryDeduplicationStore(0)})
+	assert.NoError(t, err)
+	assert.IsType(t, &uploader.Deduplicator{}, up)
+	first, err = up.UploadBinary("a.txt", []byte("same"))
+	assert.NoError(t, err)
+	second, err = up.UploadBinary("b.txt", []byte("same"))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+// 测试SSE-C密钥的长度校验，本地存储不支持时不能静默忽略
+func TestCustomerKeyOption(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithCustomerKey(make([]byte, 16)))
+	assert.ErrorIs(t, err, uploader.ErrInvalidEncryptionKey)
+
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithCustomerKey(make([]byte, config.CustomerKeySize)))
+	assert.ErrorIs(t, err, uploader.ErrNotSupported)
+}
+
+// 测试本地存储删除目录时的区分处理
+func TestLocalDeleteDir(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+	_, err := up.UploadBinary("a.txt", []byte("data"), config.WithKey("reports/2026/a.txt"), config.WithTags(map[string]string{"k": "v"}))
+	assert.NoError(t, err)
+
+	// Delete 不删除目录
+	err = up.Delete("reports")
+	assert.ErrorIs(t, err, uploader.ErrIsDirectory)
+	assert.DirExists(t, filepath.Join(dir, "reports"))
+
+	// 不能删除基础目录、标签目录或超出基础目录
+	for _, p := range []string{"", ".", "../", ".tags", "reports/../.."} {
+		assert.Error(t, up.DeleteDir(p), p)
+	}
+	assert.Error(t, up.DeleteDir("reports/2026/a.txt"))
+
+	// 指向基础目录之外的符号链接
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err == nil {
+		assert.NoError(t, os.Mkdir(filepath.Join(outside, "sub"), 0755))
+		assert.ErrorIs(t, up.DeleteDir("link/sub"), uploader.ErrInvalidKey)
+		assert.DirExists(t, filepath.Join(outside, "sub"))
+	}
+
+	assert.NoError(t, up.DeleteDir("reports"))
+	assert.NoDirExists(t, filepath.Join(dir, "reports"))
+	assert.NoDirExists(t, filepath.Join(dir, ".tags", "reports"))
+	assert.ErrorIs(t, up.DeleteDir("reports"), uploader.ErrNotFound)
+}
+
+// 测试按内容大小计算上传超时
+func TestTimeoutPerMB(t *testing.T) {
+	o, err := config.NewUploadOptions(config.WithTimeoutPerMB(2 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, config.MinUploadTimeout, o.UploadTimeout(1024))
+	assert.Equal(t, 100*time.Second, o.UploadTimeout(50<<20))
+	assert.Equal(t, 102*time.Second, o.UploadTimeout(50<<20+1))
+	assert.Equal(t, config.MaxUploadTimeout, o.UploadTimeout(-1))
+
+	// 与 WithTimeout 同时设置时取较小者
+	o, err = config.NewUploadOptions(config.WithTimeoutPerMB(2*time.Second), config.WithTimeout(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, config.MinUploadTimeout, o.UploadTimeout(1024))
+	assert.Equal(t, time.Minute, o.UploadTimeout(50<<20))
+
+	o, err = config.NewUploadOptions()
+	assert.NoError(t, err)
+	assert.Zero(t, o.UploadTimeout(50<<20))
+
+	_, err = config.NewUploadOptions(config.WithTimeout(-time.Second))
+	assert.Error(t, err)
+}
+
+// 测试从环境变量创建默认上传器
+func TestDefaultUploader(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(uploader.EnvDefaultType, "")
+	uploader.Init(nil)
+	defer uploader.Init(nil)
+
+	_, err := uploader.UploadBinary(context.Background(), "a.txt", []byte("data"))
+	assert.ErrorIs(t, err, uploader.ErrInvalidConfig)
+
+	t.Setenv(uploader.EnvDefaultType, "local")
+	t.Setenv("GOSUPLOADER_LOCAL_BASE_PATH", dir)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	path, err := uploader.UploadBinary(ctx, "a.txt", []byte("data"), config.WithKey("a.txt"))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "a.txt"))
+	assert.NoError(t, uploader.Delete(ctx, path))
+	assert.NoFileExists(t, filepath.Join(dir, "a.txt"))
+
+	// Init 替换默认上传器
+	other := t.TempDir()
+	uploader.Init(local.New(config.LocalConfig{BasePath: other}))
+	_, err = uploader.UploadBinary(context.Background(), "a.txt", []byte("data"), config.WithKey("b.txt"))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(other, "b.txt"))
+
+	// 已取消的ctx不发起上传
+	cancel()
+	_, err = uploader.UploadBinary(ctx, "a.txt", []byte("data"))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// 测试统计前缀下的文件数量
+// 测试重复删除时区分"已经删除"与真正的错误
+func TestDeleteIfExists(t *testing.T) {
+	up := local.New(config.LocalConfig{BasePath: t.TempDir()})
+	_, err := up.UploadBinary("x.txt", []byte("data"), config.WithKey("a.txt"))
+	assert.NoError(t, err)
+
+	deleted, err := uploader.DeleteIfExists(context.Background(), up, "a.txt")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	// 重复提交
+	deleted, err = uploader.DeleteIfExists(context.Background(), up, "a.txt")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	assert.ErrorIs(t, up.Delete("a.txt"), uploader.ErrNotFound)
+
+	// 存在性检查失败
+	deleted, err = uploader.DeleteIfExists(context.Background(), inspectorOnly{Uploader: up, inspector: up, failKey: "b.txt"}, "b.txt")
+	assert.Error(t, err)
+	assert.False(t, deleted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = uploader.DeleteIfExists(ctx, up, "a.txt")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// 测试本地存储各上传方法通过 WithResult 报告写入字节数与耗时
+func TestLocalUploadResult(t *testing.T) {
+	up := local.New(config.LocalConfig{BasePath: t.TempDir()})
+	content := []byte("hello world")
+
+	var res uploader.UploadResult
+	_, err := up.UploadBinary("a.txt", content, config.WithResult(&res), config.WithRequestID("req-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Size)
+	assert.Positive(t, res.Duration)
+	assert.Equal(t, "req-1", res.RequestID)
+	md5Sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(md5Sum[:]), res.MD5)
+
+	res = uploader.UploadResult{}
+	_, err = up.UploadBase64("a.txt", base64.StdEncoding.EncodeToString(content), config.WithResult(&res))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Size)
+
+	res = uploader.UploadResult{}
+	_, err = up.UploadFile(createTestFileWithContent(t, "a.txt", content), config.UploadOptions{Result: &res})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), res.Size)
+	assert.Positive(t, res.Duration)
+}
+
+// 测试以 UploadOptions 结构体传入的大小限制与内容扫描同样生效
+func TestUploadOptionsLimits(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+
+	_, err := up.UploadBinary("big.bin", []byte("0123456789"), config.UploadOptions{Key: "big.bin", MaxSize: 3})
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+	assert.NoFileExists(t, filepath.Join(baseDir, "big.bin"))
+
+	_, err = up.UploadBinary("virus.bin", []byte("xxEICARxx"), config.UploadOptions{Key: "virus.bin", Scanner: &virusScanner{}})
+	assert.ErrorIs(t, err, uploader.ErrContentRejected)
+	assert.NoFileExists(t, filepath.Join(baseDir, "virus.bin"))
+
+	_, err = up.UploadBinary("ok.bin", []byte("hello"), config.UploadOptions{Key: "ok.bin", MaxSize: 5, Scanner: &virusScanner{}})
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(baseDir, "ok.bin"))
+}
+
+// 测试直接从multipart请求体流式上传表单文件，跳过其他字段，缺少文件字段时返回 http.ErrMissingFile
+func TestUploadFromMultipartReader(t *testing.T) {
+	dir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir})
+	assert.NoError(t, err)
+
+	newReader := func(field string) *multipart.Reader {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("title", "report")
+		fw, _ := mw.CreateFormFile(field, "../../annual report.pdf")
+		fw.Write([]byte("%PDF-1.4 content"))
+		mw.WriteField("after", "ignored")
+		mw.Close()
+		return multipart.NewReader(&body, mw.Boundary())
+	}
+
+	result, err := uploader.UploadFromMultipartReader(context.Background(), up, newReader("file"), "file")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\d{4}/\d{2}/\d{2}/annual report_\d+\.pdf$`, result.Path)
+	data, err := os.ReadFile(filepath.Join(dir, result.Path))
+	assert.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4 content", string(data))
+
+	result, err = uploader.UploadFromMultipartReader(context.Background(), up, newReader("file"), "file", config.WithKey("docs/report.pdf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/report.pdf", result.Path)
+
+	_, err = uploader.UploadFromMultipartReader(context.Background(), up, newReader("other"), "file")
+	assert.ErrorIs(t, err, http.ErrMissingFile)
+}
+
+// 测试 UploadWriter 完成与中止：中止后不留下部分文件，指定key时原文件保持不变
+func TestUploadWriter(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+
+	w, err := uploader.NewUploadWriter(context.Background(), up, "a.txt")
+	assert.NoError(t, err)
+	io.WriteString(w, "hello ")
+	io.WriteString(w, "world")
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "a.txt", w.Result().Path)
+	assert.Equal(t, int64(11), w.Result().Size)
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	assert.Equal(t, "hello world", string(data))
+
+	// 覆盖已有文件时中止
+	w, err = uploader.NewUploadWriter(context.Background(), up, "a.txt")
+	assert.NoError(t, err)
+	io.WriteString(w, "partial")
+	err = w.Abort(nil)
+	assert.ErrorIs(t, err, uploader.ErrUploadAborted)
+	assert.ErrorIs(t, w.Close(), uploader.ErrUploadAborted)
+	_, err = w.Write([]byte("more"))
+	assert.Error(t, err)
+	data, _ = os.ReadFile(filepath.Join(dir, "a.txt"))
+	assert.Equal(t, "hello world", string(data))
+
+	// 自动生成文件名时中止，ctx取消同样清理
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err = uploader.NewUploadWriter(ctx, up, "", config.WithFilename("b.txt"))
+	assert.NoError(t, err)
+	io.WriteString(w, "partial")
+	cancel()
+	assert.Error(t, w.Close())
+
+	var files []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, d.Name())
+		}
+		return nil
+	})
+	assert.Equal(t, []string{"a.txt"}, files)
+
+	_, err = uploader.NewUploadWriter(context.Background(), uploader.Tx(up), "c.txt")
+	assert.ErrorIs(t, err, uploader.ErrNotSupported)
+}
+
+// 测试本地存储以分隔符生成扁平key，List前缀按原样匹配
+func TestKeySeparator(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir, KeySeparator: "_", MaxFilesPerDir: 1})
+	path, err := up.UploadBinary("image.jpg", []byte("data"))
+	assert.NoError(t, err)
+	date := time.Now().Format("2006_01_02")
+	assert.Regexp(t, `^`+date+`_image_\d+\.jpg$`, path)
+	_, err = os.Stat(filepath.Join(dir, path))
+	assert.NoError(t, err)
+
+	keys, err := up.List(time.Now().Format("2006_01_"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{path}, keys)
+
+	// WithKey 指定的含/的key不受分隔符影响，仍按/查询
+	_, err = up.UploadBinary("a.txt", []byte("data"), config.WithKey("docs/a.txt"))
+	assert.NoError(t, err)
+	keys, err = up.List("docs/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs/a.txt"}, keys)
+
+	// 按扁平前缀移动
+	_, err = up.UploadBinary("b.txt", []byte("data"), config.WithKey("tmp_b.txt"))
+	assert.NoError(t, err)
+	moved, err := uploader.MovePrefix(up, "tmp_", "archive_")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, moved)
+	assert.FileExists(t, filepath.Join(dir, "archive_b.txt"))
+
+	_, err = uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir, KeySeparator: "/"})
+	assert.ErrorIs(t, err, uploader.ErrInvalidConfig)
+}
+
+// 测试未配置持久化时顺序编号在重启后从目录中已有的最大编号继续，不与已有文件冲突
+func TestSequentialRestart(t *testing.T) {
+	date := time.Now().Format("2006/01/02")
+	for _, tc := range []struct {
+		name string
+		cfg  config.LocalConfig
+		want string
+	}{
+		{"date dir", config.LocalConfig{}, date + "/00006.jpg"},
+		{"rollover", config.LocalConfig{MaxFilesPerDir: 2}, date + "/02/00006.jpg"},
+		{"separator", config.LocalConfig{KeySeparator: "_"}, strings.ReplaceAll(date, "/", "_") + "_00006.jpg"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.BasePath = t.TempDir()
+			cfg.KeyStrategy = config.KeyStrategySequential
+			for i := 0; i < 5; i++ {
+				cfg.Sequence = config.NewSequence(0, nil)
+				_, err := local.New(cfg).UploadBinary("a.jpg", []byte("data"))
+				assert.NoError(t, err)
+			}
+
+			// 模拟重启：新的计数器与上传器
+			cfg.Sequence = config.NewSequence(0, nil)
+			key, err := local.New(cfg).UploadBinary("a.jpg", []byte("data"))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, filepath.ToSlash(key))
+		})
+	}
+}
+
+// zeroStore 总是从0开始编号的计数器存储，用于制造文件名冲突
+type zeroStore struct{}
+
+func (zeroStore) Load(string) (uint64, error) { return 0, nil }
+func (zeroStore) Save(string, uint64) error   { return nil }
+
+// 测试生成的文件名已被占用时重新生成，超过重试次数返回 ErrTooManyCollisions 且不覆盖已有文件
+func TestCollisionRetries(t *testing.T) {
+	baseDir := t.TempDir()
+	dateDir := filepath.Join(baseDir, filepath.FromSlash(time.Now().Format("2006/01/02")))
+	assert.NoError(t, os.MkdirAll(dateDir, 0755))
+	for _, name := range []string{"00001.jpg", "00002.jpg"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dateDir, name), []byte("existing"), 0644))
+	}
+	newUploader := func(retries int) *local.LocalUploader {
+		return local.New(config.LocalConfig{
+			BasePath:         baseDir,
+			KeyStrategy:      config.KeyStrategySequential,
+			Sequence:         config.NewSequence(0, zeroStore{}),
+			CollisionRetries: retries,
+		})
+	}
+
+	// 00001、00002 已存在，第二次重试成功
+	key, err := newUploader(2).UploadBinary("a.jpg", []byte("new"))
+	assert.NoError(t, err)
+	assert.Equal(t, "00003.jpg", filepath.Base(key))
+
+	// 只重试一次时 00001、00002 都冲突
+	_, err = newUploader(1).UploadBinary("a.jpg", []byte("new"))
+	assert.ErrorIs(t, err, uploader.ErrTooManyCollisions)
+	for _, name := range []string{"00001.jpg", "00002.jpg"} {
+		data, err := os.ReadFile(filepath.Join(dateDir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, "existing", string(data))
+	}
+}
+
+// 测试按扩展名与内容类型将生成的key路由到不同前缀，Exists、Delete、List使用完整key
+func TestRoutingRules(t *testing.T) {
+	rules := &config.RoutingRules{
+		Rules: []config.RoutingRule{
+			{Extensions: []string{".JPG", "png"}, ContentTypes: []string{"image/*"}, Prefix: "images"},
+			{ContentTypes: []string{"application/pdf"}, Prefix: "docs/"},
+		},
+		Default: "others",
+	}
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir(), RoutingRules: rules})
+	assert.NoError(t, err)
+	lu := up.(*local.LocalUploader)
+
+	date := time.Now().Format("2006/01/02")
+	for _, tc := range []struct {
+		name   string
+		opts   []config.UploadOption
+		prefix string
+	}{
+		{"photo.jpg", nil, "images/"},
+		{"icon.webp", nil, "images/"},
+		{"report.pdf", nil, "docs/"},
+		{"blob", []config.UploadOption{config.WithContentType("image/gif")}, "images/"},
+		{"notes.txt", nil, "others/"},
+	} {
+		key, err := up.UploadBinary(tc.name, []byte("data"), tc.opts...)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key, tc.prefix+date+"/"), key)
+
+		exists, err := lu.Exists(key)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+
+	keys, err := lu.List("images/")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+	assert.NoError(t, up.Delete(keys[0]))
+	keys, err = lu.List("images/")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// WithKey 指定的key不受路由影响
+	key, err := up.UploadBinary("a.jpg", []byte("data"), config.WithKey("custom/a.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "custom/a.jpg", key)
+
+	_, err = uploader.NewUploader(uploader.Local, config.LocalConfig{
+		BasePath:     t.TempDir(),
+		RoutingRules: &config.RoutingRules{Default: "../outside"},
+	})
+	assert.ErrorIs(t, err, uploader.ErrInvalidConfig)
+
+	// 直接调用 local.New 时不校验配置，生成的key在写入前被拒绝
+	root := t.TempDir()
+	baseDir := filepath.Join(root, "uploads")
+	unsafe := local.New(config.LocalConfig{BasePath: baseDir, RoutingRules: &config.RoutingRules{Default: "../outside"}})
+	_, err = unsafe.UploadBinary("a.txt", []byte("data"))
+	assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+	assert.NoDirExists(t, filepath.Join(root, "outside"))
+}
+
+// 测试按新规则迁移key：跳过无变化的映射，单个失败不中断，重新调用时从失败处继续
+func TestRemap(t *testing.T) {
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: t.TempDir()})
+	assert.NoError(t, err)
+	for _, key := range []string{"2025/07/01/a.jpg", "2025/07/02/b.jpg", "2025/07/02/bad.jpg", "images/c.jpg"} {
+		_, err := up.UploadBinary("x.jpg", []byte(key), config.WithKey(key))
+		assert.NoError(t, err)
+	}
+
+	broken := true
+	mapper := func(oldKey string) string {
+		if strings.HasPrefix(oldKey, "images/") {
+			return oldKey
+		}
+		if broken && strings.HasSuffix(oldKey, "bad.jpg") {
+			return "../bad.jpg"
+		}
+		return "images/" + oldKey[strings.LastIndex(oldKey, "/")+1:]
+	}
+
+	migrated, err := uploader.Remap(up, mapper)
+	assert.Equal(t, 2, migrated)
+	assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+
+	broken = false
+	migrated, err = uploader.Remap(up, mapper)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	keys, err := up.(uploader.Lister).List("")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"images/a.jpg", "images/b.jpg", "images/bad.jpg", "images/c.jpg"}, keys)
+}
+
+// 测试迁移时的key冲突：多个对象映射到同一新key、新key已存在时都不移动，已有对象不被覆盖
+func TestRemapConflicts(t *testing.T) {
+	dir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: dir})
+	assert.NoError(t, err)
+	for _, key := range []string{"a/x.jpg", "b/x.jpg", "c/y.jpg", "flat/y.jpg", "d/z.jpg"} {
+		_, err := up.UploadBinary("x.jpg", []byte(key), config.WithKey(key))
+		assert.NoError(t, err)
+	}
+
+	migrated, err := uploader.Remap(up, func(oldKey string) string {
+		if strings.HasPrefix(oldKey, "flat/") {
+			return ""
+		}
+		return "flat/" + oldKey[strings.LastIndex(oldKey, "/")+1:]
+	})
+	assert.Equal(t, 1, migrated)
+	assert.ErrorIs(t, err, uploader.ErrObjectExists)
+	assert.Contains(t, err.Error(), "a/x.jpg")
+	assert.Contains(t, err.Error(), "b/x.jpg")
+	assert.Contains(t, err.Error(), "c/y.jpg")
+
+	keys, err := up.(uploader.Lister).List("")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a/x.jpg", "b/x.jpg", "c/y.jpg", "flat/y.jpg", "flat/z.jpg"}, keys)
+	data, err := os.ReadFile(filepath.Join(dir, "flat", "y.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "flat/y.jpg", string(data))
+}
+
+// 测试记录上传时间：复制后修改时间改变而上传时间不变，未记录时回退为修改时间
+func TestUploadedAt(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+	before := time.Now()
+	_, err := up.UploadBinary("x.txt", []byte("data"), config.WithKey("a.txt"), config.WithUploadedAt(),
+		config.WithTags(map[string]string{"k": "v"}))
+	assert.NoError(t, err)
+
+	info, err := up.GetObjectInfo("a.txt")
+	assert.NoError(t, err)
+	assert.False(t, info.UploadedAt.Before(before.Truncate(time.Second)))
+	assert.False(t, info.UploadedAt.After(time.Now()))
+	tags, err := up.GetTags("a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", tags["k"])
+
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, up.Copy(context.Background(), "a.txt", "b.txt"))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "b.txt"), later, later))
+	copied, err := up.GetObjectInfo("b.txt")
+	assert.NoError(t, err)
+	assert.True(t, copied.UploadedAt.Equal(info.UploadedAt))
+	assert.False(t, copied.LastModified.Equal(copied.UploadedAt))
+
+	_, err = up.UploadBinary("x.txt", []byte("data"), config.WithKey("c.txt"))
+	assert.NoError(t, err)
+	plain, err := up.GetObjectInfo("c.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, plain.LastModified, plain.UploadedAt)
+}
+
+func TestCount(t *testing.T) {
+	up := local.New(config.LocalConfig{BasePath: t.TempDir()})
+	for _, key := range []string{"logs/a.txt", "logs/b.txt", "logs/2026/c.txt", "img/d.png"} {
+		_, err := up.UploadBinary("x.txt", []byte("data"), config.WithKey(key), config.WithTags(map[string]string{"k": "v"}))
+		assert.NoError(t, err)
+	}
+
+	count, err := up.Count(context.Background(), "logs/")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// 标签旁路文件不计入
+	count, err = up.Count(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	count, err = up.Count(context.Background(), "none/")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// 测试列举前缀不能越出基础路径
+func TestListPrefixTraversal(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644))
+	up := local.New(config.LocalConfig{BasePath: filepath.Join(root, "base")})
+	_, err := up.UploadBinary("a.txt", []byte("data"), config.WithKey("docs/a.txt"))
+	assert.NoError(t, err)
+
+	for _, prefix := range []string{"../", "../secret", "docs/../../", `..\`} {
+		keys, err := up.List(prefix)
+		if err != nil {
+			assert.ErrorIs(t, err, config.ErrInvalidKey, prefix)
+		}
+		assert.Empty(t, keys, prefix)
+		_, err = up.Count(context.Background(), prefix)
+		if err != nil {
+			assert.ErrorIs(t, err, config.ErrInvalidKey, prefix)
+		}
+	}
+	_, err = up.List("../")
+	assert.ErrorIs(t, err, config.ErrInvalidKey)
+
+	keys, err := up.List("docs/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs/a.txt"}, keys)
+}
+
+// listerOnly 只暴露 Lister 的上传器
+type listerOnly struct {
+	uploader.Uploader
+	lister uploader.Lister
+}
+
+func (u listerOnly) List(prefix string) ([]string, error) {
+	return u.lister.List(prefix)
+}
+
+// 测试将小对象读取为data URI
+func TestDownloadDataURI(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	_, err := up.UploadBinary("a.png", png, config.WithKey("icons/a.png"))
+	assert.NoError(t, err)
+
+	uri, err := uploader.DownloadDataURI(up, "icons/a.png", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(png), uri)
+
+	// 去掉前缀后可作为 UploadBase64 的输入还原
+	_, err = up.UploadBase64("b.png", strings.TrimPrefix(uri, "data:image/png;base64,"), config.WithKey("b.png"))
+	assert.NoError(t, err)
+	restored, err := os.ReadFile(filepath.Join(dir, "b.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, png, restored)
+
+	_, err = uploader.DownloadDataURI(up, "icons/a.png", 4)
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+
+	_, err = uploader.DownloadDataURI(up, "icons/none.png", 1024)
+	assert.ErrorIs(t, err, uploader.ErrNotFound)
+}
+
+// failingUploader 上传指定文件名时失败的上传器
+type failingUploader struct {
+	uploader.Uploader
+	failName string
+}
+
+func (u failingUploader) UploadBinary(filename string, content []byte, opts ...config.UploadOption) (string, error) {
+	if filename == u.failName {
+		return "", os.ErrPermission
+	}
+	return u.Uploader.UploadBinary(filename, content, opts...)
+}
+
+// 测试按映射批量上传与原子回滚
+func TestUploadMap(t *testing.T) {
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir})
+	files := map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("bb"), "c.txt": []byte("ccc")}
+
+	var audited int
+	var mu sync.Mutex
+	results, err := uploader.UploadMap(context.Background(), up, files, uploader.UploadMapOptions{Workers: 2},
+		config.WithRequestID("req-1"),
+		config.WithAuditSink(func(config.AuditRecord) { mu.Lock(); audited++; mu.Unlock() }))
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, int64(2), results["b.txt"].Size)
+	assert.Equal(t, "req-1", results["b.txt"].RequestID)
+	assert.Equal(t, "21ad0bd836b90d08f4cf640b4c298e7c", results["b.txt"].MD5)
+	assert.Equal(t, 3, audited, "调用方的审计回调仍被调用")
+	for _, r := range results {
+		assert.FileExists(t, filepath.Join(dir, r.Path))
+	}
+
+	// 非原子模式保留成功的文件
+	failing := failingUploader{Uploader: up, failName: "b.txt"}
+	results, err = uploader.UploadMap(context.Background(), failing, files, uploader.UploadMapOptions{})
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.Len(t, results, 2)
+
+	// 原子模式删除已成功上传的文件
+	before, err := up.List("")
+	assert.NoError(t, err)
+	results, err = uploader.UploadMap(context.Background(), failing, files, uploader.UploadMapOptions{Workers: 1, Atomic: true})
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.Nil(t, results)
+	after, err := up.List("")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, before, after)
+
+	_, err = uploader.UploadMap(context.Background(), up, files, uploader.UploadMapOptions{}, config.WithKey("x.txt"))
+	assert.ErrorIs(t, err, uploader.ErrInvalidKey)
+}
+
+// 测试上传后核对大小
+func TestVerifyAfterUpload(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+
+	path, err := up.UploadBinary("a.txt", []byte("data"), config.WithVerifyAfterUpload())
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(baseDir, path))
+
+	err = config.VerifySize(func() (int64, error) { return 3, nil }, 4)
+	assert.ErrorIs(t, err, uploader.ErrVerificationFailed)
+	err = config.VerifySize(func() (int64, error) { return 0, uploader.ErrNotFound }, 4)
+	assert.ErrorIs(t, err, uploader.ErrVerificationFailed)
+}
+
+// 测试将对象的一段读入调用方缓冲区
+func TestReadRangeInto(t *testing.T) {
+	up := local.New(config.LocalConfig{BasePath: t.TempDir()})
+	_, err := up.UploadBinary("a.txt", []byte("0123456789"), config.WithKey("a.txt"))
+	assert.NoError(t, err)
+
+	// 未实现 RangeIntoReader 时以 DownloadRange 读取，两种方式结果一致
+	for _, u := range []uploader.Uploader{up, rangeReaderOnly{Uploader: up, reader: up}} {
+		buf := make([]byte, 4)
+		n, err := uploader.ReadRangeInto(u, "a.txt", 2, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "2345", string(buf[:n]))
+
+		n, err = uploader.ReadRangeInto(u, "a.txt", 8, buf)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, "89", string(buf[:n]))
+
+		n, err = uploader.ReadRangeInto(u, "a.txt", 10, buf)
+		assert.Equal(t, io.EOF, err)
+		assert.Zero(t, n)
+
+		_, err = uploader.ReadRangeInto(u, "none.txt", 0, buf)
+		assert.ErrorIs(t, err, uploader.ErrNotFound)
+	}
+
+	_, err = uploader.ReadRangeInto(listerOnly{Uploader: up, lister: up}, "a.txt", 0, make([]byte, 4))
+	assert.ErrorIs(t, err, uploader.ErrNotSupported)
+}
+
+// rangeReaderOnly 只暴露 RangeReader 的上传器
+type rangeReaderOnly struct {
+	uploader.Uploader
+	reader uploader.RangeReader
+}
+
+func (u rangeReaderOnly) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return u.reader.DownloadRange(ctx, key, offset, length)
+}
+
+// 测试上传 fs.File
+func TestUploadFSFile(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	fsys := fstest.MapFS{
+		"static/logo.png": {Data: png},
+		"static/a.txt":    {Data: []byte("hello")},
+	}
+
+	f, err := fsys.Open("static/logo.png")
+	assert.NoError(t, err)
+	result, err := up.UploadFSFile(context.Background(), f, "assets/logo.png", config.WithRequestID("req-1"))
+	f.Close()
+	assert.NoError(t, err)
+	md5Sum, shaSum := md5.Sum(png), sha256.Sum256(png)
+	assert.Positive(t, result.Duration)
+	result.Duration = 0
+	assert.Equal(t, uploader.UploadResult{
+		Path: "assets/logo.png", Size: int64(len(png)), ContentType: "image/png", RequestID: "req-1",
+		MD5: hex.EncodeToString(md5Sum[:]), SHA256: hex.EncodeToString(shaSum[:]),
+	}, result)
+	data, err := os.ReadFile(filepath.Join(baseDir, "assets", "logo.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, png, data)
+
+	// key为空时按文件名生成
+	f, err = fsys.Open("static/a.txt")
+	assert.NoError(t, err)
+	result, err = up.UploadFSFile(context.Background(), f, "")
+	f.Close()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\d{4}/\d{2}/\d{2}/a_\d+\.txt$`, filepath.ToSlash(result.Path))
+	assert.Equal(t, "text/plain; charset=utf-8", result.ContentType)
+
+	dir, err := fsys.Open("static")
+	assert.NoError(t, err)
+	_, err = up.UploadFSFile(context.Background(), dir, "static")
+	assert.ErrorIs(t, err, uploader.ErrIsDirectory)
+
+	// ctx已取消时中止上传
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f, err = fsys.Open("static/a.txt")
+	assert.NoError(t, err)
+	_, err = up.UploadFSFile(ctx, f, "canceled.txt")
+	f.Close()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(baseDir, "canceled.txt"))
+}
+
+func TestTransaction(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+
+	// 回滚删除本次上传和复制的对象，并保留调用方的审计回调
+	var audited []string
+	tx := uploader.Tx(up)
+	_, err := tx.UploadBinary("a.txt", []byte("a"), config.WithKey("tx/a.txt"),
+		config.WithAuditSink(func(r config.AuditRecord) { audited = append(audited, r.Key) }))
+	assert.NoError(t, err)
+	_, err = tx.UploadBinary("b.txt", []byte("b"), config.WithKey("tx/b.txt"))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Copy(context.Background(), "tx/a.txt", "tx/c.txt"))
+	assert.Equal(t, []string{"tx/a.txt"}, audited)
+	assert.Len(t, tx.Keys(), 3)
+	assert.NoError(t, tx.Rollback())
+	assert.Empty(t, tx.Keys())
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		assert.NoFileExists(t, filepath.Join(baseDir, "tx", name))
+	}
+
+	// 提交后对象保留，之后的回滚不再删除
+	_, err = tx.UploadBinary("keep.txt", []byte("k"), config.WithKey("tx/keep.txt"))
+	assert.NoError(t, err)
+	tx.Commit()
+	assert.NoError(t, tx.Rollback())
+	assert.FileExists(t, filepath.Join(baseDir, "tx", "keep.txt"))
+
+	// 部分删除失败时继续删除其余对象，错误汇总返回，失败的key保留
+	_, err = tx.UploadBinary("x.txt", []byte("x"), config.WithKey("tx/x.txt"))
+	assert.NoError(t, err)
+	_, err = tx.UploadBinary("y.txt", []byte("y"), config.WithKey("tx/y.txt"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Remove(filepath.Join(baseDir, "tx", "x.txt")))
+	err = tx.Rollback()
+	assert.ErrorContains(t, err, "rollback tx/x.txt")
+	assert.NoFileExists(t, filepath.Join(baseDir, "tx", "y.txt"))
+	assert.Equal(t, []string{"tx/x.txt"}, tx.Keys())
+}
+
+// 测试内容寻址的key：相同内容得到相同路径且不重复写入
+func TestContentAddressedKey(t *testing.T) {
+	baseDir := t.TempDir()
+	up, err := uploader.NewUploader(uploader.Local, config.LocalConfig{BasePath: baseDir, KeyStrategy: config.KeyStrategyContentAddressed})
+	assert.NoError(t, err)
+
+	path, err := up.UploadBinary("a.txt", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256/2c/f2/2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824.txt", filepath.ToSlash(path))
+
+	// 已存在时跳过写入，不会覆盖现有文件
+	fullPath := filepath.Join(baseDir, path)
+	assert.NoError(t, os.WriteFile(fullPath, []byte("marker"), 0644))
+	again, err := up.UploadFile(createTestFileWithContent(t, "b.txt", []byte("hello")))
+	assert.NoError(t, err)
+	assert.Equal(t, path, again)
+	data, err := os.ReadFile(fullPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "marker", string(data))
+
+	other, err := up.UploadBinary("c.txt", []byte("world"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, path, other)
+	assert.FileExists(t, filepath.Join(baseDir, other))
+
+	// 不可Seek的流在计算摘要的同一次读取中受大小限制与扫描约束，失败时不留下临时文件
+	stream := up.(uploader.StreamUploader)
+	big := io.MultiReader(bytes.NewReader(bytes.Repeat([]byte("x"), 1<<20)))
+	_, err = stream.UploadStream(context.Background(), "", big, config.WithFilename("big.bin"), config.WithMaxSize(1024))
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+	_, err = stream.UploadStream(context.Background(), "", io.MultiReader(strings.NewReader("xxEICARxx")),
+		config.WithFilename("virus.bin"), config.WithScanner(&virusScanner{}))
+	assert.ErrorIs(t, err, uploader.ErrContentRejected)
+	entries, err := os.ReadDir(baseDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	result, err := stream.UploadStream(context.Background(), "", io.MultiReader(strings.NewReader("world")), config.WithFilename("d.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, other, result.Path)
+}
+
+// 测试本地文件服务的Range请求
+func TestLocalFileServer(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+	content := []byte("0123456789abcdefghij")
+	_, err := up.UploadBinary("clip.mp4", content, config.WithKey("media/clip.mp4"), config.WithTags(map[string]string{"k": "v"}))
+	assert.NoError(t, err)
+	server := http.StripPrefix("/files", up.FileServer())
+
+	get := func(path, rng string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/files/media/clip.mp4", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "video/mp4", rec.Header().Get("Content-Type"))
+	assert.Equal(t, content, rec.Body.Bytes())
+
+	rec = get("/files/media/clip.mp4", "bytes=5-9")
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "bytes 5-9/20", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "56789", rec.Body.String())
+	assert.Equal(t, "video/mp4", rec.Header().Get("Content-Type"))
+
+	rec = get("/files/media/clip.mp4", "bytes=-4")
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "ghij", rec.Body.String())
+
+	rec = get("/files/media/clip.mp4", "bytes=100-")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	assert.Equal(t, http.StatusNotFound, get("/files/media/missing.mp4", "").Code)
+	assert.Equal(t, http.StatusNotFound, get("/files/media", "").Code)
+	assert.Equal(t, http.StatusNotFound, get("/files/.tags/media/clip.mp4.json", "").Code, "不提供标签旁路文件")
+}
+
+// 测试key字符策略：生成的key只含安全字符，Delete/Exists使用原始key同样能找到文件
+func TestKeyCharPolicy(t *testing.T) {
+	assert.Equal(t, "docs/my-report_2026_-中文.pdf", config.KeyCharPolicyURLSafe.Apply("docs/my report+2026#-中文.pdf"))
+	assert.Equal(t, "docs/a-b%2B%23%E4%B8%AD.pdf", config.KeyCharPolicyStrictASCII.Apply("docs/a b+#中.pdf"))
+	// 重复应用结果不变
+	for _, p := range []*config.KeyCharPolicy{config.KeyCharPolicyURLSafe, config.KeyCharPolicyStrictASCII} {
+		once := p.Apply("a b+%#?&=中.png")
+		assert.Equal(t, once, p.Apply(once))
+	}
+	custom := &config.KeyCharPolicy{Replace: map[rune]string{'#': "-sharp-"}}
+	assert.Equal(t, "c-sharp-/a b.txt", custom.Apply("c#/a b.txt"))
+	var none *config.KeyCharPolicy
+	assert.Equal(t, "a b.txt", none.Apply("a b.txt"))
+
+	dir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: dir, KeyCharPolicy: config.KeyCharPolicyURLSafe})
+	path, err := up.UploadBinary("my photo+1#.png", []byte("data"))
+	assert.NoError(t, err)
+	assert.NotContains(t, path, " ")
+	assert.NotContains(t, path, "+")
+	assert.NotContains(t, path, "#")
+
+	path, err = up.UploadBinary("x.png", []byte("data"), config.WithKey("albums/summer trip/a+b.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("albums/summer-trip/a_b.png"), path)
+
+	exists, err := up.Exists("albums/summer trip/a+b.png")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, up.Delete("albums/summer trip/a+b.png"))
+	exists, err = up.Exists("albums/summer-trip/a_b.png")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// 删除时标签旁路文件同样按转换后的key清理
+	_, err = up.UploadBinary("x.txt", []byte("data"), config.WithKey("a b.txt"), config.WithTags(map[string]string{"k": "v"}))
+	assert.NoError(t, err)
+	tagsFile := filepath.Join(dir, ".tags", "a-b.txt.json")
+	assert.FileExists(t, tagsFile)
+	assert.NoError(t, up.Delete("a b.txt"))
+	assert.NoFileExists(t, tagsFile)
+}
+
+// countingFile 统计从底层文件读取的字节数
+type countingFile struct {
+	fs.File
+	read int
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.read += n
+	return n, err
+}
+
+// virusScanner 内容中出现特征串时拒绝
+type virusScanner struct {
+	buf bytes.Buffer
+}
+
+func (s *virusScanner) Write(p []byte) (int, error) { return s.buf.Write(p) }
+
+func (s *virusScanner) Verdict() error {
+	if bytes.Contains(s.buf.Bytes(), []byte("EICAR")) {
+		return errors.New("malware detected")
+	}
+	return nil
+}
+
+// 测试摘要、扫描与大小限制在同一次读取中完成
+func TestSinglePassPipeline(t *testing.T) {
+	baseDir := t.TempDir()
+	up := local.New(config.LocalConfig{BasePath: baseDir})
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	fsys := fstest.MapFS{"a.bin": {Data: content}, "virus.bin": {Data: []byte("xxEICARxx")}}
+
+	f, err := fsys.Open("a.bin")
+	assert.NoError(t, err)
+	file := &countingFile{File: f}
+	scanner := &virusScanner{}
+	result, err := up.UploadFSFile(context.Background(), file, "a.bin", config.WithScanner(scanner), config.WithMaxSize(1<<20))
+	assert.NoError(t, err)
+	// 内容只读取一次，扫描器与摘要都观察到了完整内容
+	assert.Equal(t, len(content), file.read)
+	assert.Equal(t, content, scanner.buf.Bytes())
+	shaSum := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(shaSum[:]), result.SHA256)
+
+	// 扫描拒绝时不保留文件
+	f, err = fsys.Open("virus.bin")
+	assert.NoError(t, err)
+	_, err = up.UploadFSFile(context.Background(), f, "virus.bin", config.WithScanner(&virusScanner{}))
+	assert.ErrorIs(t, err, uploader.ErrContentRejected)
+	assert.NoFileExists(t, filepath.Join(baseDir, "virus.bin"))
+
+	// 超出大小限制
+	_, err = up.UploadBinary("big.bin", content, config.WithKey("big.bin"), config.WithMaxSize(1000))
+	assert.ErrorIs(t, err, uploader.ErrFileTooLarge)
+	assert.NoFileExists(t, filepath.Join(baseDir, "big.bin"))
+}
@@ -0,0 +1,121 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 04:05:37
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 04:05:37
+ * Description: 以文件名到内容的映射批量上传，可选失败时回滚
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// defaultUploadMapWorkers UploadMap 的默认并发数
+const defaultUploadMapWorkers = 4
+
+// UploadMapOptions UploadMap 的参数
+type UploadMapOptions struct {
+	// Workers 并发上传的文件数，默认4
+	Workers int
+	// Atomic 任一文件失败时删除本次已成功上传的文件，并且不再开始新的上传
+	// 回滚是尽力而为的：删除失败的文件会残留，删除错误会汇总到返回的错误中
+	Atomic bool
+}
+
+// UploadMap 以有限并发上传files中的每个文件，键为原始文件名，值为内容，结果按原始文件名返回
+// 每个文件以 UploadBinary 上传，uploadOpts作用于每个文件，因此不能包含 config.WithKey。
+// 非原子模式下部分失败时仍返回成功文件的结果，错误汇总返回；原子模式下任一失败都返回nil结果。
+// ctx结束后不再开始新的上传，未开始的文件以ctx的错误计为失败
+func UploadMap(ctx context.Context, u Uploader, files map[string][]byte, opts UploadMapOptions, uploadOpts ...config.UploadOption) (map[string]UploadResult, error) {
+	o, err := config.NewUploadOptions(uploadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if o.Key != "" {
+		return nil, fmt.Errorf("%w: UploadMap does not accept a fixed key", ErrInvalidKey)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultUploadMapWorkers
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]UploadResult, len(files))
+		keys    = make(map[string]string, len(files)) // 文件名 -> 存储key，用于回滚
+		errs    []error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				if err := ctx.Err(); err != nil || (opts.Atomic && failed()) {
+					if err != nil {
+						mu.Lock()
+						errs = append(errs, fmt.Errorf("%s: %w", name, err))
+						mu.Unlock()
+					}
+					continue
+				}
+
+				// 通过审计回调取得存储key与摘要，云存储的上传方法返回的是URL，不能直接用于删除
+				var rec config.AuditRecord
+				sink := config.WithAuditSink(func(r config.AuditRecord) {
+					rec = r
+					if o.AuditSink != nil {
+						o.AuditSink(r)
+					}
+				})
+				path, err := u.UploadBinary(name, files[name], append(uploadOpts[:len(uploadOpts):len(uploadOpts)], sink)...)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				} else {
+					results[name] = UploadResult{Path: path, Size: int64(len(files[name])), RequestID: o.RequestID, MD5: rec.MD5, SHA256: rec.SHA256}
+					keys[name] = rec.Key
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for name := range files {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return results, nil
+	}
+	if !opts.Atomic {
+		return results, errors.Join(errs...)
+	}
+
+	// 回滚已成功上传的文件；没有审计记录的文件(如去重命中了已有对象)并非本次写入，不删除
+	for name, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := u.Delete(key); err != nil {
+			errs = append(errs, fmt.Errorf("rollback %s: %w", name, err))
+		}
+	}
+	return nil, errors.Join(errs...)
+}
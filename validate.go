@@ -0,0 +1,121 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/17 23:02:44
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/17 23:02:44
+ * Description: 表单文件上传前的大小与类型校验
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/zjguoxin/gosuploader/config"
+	"github.com/zjguoxin/gosuploader/internal/magic"
+	"github.com/zjguoxin/gosuploader/internal/mime"
+)
+
+// ValidationOpts 表单文件上传前的校验规则，零值字段表示不校验该项
+type ValidationOpts struct {
+	// MaxSizeBytes 文件大小上限，超出时返回 ErrFileTooLarge
+	MaxSizeBytes int64
+	// AllowedMIMETypes 允许的内容类型，支持 image/* 形式的通配
+	// 内容类型按文件内容嗅探，无法识别时按扩展名推断，不信任客户端提交的Content-Type
+	AllowedMIMETypes []string
+	// AllowedExtensions 允许的扩展名，如 .jpg 或 jpg，不区分大小写
+	AllowedExtensions []string
+	// StrictMagicBytes 按文件头魔数与扩展名交叉校验，不一致时返回 ErrFileTypeMismatch
+	StrictMagicBytes bool
+}
+
+// UploadResult 上传结果
+type UploadResult = config.UploadResult
+
+// ValidatedUpload 完成全部校验后再调用 UploadFile 上传表单文件
+// 超出大小返回 ErrFileTooLarge，扩展名或内容类型不在允许范围内返回 ErrFileTypeForbidden，
+// 魔数与扩展名不一致返回 ErrFileTypeMismatch
+func ValidatedUpload(ctx context.Context, u Uploader, file *multipart.FileHeader, opts ValidationOpts, uploadOpts ...config.UploadOption) (UploadResult, error) {
+	if file == nil {
+		return UploadResult{}, fmt.Errorf("%w: file header is nil", ErrInvalidFilename)
+	}
+	o, err := config.NewUploadOptions(uploadOpts...)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if opts.MaxSizeBytes > 0 && file.Size > opts.MaxSizeBytes {
+		return UploadResult{}, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrFileTooLarge, file.Size, opts.MaxSizeBytes)
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if len(opts.AllowedExtensions) > 0 && !allowedExtension(opts.AllowedExtensions, ext) {
+		return UploadResult{}, fmt.Errorf("%w: extension %q", ErrFileTypeForbidden, ext)
+	}
+
+	contentType, err := inspectFile(file, opts.StrictMagicBytes)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if len(opts.AllowedMIMETypes) > 0 && !allowedMIMEType(opts.AllowedMIMETypes, contentType) {
+		return UploadResult{}, fmt.Errorf("%w: content type %q", ErrFileTypeForbidden, contentType)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return UploadResult{}, err
+	}
+	result := UploadResult{Size: file.Size, ContentType: contentType, RequestID: o.RequestID}
+	o.CaptureDigest(&result)
+	path, err := u.UploadFile(file, append(uploadOpts[:len(uploadOpts):len(uploadOpts)], config.WithAuditSink(o.AuditSink))...)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	result.Path = path
+	return result, nil
+}
+
+// inspectFile 读取文件开头识别内容类型，strict为true时同时校验魔数
+func inspectFile(file *multipart.FileHeader, strict bool) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	head, r, err := mime.Peek(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file header: %w", err)
+	}
+	if strict {
+		if _, err := magic.Validate(r, file.Filename, config.MagicByteRules); err != nil {
+			return "", err
+		}
+	}
+	return mime.Resolver{DetectMIME: true}.ResolveContentType(file.Filename, head, ""), nil
+}
+
+// allowedExtension 判断扩展名是否在允许列表中
+func allowedExtension(allowed []string, ext string) bool {
+	for _, a := range allowed {
+		if a = strings.ToLower(a); !strings.HasPrefix(a, ".") {
+			a = "." + a
+		}
+		if a == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMIMEType 判断内容类型是否匹配允许列表中的任一模式
+func allowedMIMEType(allowed []string, contentType string) bool {
+	for _, pattern := range allowed {
+		if mime.Match(pattern, contentType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+/**
+ * @Author: guxline zjguoxin@163.com
+ * @Date: 2026/10/18 07:41:26
+ * @LastEditors: guxline zjguoxin@163.com
+ * @LastEditTime: 2026/10/18 07:41:26
+ * Description: 以 io.Writer 方式上传，可在完成前中止并清理
+ * Copyright: Copyright (©) 2025 中易综服. All rights reserved.
+ */
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/zjguoxin/gosuploader/config"
+)
+
+// StreamUploader 可流式上传大小未知内容的上传器，四种存储后端均已实现
+// 本地存储写入文件(指定key时经临时文件重命名)，云存储使用分片上传；
+// ctx取消、超时或读取r失败时中止上传并清理：本地删除已写入的部分，云存储中止分片上传，均不会留下不完整的对象
+type StreamUploader interface {
+	UploadStream(ctx context.Context, key string, r io.Reader, opts ...config.UploadOption) (UploadResult, error)
+}
+
+// UploadWriter 以 io.Writer 方式上传，写入的内容经管道交给 StreamUploader.UploadStream
+// 全部写完后调用 Close 完成上传；客户端断开等需要放弃时调用 Abort，上传中止并清理，不会生成对象
+type UploadWriter struct {
+	pw     *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	once   sync.Once
+	mu     sync.Mutex
+	cause  error // Abort的原因
+	result UploadResult
+	err    error
+}
+
+// NewUploadWriter 创建上传到key的 UploadWriter，u未实现 StreamUploader 时返回 ErrNotSupported
+// 上传在后台进行，ctx取消时同样中止；key为空时按 WithFilename 指定的文件名生成
+func NewUploadWriter(ctx context.Context, u Uploader, key string, opts ...config.UploadOption) (*UploadWriter, error) {
+	s, ok := u.(StreamUploader)
+	if !ok {
+		return nil, fmt.Errorf("uploader cannot stream uploads: %w", ErrNotSupported)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	w := &UploadWriter{pw: pw, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		result, err := s.UploadStream(ctx, key, pr, opts...)
+		// 中止时上传可能先看到ctx取消，统一包装中止的原因
+		w.mu.Lock()
+		if cause := w.cause; err != nil && cause != nil && !errors.Is(err, cause) {
+			err = fmt.Errorf("%w: %w", cause, err)
+		}
+		w.mu.Unlock()
+		w.result, w.err = result, err
+		// 上传提前结束(失败)时让后续的Write返回错误，而不是阻塞
+		if err != nil {
+			pr.CloseWithError(err)
+		} else {
+			pr.Close()
+		}
+	}()
+	return w, nil
+}
+
+// Write 写入内容，上传已失败或已中止时返回错误
+func (w *UploadWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close 结束写入并等待上传完成，返回上传的错误；可重复调用，Abort之后返回中止的错误
+func (w *UploadWriter) Close() error {
+	w.once.Do(func() {
+		w.pw.Close()
+		<-w.done
+		w.cancel()
+	})
+	return w.err
+}
+
+// Abort 放弃上传并等待清理完成后返回，cause为nil时使用 ErrUploadAborted；Close之后调用不会撤销已完成的上传
+// 返回上传的最终错误，中止时包装了cause
+func (w *UploadWriter) Abort(cause error) error {
+	if cause == nil {
+		cause = ErrUploadAborted
+	}
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.cause = cause
+		w.mu.Unlock()
+		w.pw.CloseWithError(cause)
+		w.cancel()
+		<-w.done
+	})
+	return w.err
+}
+
+// Result 返回上传结果，Close成功之后有效
+func (w *UploadWriter) Result() UploadResult {
+	<-w.done
+	return w.result
+}